@@ -0,0 +1,46 @@
+// Command docsgen generates the markdown reference of a module config struct declared in Go source files,
+// publishable to the module registry.
+//
+// Usage:
+//
+//	docsgen -dir ./pkg/config -type Config -title "MySQL module" -out docs/config.md
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion-module-framework/pkg/schemagen"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the Go package declaring the config struct")
+	typeName := flag.String("type", "", "name of the config struct")
+	title := flag.String("title", "", "title of the reference, defaults to the type name")
+	out := flag.String("out", "", "output markdown file, defaults to stdout")
+	flag.Parse()
+
+	if *typeName == "" {
+		fmt.Fprintln(os.Stderr, "docsgen: -type is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	schemas, err := schemagen.FromSource(*dir, *typeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *title == "" {
+		*title = *typeName
+	}
+	content := schemagen.RenderMarkdown(*title, schemas)
+	if *out == "" {
+		_, _ = os.Stdout.Write(content)
+		return
+	}
+	if err = os.WriteFile(*out, content, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,162 @@
+//go:build !wasip1
+
+// Command kusion-module scaffolds new Kusion module repositories and replays recorded module requests.
+//
+// Usage:
+//
+//	kusion-module init [-dir DIR] [-module MODULE_PATH] NAME
+//	kusion-module replay -plugin BINARY RECORDING...
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*
+var templates embed.FS
+
+// files maps the generated files to their templates.
+var files = map[string]string{
+	"main.go":                       "main.go.tmpl",
+	"generator.go":                  "generator.go.tmpl",
+	"generator_test.go":             "generator_test.go.tmpl",
+	"testdata/default.request.yaml": "request.yaml.tmpl",
+	"testdata/default.golden.yaml":  "golden.yaml.tmpl",
+	"Makefile":                      "Makefile.tmpl",
+	"go.mod":                        "go.mod.tmpl",
+	"README.md":                     "README.md.tmpl",
+	"kcl.mod":                       "kcl.mod.tmpl",
+	"{{ .Name }}.k":                 "schema.k.tmpl",
+}
+
+type scaffold struct {
+	// Name is the module name, e.g. mysql
+	Name string
+	// TypeName is the Go type implementing the module, e.g. Mysql
+	TypeName string
+	// ModulePath is the Go module path of the new repository
+	ModulePath string
+}
+
+const usage = `usage:
+  kusion-module init [-dir DIR] [-module MODULE_PATH] NAME
+  kusion-module replay -plugin BINARY RECORDING...`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "init":
+		err = runInit(os.Args[2:])
+	case "replay":
+		err = runReplay(os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kusion-module: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the new module, defaults to NAME")
+	modulePath := fs.String("module", "", "Go module path of the new module, defaults to NAME")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	name := fs.Arg(0)
+	s := &scaffold{Name: name, TypeName: typeName(name), ModulePath: *modulePath}
+	if s.ModulePath == "" {
+		s.ModulePath = name
+	}
+	if *dir == "" {
+		*dir = name
+	}
+	if err := s.generate(*dir); err != nil {
+		return err
+	}
+	fmt.Printf("module %s created in %s, run `go mod tidy && make test` to get started\n", name, *dir)
+	return nil
+}
+
+func (s *scaffold) generate(dir string) error {
+	if s.TypeName == "" {
+		return fmt.Errorf("invalid module name %q", s.Name)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for file, tmpl := range files {
+		path, err := s.render(file)
+		if err != nil {
+			return err
+		}
+		content, err := templates.ReadFile("templates/" + tmpl)
+		if err != nil {
+			return err
+		}
+		out, err := s.render(string(content))
+		if err != nil {
+			return fmt.Errorf("render %s failed: %w", tmpl, err)
+		}
+		path = filepath.Join(dir, path)
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err = os.WriteFile(path, []byte(out), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scaffold) render(text string) (string, error) {
+	t, err := template.New("").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err = t.Execute(buf, s); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// typeName converts a module name such as "mysql" or "network-policy" into an exported Go type name.
+func typeName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || (unicode.IsDigit(r) && b.Len() > 0):
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
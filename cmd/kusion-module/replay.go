@@ -0,0 +1,46 @@
+//go:build !wasip1
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// runReplay re-feeds recordings dumped with KUSION_MODULE_RECORD into a local build of the module
+// and prints the generated resources, so issues reported from user environments can be debugged offline.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	binary := fs.String("plugin", "", "path of the module plugin binary")
+	_ = fs.Parse(args)
+	if *binary == "" || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	conn, kill, err := module.LaunchPlugin(*binary, nil)
+	if err != nil {
+		return err
+	}
+	defer kill()
+
+	for _, path := range fs.Args() {
+		r, err := module.LoadRecording(path)
+		if err != nil {
+			return err
+		}
+		resp, err := r.Replay(context.Background(), conn)
+		if err != nil {
+			return fmt.Errorf("replay %s failed: %w", path, err)
+		}
+		fmt.Printf("# %s: %d resources\n", path, len(resp.Resources))
+		for _, res := range resp.Resources {
+			fmt.Printf("---\n%s", res)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Command schemagen generates the KCL schema of a module config struct declared in Go source files.
+//
+// Usage:
+//
+//	schemagen -dir ./pkg/config -type Config -out schema.k
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion-module-framework/pkg/schemagen"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the Go package declaring the config struct")
+	typeName := flag.String("type", "", "name of the config struct")
+	out := flag.String("out", "", "output KCL file, defaults to stdout")
+	flag.Parse()
+
+	if *typeName == "" {
+		fmt.Fprintln(os.Stderr, "schemagen: -type is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	schemas, err := schemagen.FromSource(*dir, *typeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	content := schemagen.Render(schemas)
+	if *out == "" {
+		_, _ = os.Stdout.Write(content)
+		return
+	}
+	if err = os.WriteFile(*out, content, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
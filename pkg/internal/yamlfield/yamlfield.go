@@ -0,0 +1,83 @@
+// Package yamlfield maps struct fields to YAML keys following the rules of yaml.v2, shared by the strict
+// decoder, the request redaction and the schema generators so that they agree on the keys of configs.
+package yamlfield
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Tag is a parsed yaml struct tag.
+type Tag struct {
+	// Name is the key of the field, the lower-cased field name if the tag sets none
+	Name string
+	// OmitEmpty is set by the omitempty option
+	OmitEmpty bool
+	// Inline is set by the inline option
+	Inline bool
+	// Skip is set by the "-" tag
+	Skip bool
+}
+
+// Parse parses the yaml tag of the field named fieldName.
+func Parse(fieldName, tag string) Tag {
+	if tag == "-" {
+		return Tag{Skip: true}
+	}
+	parts := strings.Split(tag, ",")
+	t := Tag{Name: parts[0]}
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			t.OmitEmpty = true
+		case "inline":
+			t.Inline = true
+		}
+	}
+	if t.Name == "" {
+		t.Name = strings.ToLower(fieldName)
+	}
+	return t
+}
+
+// Field is a struct field with its parsed yaml tag.
+type Field struct {
+	reflect.StructField
+	YAML Tag
+}
+
+// Walk calls fn with the fields of struct t in declaration order, descending into inlined structs and
+// pointers to structs. The Index of the fields passed to fn is relative to t, for reflect.Value.FieldByIndex.
+// Unexported fields, except inlined embedded structs, and fields tagged "-" are skipped. Inlined fields of other kinds, e.g. inline maps, are
+// passed to fn with YAML.Inline set. Walk stops at and returns the first error of fn.
+func Walk(t reflect.Type, fn func(f Field) error) error {
+	return walk(t, nil, fn)
+}
+
+func walk(t reflect.Type, index []int, fn func(f Field) error) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := Parse(sf.Name, sf.Tag.Get("yaml"))
+		// the exported fields of unexported embedded structs are settable when inlined
+		if tag.Skip || !sf.IsExported() && !(sf.Anonymous && tag.Inline) {
+			continue
+		}
+		sf.Index = append(append([]int(nil), index...), i)
+		if tag.Inline {
+			ft := sf.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := walk(ft, sf.Index, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := fn(Field{StructField: sf, YAML: tag}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package yamlfield
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want Tag
+	}{
+		{tag: "", want: Tag{Name: "replicas"}},
+		{tag: "count", want: Tag{Name: "count"}},
+		{tag: ",omitempty", want: Tag{Name: "replicas", OmitEmpty: true}},
+		{tag: "spec,inline", want: Tag{Name: "spec", Inline: true}},
+		{tag: "-", want: Tag{Skip: true}},
+	}
+	for _, tt := range tests {
+		if got := Parse("Replicas", tt.tag); got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+type base struct {
+	Name string `yaml:"name"`
+}
+
+type Labels struct {
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type config struct {
+	base    `yaml:",inline"`
+	*Labels `yaml:",inline"`
+	Extra   map[string]any `yaml:",inline"`
+	Image   string
+	Ignored string `yaml:"-"`
+	private string
+}
+
+func TestWalk(t *testing.T) {
+	var keys []string
+	var indexes [][]int
+	err := Walk(reflect.TypeOf(config{}), func(f Field) error {
+		name := f.YAML.Name
+		if f.YAML.Inline {
+			name = "inline:" + f.Name
+		}
+		keys = append(keys, name)
+		indexes = append(indexes, f.Index)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if want := []string{"name", "labels", "inline:Extra", "image"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Walk() keys = %v, want %v", keys, want)
+	}
+	if want := [][]int{{0, 0}, {1, 0}, {2}, {3}}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("Walk() indexes = %v, want %v", indexes, want)
+	}
+}
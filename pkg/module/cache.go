@@ -0,0 +1,169 @@
+package module
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize and DefaultCacheTTL are the size and TTL of the cache shared by all Generate calls of
+// a plugin process.
+const (
+	DefaultCacheSize = 1024
+	DefaultCacheTTL  = 10 * time.Minute
+)
+
+// Cache is a concurrent-safe LRU cache with TTL, used by modules to avoid repeating expensive
+// external lookups, such as querying the latest AMI, for every stack of a preview.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   CacheStats
+	onEvict []func(key string, value any)
+}
+
+// CacheStats are the metrics of a Cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+type cacheEntry struct {
+	key      string
+	value    any
+	expireAt time.Time
+}
+
+// NewCache returns a Cache holding at most size entries, each expiring ttl after it is set.
+// Entries never expire if ttl is zero.
+func NewCache(size int, ttl time.Duration) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{size: size, ttl: ttl, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+var defaultCache = NewCache(DefaultCacheSize, DefaultCacheTTL)
+
+type cacheKey struct{}
+
+// ContextWithCache returns a copy of ctx carrying c.
+func ContextWithCache(ctx context.Context, c *Cache) context.Context {
+	return context.WithValue(ctx, cacheKey{}, c)
+}
+
+// CacheFrom returns the cache carried by ctx, or the cache shared by the plugin process if ctx carries none.
+func CacheFrom(ctx context.Context) *Cache {
+	if c, ok := ctx.Value(cacheKey{}).(*Cache); ok && c != nil {
+		return c
+	}
+	return defaultCache
+}
+
+// Get returns the value of key and whether it is found and not expired.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.removeElement(elem)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.value, true
+}
+
+// Set sets the value of key, evicting the least recently used entry if the cache is full.
+func (c *Cache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expireAt time.Time
+	if c.ttl > 0 {
+		expireAt = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.value, entry.expireAt = value, expireAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expireAt: expireAt})
+	for c.lru.Len() > c.size {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// GetOrLoad returns the value of key, calling load and caching its result on a miss.
+// Errors returned by load are not cached.
+func (c *Cache) GetOrLoad(key string, load func() (any, error)) (any, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.Set(key, v)
+	return v, nil
+}
+
+// Delete invalidates the entry of key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Purge invalidates all entries.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// OnEvict registers a hook called with the key and value of every entry removed from the cache,
+// whether evicted, expired or invalidated. Hooks are called with the cache locked and must not use it.
+func (c *Cache) OnEvict(hook func(key string, value any)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = append(c.onEvict, hook)
+}
+
+// Stats returns the metrics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Len returns the number of entries in the cache, including expired ones not yet removed.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.stats.Evictions++
+	for _, hook := range c.onEvict {
+		hook(entry.key, entry.value)
+	}
+}
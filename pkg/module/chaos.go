@@ -0,0 +1,190 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// ChaosEnv is the environment variable of the faults injected into modules served with WithChaosFromEnv,
+// in the format of ParseChaos. It is ignored by modules not opting in.
+const ChaosEnv = "KUSION_MODULE_CHAOS"
+
+// Chaos are the faults injected around the Generate calls of a module, to test that the engine retries
+// transient failures and handles slow and canceled calls of real modules. Faults are injected at
+// random with the given rates, between 0 and 1. It must not be enabled in production.
+type Chaos struct {
+	// Latency is the delay added before every Generate call
+	Latency time.Duration
+	// Jitter is the max random delay added to Latency
+	Jitter time.Duration
+	// FailureRate is the rate of calls failing with ErrCodeUnavailable, which is a transient gRPC
+	// failure for the engine, before Generate is called
+	FailureRate float64
+	// CancelRate is the rate of calls whose context is canceled while Generate runs
+	CancelRate float64
+	// CancelAfter is the max random time after which the context of calls is canceled, immediately
+	// if zero
+	CancelAfter time.Duration
+	// Seed is the seed of the random faults, which differ between runs if zero
+	Seed int64
+}
+
+// WithChaos injects the faults of c into the Generate calls of the module. It overrides WithChaosFromEnv.
+func WithChaos(c Chaos) ServeOption {
+	return func(o *serveOptions) {
+		o.chaos = &c
+	}
+}
+
+// WithChaosFromEnv injects the faults in ChaosEnv, if set, into the Generate calls of the module, e.g.
+// in test builds of modules. Without this option or WithChaos no faults are injected.
+func WithChaosFromEnv() ServeOption {
+	return func(o *serveOptions) {
+		o.chaosFromEnv = true
+	}
+}
+
+// ChaosFromEnv returns the faults in ChaosEnv, or nil if it is not set.
+func ChaosFromEnv() (*Chaos, error) {
+	s := os.Getenv(ChaosEnv)
+	if s == "" {
+		return nil, nil
+	}
+	return ParseChaos(s)
+}
+
+// ParseChaos parses faults in the format of comma-separated key=value pairs of the fields of Chaos,
+// e.g. "latency=200ms,jitter=100ms,failure=0.2,cancel=0.1,cancelAfter=50ms,seed=42".
+func ParseChaos(s string) (*Chaos, error) {
+	c := &Chaos{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chaos fault %q, expected key=value", pair)
+		}
+		var err error
+		switch strings.TrimSpace(key) {
+		case "latency":
+			c.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			c.Jitter, err = time.ParseDuration(value)
+		case "failure":
+			c.FailureRate, err = parseRate(value)
+		case "cancel":
+			c.CancelRate, err = parseRate(value)
+		case "cancelAfter":
+			c.CancelAfter, err = time.ParseDuration(value)
+		case "seed":
+			c.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return nil, fmt.Errorf("unknown chaos fault %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid chaos fault %q. %w", pair, err)
+		}
+	}
+	return c, nil
+}
+
+func parseRate(s string) (float64, error) {
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("rate %v is not between 0 and 1", rate)
+	}
+	return rate, nil
+}
+
+// chaosConfig returns the configured faults, logging that they are enabled.
+func (o *serveOptions) chaosConfig() *Chaos {
+	c := o.chaos
+	if c == nil && o.chaosFromEnv {
+		var err error
+		if c, err = ChaosFromEnv(); err != nil {
+			log.Errorf("parse %s failed, no faults are injected: %v", ChaosEnv, err)
+			return nil
+		}
+	}
+	if c != nil {
+		log.Warnf("chaos faults are injected into module %s: %+v", o.name, *c)
+	}
+	return c
+}
+
+// chaosSource is the random source of the faults of a wrapper.
+type chaosSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (s *chaosSource) float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64()
+}
+
+func (s *chaosSource) duration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.rand.Int63n(int64(max)))
+}
+
+// injectChaos injects the faults of the wrapper into a Generate call, returning the context the module
+// is called with and the function releasing it.
+func (f *FrameworkModuleWrapper) injectChaos(ctx context.Context) (context.Context, func(), error) {
+	c := f.Chaos
+	if c == nil {
+		return ctx, func() {}, nil
+	}
+	f.chaosOnce.Do(func() {
+		seed := c.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		f.chaosSource = &chaosSource{rand: rand.New(rand.NewSource(seed))}
+	})
+	src := f.chaosSource
+
+	if delay := c.Latency + src.duration(c.Jitter); delay > 0 {
+		observeChaos(f.Name, "latency")
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, f.contextError(ctx)
+		}
+	}
+	if c.FailureRate > 0 && src.float64() < c.FailureRate {
+		observeChaos(f.Name, "failure")
+		return nil, nil, NewError(ErrCodeUnavailable, "chaos: injected transient failure of %s", f.Name).
+			WithHint("the failure is injected by the chaos faults of the module, see " + ChaosEnv)
+	}
+	if c.CancelRate > 0 && src.float64() < c.CancelRate {
+		observeChaos(f.Name, "cancel")
+		ctx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(src.duration(c.CancelAfter), cancel)
+		return ctx, func() {
+			timer.Stop()
+			cancel()
+		}, nil
+	}
+	return ctx, func() {}, nil
+}
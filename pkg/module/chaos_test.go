@@ -0,0 +1,31 @@
+package module
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChaosConfig(t *testing.T) {
+	t.Setenv(ChaosEnv, "failure=1")
+	tests := []struct {
+		name string
+		opts []ServeOption
+		want *Chaos
+	}{
+		{name: "env ignored without opt-in"},
+		{name: "env", opts: []ServeOption{WithChaosFromEnv()}, want: &Chaos{FailureRate: 1}},
+		{name: "explicit faults override env", opts: []ServeOption{WithChaosFromEnv(), WithChaos(Chaos{Latency: time.Second})}, want: &Chaos{Latency: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &serveOptions{}
+			for _, opt := range tt.opts {
+				opt(o)
+			}
+			got := o.chaosConfig()
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("chaosConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
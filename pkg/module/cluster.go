@@ -0,0 +1,112 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// TargetCluster is the identity of the Kubernetes cluster the resources are applied to.
+type TargetCluster struct {
+	// KubeConfig is the kubeconfig file the identity is read from, empty if none is configured
+	KubeConfig string
+	// Context is the current context of the kubeconfig
+	Context string
+	// Cluster is the name of the cluster of the current context
+	Cluster string
+	// Server is the API server URL of the cluster
+	Server string
+	// Namespace is the default namespace of the current context
+	Namespace string
+	// Version is the Kubernetes version declared in the platform module config, empty if not declared.
+	// Generating is offline, so the version is never read from the cluster itself.
+	Version string
+}
+
+// kubeConfigFile is the subset of a kubeconfig file read by TargetCluster.
+type kubeConfigFile struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+}
+
+// TargetCluster returns the identity of the target cluster, read from the current context of the
+// kubeconfig of the Kubernetes runtime, and the version declared by platform engineers under
+// PlatformConfigKubernetesVersionKey. Only the version is set if no kubeconfig is configured.
+func (r *GeneratorRequest) TargetCluster() (*TargetCluster, error) {
+	tc := &TargetCluster{}
+	if v, ok := r.PlatformModuleConfig[PlatformConfigKubernetesVersionKey]; ok && v != nil {
+		s, ok := v.(string)
+		if !ok {
+			return nil, NewError(ErrCodeInvalidConfig, "kubernetes version %v must be a string", v).
+				WithHint("quote the kubernetesVersion of the platform module config, e.g. \"1.30\", as YAML reads 1.30 as 1.3")
+		}
+		tc.Version = s
+		if _, err := version.ParseGeneric(tc.Version); err != nil {
+			return nil, NewError(ErrCodeInvalidConfig, "invalid kubernetes version %q: %v", tc.Version, err).
+				WithHint("set the kubernetesVersion of the platform module config to a version like 1.28")
+		}
+	}
+	// KUBECONFIG may list several files, the first one with a current context is used like kubectl does
+	for _, path := range filepath.SplitList(r.Runtime().Kubernetes().KubeConfig) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read kubeconfig %s failed. %w", path, err)
+		}
+		kc := &kubeConfigFile{}
+		if err = yaml.Unmarshal(data, kc); err != nil {
+			return nil, fmt.Errorf("unmarshal kubeconfig %s failed. %w", path, err)
+		}
+		if kc.CurrentContext == "" {
+			continue
+		}
+		tc.KubeConfig = path
+		tc.Context = kc.CurrentContext
+		for _, c := range kc.Contexts {
+			if c.Name == kc.CurrentContext {
+				tc.Cluster = c.Context.Cluster
+				tc.Namespace = c.Context.Namespace
+			}
+		}
+		for _, c := range kc.Clusters {
+			if c.Name == tc.Cluster {
+				tc.Server = c.Cluster.Server
+			}
+		}
+		break
+	}
+	return tc, nil
+}
+
+// AtLeast reports whether the version of the cluster is at least min, e.g. AtLeast("1.19") to choose
+// networking.k8s.io/v1 Ingresses. It returns true if the version is unknown, as clusters are assumed
+// to be recent unless declared otherwise.
+func (c *TargetCluster) AtLeast(min string) bool {
+	if c.Version == "" {
+		return true
+	}
+	current, err := version.ParseGeneric(c.Version)
+	if err != nil {
+		return true
+	}
+	return current.AtLeast(version.MustParseGeneric(min))
+}
@@ -0,0 +1,25 @@
+package module
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// CompressionGzip is the name of the gzip compressor registered in module plugins. Plugins decompress
+// requests compressed with it and compress their responses with the compressor of the request, so the
+// compression is negotiated by the host per call.
+const CompressionGzip = gzip.Name
+
+// CompressedCall returns the call option compressing the request of module RPCs with gzip, used by
+// hosts of modules to cut the transfer size of big workloads and responses, e.g.
+//
+//	client.Generate(ctx, req, module.CompressedCall())
+func CompressedCall() grpc.CallOption {
+	return grpc.UseCompressor(CompressionGzip)
+}
+
+// SetCompressionLevel sets the gzip level of the responses of module plugins, ranging from
+// gzip.BestSpeed to gzip.BestCompression. Defaults to gzip.DefaultCompression.
+func SetCompressionLevel(level int) error {
+	return gzip.SetLevel(level)
+}
@@ -0,0 +1,136 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// testResources returns n Deployments like the ones generated for real apps.
+func testResources(n int) []v1.Resource {
+	resources := make([]v1.Resource, n)
+	for i := range resources {
+		name := fmt.Sprintf("app-%d", i)
+		resources[i] = v1.Resource{
+			ID:   "apps/v1:Deployment:default:" + name,
+			Type: v1.Kubernetes,
+			Attributes: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]any{
+					"name":      name,
+					"namespace": "default",
+					"labels":    map[string]any{"app.kubernetes.io/name": name, "app.kubernetes.io/managed-by": "kusion"},
+				},
+				"spec": map[string]any{
+					"replicas": 2,
+					"selector": map[string]any{"matchLabels": map[string]any{"app.kubernetes.io/name": name}},
+					"template": map[string]any{
+						"metadata": map[string]any{"labels": map[string]any{"app.kubernetes.io/name": name}},
+						"spec": map[string]any{
+							"containers": []any{map[string]any{
+								"name":  "main",
+								"image": "nginx:1.25",
+								"ports": []any{map[string]any{"containerPort": 80, "protocol": "TCP"}},
+								"resources": map[string]any{
+									"limits":   map[string]any{"cpu": "500m", "memory": "512Mi"},
+									"requests": map[string]any{"cpu": "250m", "memory": "256Mi"},
+								},
+							}},
+						},
+					},
+				},
+			},
+		}
+	}
+	return resources
+}
+
+type resourcesTestModule struct {
+	resources []v1.Resource
+}
+
+func (m resourcesTestModule) Generate(context.Context, *GeneratorRequest) (*GeneratorResponse, error) {
+	return &GeneratorResponse{Resources: m.resources, PreserveOrder: true}, nil
+}
+
+// countingConn counts the bytes read from the module, i.e. the size of its responses on the wire.
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+// newCountingConn serves a module generating n resources and returns the client connection to it with
+// the counter of the bytes received.
+func newCountingConn(tb testing.TB, n int) (*grpc.ClientConn, *atomic.Int64) {
+	tb.Helper()
+	read := &atomic.Int64{}
+	w := &FrameworkModuleWrapper{Module: resourcesTestModule{resources: testResources(n)}, Name: "compression", Logger: hclog.NewNullLogger()}
+	conn := newTestConn(tb, w, func(c net.Conn) net.Conn { return countingConn{Conn: c, read: read} })
+	// connect before counting, so that only the calls are counted
+	if _, err := invokeFramework(context.Background(), conn, "Ready", nil); err != nil {
+		tb.Fatalf("call Ready failed: %v", err)
+	}
+	read.Store(0)
+	return conn, read
+}
+
+func generateStreamResources(tb testing.TB, conn *grpc.ClientConn, n int, opts ...grpc.CallOption) {
+	tb.Helper()
+	resp, _, err := GenerateStream(context.Background(), conn, &proto.GeneratorRequest{Project: "p", Stack: "dev", App: "app"}, opts...)
+	if err != nil {
+		tb.Fatalf("GenerateStream() error = %v", err)
+	}
+	if len(resp.Resources) != n {
+		tb.Fatalf("GenerateStream() returned %d resources, want %d", len(resp.Resources), n)
+	}
+}
+
+func TestCompressedCall(t *testing.T) {
+	const n = 1000
+	conn, read := newCountingConn(t, n)
+	generateStreamResources(t, conn, n)
+	plain := read.Swap(0)
+	generateStreamResources(t, conn, n, CompressedCall())
+	compressed := read.Load()
+	t.Logf("%d resources: %d bytes uncompressed, %d bytes compressed", n, plain, compressed)
+	// every resource is compressed on its own as a message of the stream
+	if compressed*2 > plain {
+		t.Errorf("compressed response of %d bytes is not half of %d bytes", compressed, plain)
+	}
+}
+
+func BenchmarkCompressedCall(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		for _, bc := range []struct {
+			name string
+			opts []grpc.CallOption
+		}{
+			{name: "identity"},
+			{name: "gzip", opts: []grpc.CallOption{CompressedCall()}},
+		} {
+			b.Run(fmt.Sprintf("resources=%d/%s", n, bc.name), func(b *testing.B) {
+				conn, read := newCountingConn(b, n)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					generateStreamResources(b, conn, n, bc.opts...)
+				}
+				b.ReportMetric(float64(read.Load())/float64(b.N), "wire-bytes/op")
+			})
+		}
+	}
+}
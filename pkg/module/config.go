@@ -0,0 +1,228 @@
+package module
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// DecodeDevConfig decodes the developer's inputs of this module into out, which must be
+// a pointer to a struct with yaml tags. Unknown fields in the config are ignored unless the
+// module is served with WithStrictDecoding.
+func (r *GeneratorRequest) DecodeDevConfig(out interface{}) error {
+	if err := decodeConfig(r.DevModuleConfig, out, "devModuleConfig", r.strict); err != nil {
+		return fmt.Errorf("decode dev module config failed. %w", err)
+	}
+	return nil
+}
+
+// DecodeDevConfigStrict is like DecodeDevConfig but returns an error if the config
+// contains fields that do not exist in out, which is a DecodeErrors with the paths of the fields.
+func (r *GeneratorRequest) DecodeDevConfigStrict(out interface{}) error {
+	if err := decodeConfig(r.DevModuleConfig, out, "devModuleConfig", true); err != nil {
+		return fmt.Errorf("decode dev module config failed. %w", err)
+	}
+	return nil
+}
+
+// DecodePlatformConfig decodes the platform engineer's inputs of this module into out, which
+// must be a pointer to a struct with yaml tags. Unknown fields in the config are ignored unless
+// the module is served with WithStrictDecoding.
+func (r *GeneratorRequest) DecodePlatformConfig(out interface{}) error {
+	if err := decodeConfig(r.PlatformModuleConfig, out, "platformModuleConfig", r.strict, platformConfigReservedKeys...); err != nil {
+		return fmt.Errorf("decode platform module config failed. %w", err)
+	}
+	return nil
+}
+
+// DecodePlatformConfigStrict is like DecodePlatformConfig but returns an error if the config
+// contains fields that do not exist in out, which is a DecodeErrors with the paths of the fields.
+func (r *GeneratorRequest) DecodePlatformConfigStrict(out interface{}) error {
+	if err := decodeConfig(r.PlatformModuleConfig, out, "platformModuleConfig", true, platformConfigReservedKeys...); err != nil {
+		return fmt.Errorf("decode platform module config failed. %w", err)
+	}
+	return nil
+}
+
+// decodeConfig converts the raw config map into out by a yaml round trip, so that
+// the yaml tags of out are honored. In strict mode the keys unknown to out are reported
+// with their paths under root, except the reserved top-level keys read by the framework.
+func decodeConfig(config any, out interface{}, root string, strict bool, reserved ...string) error {
+	if out == nil {
+		return fmt.Errorf("decode target is nil")
+	}
+	if strict {
+		checked := config
+		if m, ok := asStringMap(config); ok && len(reserved) > 0 {
+			filtered := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				if !containsString(reserved, k) {
+					filtered[k] = v
+				}
+			}
+			checked = filtered
+		}
+		t := reflect.TypeOf(out)
+		if errs := checkUnknownFields(checked, t, root, strings.TrimPrefix(t.String(), "*")); len(errs) > 0 {
+			return errs
+		}
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// PlatformConfigNamespaceKey is the key of the platform module config overriding the
+// Kubernetes namespace of the generated resources.
+const PlatformConfigNamespaceKey = "namespace"
+
+// PlatformConfigFeatureGatesKey is the key of the platform module config enabling module behaviors
+// per workspace, a map of feature names to booleans read by FeatureEnabled.
+const PlatformConfigFeatureGatesKey = "featureGates"
+
+// PlatformConfigKubernetesVersionKey is the key of the platform module config declaring the Kubernetes
+// version of the target cluster as a string, e.g. "1.28", read by TargetCluster.
+const PlatformConfigKubernetesVersionKey = "kubernetesVersion"
+
+// PlatformConfigPoliciesKey is the key of the platform module config supplying Rego policies evaluated
+// against the generated resources, a map of policy names to Rego sources read by the policy package.
+const PlatformConfigPoliciesKey = "policies"
+
+// platformConfigReservedKeys are the keys of the platform module config read by the framework,
+// which are not reported as unknown by strict decoding.
+var platformConfigReservedKeys = []string{PlatformConfigNamespaceKey, PlatformConfigFeatureGatesKey, PlatformConfigKubernetesVersionKey, PlatformConfigPoliciesKey, PlatformConfigEnvironmentKey, PlatformConfigSecretKeyKey}
+
+// Namespace returns the effective Kubernetes namespace of the generated resources. The namespace
+// set by platform engineers in the platform module config takes precedence over the app name,
+// which is the default namespace of Kusion applications.
+func (r *GeneratorRequest) Namespace() string {
+	if ns, ok := r.PlatformModuleConfig[PlatformConfigNamespaceKey].(string); ok && ns != "" {
+		return ns
+	}
+	return r.App
+}
+
+// ValidateNamespace checks whether the effective namespace is a valid Kubernetes namespace name.
+func (r *GeneratorRequest) ValidateNamespace() error {
+	ns := r.Namespace()
+	if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+		return NewError(ErrCodeInvalidConfig, "invalid namespace %q: %s", ns, strings.Join(errs, "; ")).
+			WithHint("set a valid namespace in the platform module config")
+	}
+	return nil
+}
+
+// MergeConfigs merges the configs of a module with the documented precedence: the developer's config
+// overrides the platform config, which overrides defaults. Maps are merged deeply while lists and scalars
+// are replaced as a whole. The merged config is decoded into a new T, which must be a struct with yaml tags.
+func MergeConfigs[T any](dev v1.Accessory, platform v1.GenericConfig, defaults T) (*T, error) {
+	data, err := yaml.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("marshal default config failed. %w", err)
+	}
+	var merged interface{}
+	if err = yaml.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("unmarshal default config failed. %w", err)
+	}
+	merged = mergeValues(merged, map[string]interface{}(platform))
+	merged = mergeValues(merged, map[string]interface{}(dev))
+
+	out := new(T)
+	if err = decodeConfig(merged, out, "", false); err != nil {
+		return nil, fmt.Errorf("decode merged config failed. %w", err)
+	}
+	return out, nil
+}
+
+// mergeValues merges override into base. Maps are merged key by key, any other value of override
+// replaces base unless it is nil. Nil and empty maps, e.g. a request without platform config, leave base
+// unchanged.
+func mergeValues(base, override interface{}) interface{} {
+	if v := reflect.ValueOf(override); v.Kind() == reflect.Map && (v.IsNil() || v.Len() == 0) {
+		return base
+	}
+	overrideMap, ok := asStringMap(override)
+	if !ok {
+		if override == nil {
+			return base
+		}
+		return override
+	}
+	baseMap, ok := asStringMap(base)
+	if !ok {
+		baseMap = map[string]interface{}{}
+	}
+	merged := make(map[string]interface{}, len(baseMap)+len(overrideMap))
+	for k, v := range baseMap {
+		merged[k] = v
+	}
+	for k, v := range overrideMap {
+		merged[k] = mergeValues(merged[k], v)
+	}
+	return merged
+}
+
+// asStringMap converts the map types produced by yaml decoding into a map with string keys.
+func asStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, m != nil
+	case v1.GenericConfig:
+		return m, m != nil
+	case v1.Accessory:
+		return m, m != nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// FeatureGates returns the feature gates in the platform module config. The values must be booleans
+// or the strings "true" and "false".
+func (r *GeneratorRequest) FeatureGates() (map[string]bool, error) {
+	raw, ok := r.PlatformModuleConfig[PlatformConfigFeatureGatesKey]
+	if !ok || raw == nil {
+		return map[string]bool{}, nil
+	}
+	m, ok := asStringMap(raw)
+	if !ok {
+		return nil, NewError(ErrCodeInvalidConfig, "%s must be a map of feature names to booleans", PlatformConfigFeatureGatesKey)
+	}
+	gates := make(map[string]bool, len(m))
+	for name, v := range m {
+		switch enabled := v.(type) {
+		case bool:
+			gates[name] = enabled
+		case string:
+			b, err := strconv.ParseBool(enabled)
+			if err != nil {
+				return nil, NewError(ErrCodeInvalidConfig, "invalid value %q of feature gate %s", enabled, name)
+			}
+			gates[name] = b
+		default:
+			return nil, NewError(ErrCodeInvalidConfig, "invalid value %v of feature gate %s, expected a boolean", v, name)
+		}
+	}
+	return gates, nil
+}
+
+// FeatureEnabled returns whether the feature gate of name is enabled in the platform module config.
+// Features not listed or with invalid values are disabled, call FeatureGates to check the values.
+func (r *GeneratorRequest) FeatureEnabled(name string) bool {
+	gates, err := r.FeatureGates()
+	if err != nil {
+		return false
+	}
+	return gates[name]
+}
@@ -0,0 +1,149 @@
+package module
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+type mergeTestConfig struct {
+	Replicas  int               `yaml:"replicas"`
+	Image     string            `yaml:"image"`
+	Ports     []int             `yaml:"ports"`
+	Resources mergeTestLimits   `yaml:"resources"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+type mergeTestLimits struct {
+	CPU    string `yaml:"cpu"`
+	Memory string `yaml:"memory"`
+}
+
+func TestMergeConfigs(t *testing.T) {
+	defaults := mergeTestConfig{
+		Replicas:  1,
+		Image:     "nginx",
+		Ports:     []int{80},
+		Resources: mergeTestLimits{CPU: "100m", Memory: "128Mi"},
+		Labels:    map[string]string{"tier": "web"},
+	}
+	tests := []struct {
+		name     string
+		dev      v1.Accessory
+		platform v1.GenericConfig
+		want     mergeTestConfig
+	}{
+		{
+			name: "nil configs keep the defaults",
+			want: defaults,
+		},
+		{
+			name:     "empty configs keep the defaults",
+			dev:      v1.Accessory{},
+			platform: v1.GenericConfig{},
+			want:     defaults,
+		},
+		{
+			name: "dev config without platform config",
+			dev:  v1.Accessory{"replicas": 3},
+			want: mergeTestConfig{
+				Replicas:  3,
+				Image:     "nginx",
+				Ports:     []int{80},
+				Resources: mergeTestLimits{CPU: "100m", Memory: "128Mi"},
+				Labels:    map[string]string{"tier": "web"},
+			},
+		},
+		{
+			name:     "platform config without dev config",
+			platform: v1.GenericConfig{"image": "nginx:1.25"},
+			want: mergeTestConfig{
+				Replicas:  1,
+				Image:     "nginx:1.25",
+				Ports:     []int{80},
+				Resources: mergeTestLimits{CPU: "100m", Memory: "128Mi"},
+				Labels:    map[string]string{"tier": "web"},
+			},
+		},
+		{
+			name:     "dev overrides platform overrides defaults",
+			dev:      v1.Accessory{"replicas": 3},
+			platform: v1.GenericConfig{"replicas": 2, "image": "nginx:1.25"},
+			want: mergeTestConfig{
+				Replicas:  3,
+				Image:     "nginx:1.25",
+				Ports:     []int{80},
+				Resources: mergeTestLimits{CPU: "100m", Memory: "128Mi"},
+				Labels:    map[string]string{"tier": "web"},
+			},
+		},
+		{
+			name:     "nested maps are merged deeply",
+			dev:      v1.Accessory{"resources": map[string]any{"memory": "1Gi"}},
+			platform: v1.GenericConfig{"resources": map[interface{}]interface{}{"cpu": "500m"}, "labels": map[string]any{"team": "a"}},
+			want: mergeTestConfig{
+				Replicas:  1,
+				Image:     "nginx",
+				Ports:     []int{80},
+				Resources: mergeTestLimits{CPU: "500m", Memory: "1Gi"},
+				Labels:    map[string]string{"tier": "web", "team": "a"},
+			},
+		},
+		{
+			name:     "nil and empty nested overrides keep the defaults",
+			dev:      v1.Accessory{"resources": map[string]any{}},
+			platform: v1.GenericConfig{"resources": map[string]any(nil), "labels": nil},
+			want:     defaults,
+		},
+		{
+			name: "lists are replaced as a whole",
+			dev:  v1.Accessory{"ports": []any{8080, 8443}},
+			want: mergeTestConfig{
+				Replicas:  1,
+				Image:     "nginx",
+				Ports:     []int{8080, 8443},
+				Resources: mergeTestLimits{CPU: "100m", Memory: "128Mi"},
+				Labels:    map[string]string{"tier": "web"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeConfigs(tt.dev, tt.platform, defaults)
+			if err != nil {
+				t.Fatalf("MergeConfigs() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("MergeConfigs() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]any{"a": 1, "b": map[string]any{"c": 2}}
+	tests := []struct {
+		name     string
+		override any
+		want     any
+	}{
+		{name: "nil", override: nil, want: base},
+		{name: "typed nil map", override: map[string]any(nil), want: base},
+		{name: "typed nil platform config", override: v1.GenericConfig(nil), want: base},
+		{name: "empty map", override: map[string]any{}, want: base},
+		{name: "scalar", override: 3, want: 3},
+		{
+			name:     "nested",
+			override: map[string]any{"b": map[interface{}]interface{}{"d": 4}},
+			want:     map[string]any{"a": 1, "b": map[string]any{"c": 2, "d": 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeValues(base, tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeValues() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
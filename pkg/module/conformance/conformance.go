@@ -0,0 +1,194 @@
+// Package conformance checks that FrameworkModule implementations follow the contract of the framework,
+// so that every module can run the same suite in its tests:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, &MyModule{})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/testutil"
+)
+
+// CancelTimeout is the time a module has to return after the context of Generate is canceled.
+var CancelTimeout = 5 * time.Second
+
+// Runs is the number of times every request is generated to check the output is deterministic.
+const Runs = 3
+
+// Run runs the conformance checks of m as subtests of t:
+//
+//   - NonNilResponse: Generate returns a response, e.g. module.EmptyResponse, instead of nil on success
+//   - Deterministic: the same request always generates the same response
+//   - NilConfigs: Generate does not panic without workloads or with nil dev, platform and runtime configs
+//   - ResourceIDs: the IDs of the generated resources are well-formed and unique
+//   - Cancellation: Generate returns within CancelTimeout when its context is canceled
+//
+// The checks generate reqs, which should be valid requests of the module, or the default request of
+// testutil.NewRequestBuilder if none is given. Requests are copied before every call.
+func Run(t *testing.T, m module.FrameworkModule, reqs ...*module.GeneratorRequest) {
+	t.Helper()
+
+	if len(reqs) == 0 {
+		reqs = []*module.GeneratorRequest{testutil.NewRequestBuilder().Build()}
+	}
+
+	t.Run("NonNilResponse", func(t *testing.T) {
+		for i, req := range reqs {
+			resp, err := generate(context.Background(), m, copyRequest(t, req))
+			if err != nil {
+				t.Fatalf("request %d: generate failed: %v", i, err)
+			}
+			if resp == nil {
+				t.Errorf("request %d: Generate returned a nil response without an error, return module.EmptyResponse instead", i)
+			}
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		for i, req := range reqs {
+			var first []byte
+			for run := 0; run < Runs; run++ {
+				resp, err := generate(context.Background(), m, copyRequest(t, req))
+				if err != nil {
+					t.Fatalf("request %d: generate failed: %v", i, err)
+				}
+				out := marshalResponse(t, resp)
+				if run == 0 {
+					first = out
+				} else if string(out) != string(first) {
+					t.Fatalf("request %d: responses of the same request differ, e.g. by iterating maps or using "+
+						"random names\n--- run 1\n%s\n+++ run %d\n%s", i, first, run+1, out)
+				}
+			}
+		}
+	})
+
+	t.Run("NilConfigs", func(t *testing.T) {
+		bare := copyRequest(t, reqs[0])
+		bare.Workload, bare.Workloads = nil, nil
+		names := []string{"no workloads"}
+		cases := []*module.GeneratorRequest{bare}
+		for i, req := range reqs {
+			req = copyRequest(t, req)
+			req.DevModuleConfig, req.PlatformModuleConfig, req.RuntimeConfig = nil, nil, nil
+			names = append(names, fmt.Sprintf("request %d without configs", i))
+			cases = append(cases, req)
+		}
+		for i, req := range cases {
+			// errors are fine, only panics break the contract
+			if _, err := generate(context.Background(), m, req); err != nil {
+				if e, ok := err.(*panicError); ok {
+					t.Errorf("%s: %v", names[i], e)
+				}
+			}
+		}
+	})
+
+	t.Run("ResourceIDs", func(t *testing.T) {
+		for i, req := range reqs {
+			resp, err := generate(context.Background(), m, copyRequest(t, req))
+			if err != nil {
+				t.Fatalf("request %d: generate failed: %v", i, err)
+			}
+			if resp == nil {
+				continue
+			}
+			seen := map[string]bool{}
+			for _, res := range resp.Resources {
+				if err = module.ValidateResourceID(res.Type, res.ID); err != nil {
+					t.Errorf("request %d: resource %s: %v", i, res.ID, err)
+				}
+				if seen[res.ID] {
+					t.Errorf("request %d: resource id %s is generated more than once", i, res.ID)
+				}
+				seen[res.ID] = true
+			}
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		done := make(chan error, 1)
+		go func() {
+			_, err := generate(ctx, m, copyRequest(t, reqs[0]))
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if e, ok := err.(*panicError); ok {
+				t.Errorf("generate with a canceled context failed: %v", e)
+			}
+		case <-time.After(CancelTimeout):
+			t.Errorf("Generate did not return within %s after its context was canceled", CancelTimeout)
+		}
+	})
+}
+
+// panicError is a panic recovered from Generate.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("Generate panicked: %v\n%s", e.value, e.stack)
+}
+
+// generate calls the Generate method of m, converting panics into a panicError.
+func generate(ctx context.Context, m module.FrameworkModule, req *module.GeneratorRequest) (resp *module.GeneratorResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = nil, &panicError{value: r, stack: debug.Stack()}
+		}
+	}()
+	return m.Generate(ctx, req)
+}
+
+// copyRequest returns a deep copy of req, so that modules mutating their requests do not affect the
+// later checks.
+func copyRequest(tb testing.TB, req *module.GeneratorRequest) *module.GeneratorRequest {
+	tb.Helper()
+
+	data, err := yaml.Marshal(req)
+	if err != nil {
+		tb.Fatalf("marshal request failed: %v", err)
+	}
+	out := &module.GeneratorRequest{}
+	if err = yaml.Unmarshal(data, out); err != nil {
+		tb.Fatalf("unmarshal request failed: %v", err)
+	}
+	return out
+}
+
+// marshalResponse returns the YAML of resp with the resources sorted by ID like the wrapper does,
+// unless the module preserves their order.
+func marshalResponse(tb testing.TB, resp *module.GeneratorResponse) []byte {
+	tb.Helper()
+
+	if resp == nil {
+		resp = &module.GeneratorResponse{}
+	}
+	sorted := *resp
+	if !resp.PreserveOrder {
+		sorted.Resources = append([]v1.Resource(nil), resp.Resources...)
+		sort.SliceStable(sorted.Resources, func(i, j int) bool { return sorted.Resources[i].ID < sorted.Resources[j].ID })
+	}
+	out, err := yaml.Marshal(&sorted)
+	if err != nil {
+		tb.Fatalf("marshal response failed: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package module
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/workloadutil"
+)
+
+// ConnectionSecretSuffix is the suffix of the Secrets holding the connection info exposed to workloads.
+//
+// The framework convention for exposing module outputs such as a database host, port or password to the
+// workload is: every value is a key of the Secret <app>-<name>-connection in the namespace of the request,
+// and every container of the workload references it as an environment variable of the same name.
+const ConnectionSecretSuffix = "-connection"
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Exposure is a value exposed to the workload as the environment variable EnvName.
+type Exposure struct {
+	// EnvName is the environment variable name, which is also the key in the connection Secret
+	EnvName string
+	// ValueFrom is the value, which may reference an output of a resource generated in the same apply,
+	// e.g. RefAttr(id, "address"), resolved by the engine
+	ValueFrom string
+}
+
+// ExposeToWorkload exposes valueFrom to the workload as the environment variable envName.
+func ExposeToWorkload(envName, valueFrom string) Exposure {
+	return Exposure{EnvName: envName, ValueFrom: valueFrom}
+}
+
+// ConnectionSecretName returns the name of the connection Secret of the module output name.
+func ConnectionSecretName(req *GeneratorRequest, name string) string {
+	return strings.ToLower(req.App + "-" + name + ConnectionSecretSuffix)
+}
+
+// ConnectionResources returns the connection Secret of the exposures and the patch injecting them as
+// environment variables into every container of the workload, to be merged into the response of the module.
+func ConnectionResources(req *GeneratorRequest, name string, exposures ...Exposure) (*GeneratorResponse, error) {
+	if len(exposures) == 0 {
+		return nil, fmt.Errorf("no values exposed to the workload")
+	}
+	w, err := req.LoadWorkload()
+	if err != nil {
+		return nil, err
+	}
+	containers := workloadutil.ContainerNames(w)
+	if len(containers) == 0 {
+		return nil, NewError(ErrCodeInvalidRequest, "workload of app %s has no containers to expose values to", req.App).
+			WithHint("this module must be used with a workload")
+	}
+	secretName := ConnectionSecretName(req, name)
+	data := make(map[string]interface{}, len(exposures))
+	env := make([]any, 0, len(exposures))
+	for _, e := range exposures {
+		if !envNamePattern.MatchString(e.EnvName) {
+			return nil, fmt.Errorf("invalid environment variable name %q", e.EnvName)
+		}
+		if _, ok := data[e.EnvName]; ok {
+			return nil, fmt.Errorf("environment variable %s is exposed twice", e.EnvName)
+		}
+		data[e.EnvName] = e.ValueFrom
+		env = append(env, map[string]any{
+			"name": e.EnvName,
+			"valueFrom": map[string]any{
+				"secretKeyRef": map[string]any{"name": secretName, "key": e.EnvName},
+			},
+		})
+	}
+
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{"type": "Opaque", "stringData": data}}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace(req.Namespace())
+	secret.SetName(secretName)
+	resp := &GeneratorResponse{}
+	if err := resp.AppendKube(secret); err != nil {
+		return nil, err
+	}
+
+	patched := make([]any, 0, len(containers))
+	for _, c := range containers {
+		patched = append(patched, map[string]any{"name": c, "env": env})
+	}
+	resp.Patcher = &Patcher{StrategicMergePatch: map[string]any{
+		"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": patched}}},
+	}}
+	return resp, nil
+}
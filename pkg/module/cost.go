@@ -0,0 +1,89 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// CostMetadataKey is the gRPC response header carrying the cost estimates of the generated resources,
+// which the proto response has no field for. Engines not reading it are not affected.
+const CostMetadataKey = "kusion-module-cost-bin"
+
+// DefaultCurrency is the currency of cost estimates without one.
+const DefaultCurrency = "USD"
+
+// CostEstimate is the estimated monthly cost of a generated resource.
+type CostEstimate struct {
+	// ResourceID is the ID of the estimated resource
+	ResourceID string `json:"resourceID" yaml:"resourceID"`
+	// MonthlyCost is the estimated cost per month
+	MonthlyCost float64 `json:"monthlyCost" yaml:"monthlyCost"`
+	// Currency is the ISO 4217 code of the currency of MonthlyCost, DefaultCurrency if empty
+	Currency string `json:"currency,omitempty" yaml:"currency,omitempty"`
+	// Description explains the estimate, e.g. the instance class and storage size priced
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// CostEstimator is an optional interface of FrameworkModule. If implemented, EstimateCost is called
+// with the generated resources, except for destroy operations, and the estimates are sent to the engine
+// with the response, so that previews can display costs. Failures are logged and do not fail Generate.
+type CostEstimator interface {
+	EstimateCost(ctx context.Context, resources []v1.Resource) ([]CostEstimate, error)
+}
+
+// TotalMonthlyCost sums the estimates by currency.
+func TotalMonthlyCost(estimates []CostEstimate) map[string]float64 {
+	totals := map[string]float64{}
+	for _, e := range estimates {
+		currency := e.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		totals[currency] += e.MonthlyCost
+	}
+	return totals
+}
+
+// estimateCost adds the estimates of the wrapped module, or of the module routed to by a Registry, to the
+// estimates set by the module in resp.
+func (f *FrameworkModuleWrapper) estimateCost(ctx context.Context, req *GeneratorRequest, resp *GeneratorResponse) {
+	m := f.Module
+	if r, isRegistry := m.(*Registry); isRegistry {
+		var err error
+		if m, err = r.Lookup(req); err != nil {
+			return
+		}
+	}
+	e, ok := m.(CostEstimator)
+	if !ok || req.Operation == OperationDestroy {
+		return
+	}
+	estimates, err := e.EstimateCost(ctx, resp.Resources)
+	if err != nil {
+		LoggerFrom(ctx).Warn("estimate cost failed", "error", err)
+		return
+	}
+	resp.Costs = append(resp.Costs, estimates...)
+}
+
+// sendCosts sends the estimates sorted by resource ID to the engine in the gRPC response header.
+func sendCosts(ctx context.Context, estimates []CostEstimate) error {
+	if len(estimates) == 0 {
+		return nil
+	}
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return estimates[i].ResourceID < estimates[j].ResourceID
+	})
+	out, err := json.Marshal(estimates)
+	if err != nil {
+		return fmt.Errorf("marshal cost estimates failed. %w", err)
+	}
+	if err = setHeader(ctx, CostMetadataKey, string(out)); err != nil {
+		return fmt.Errorf("send cost estimates failed. %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithGenerateTimeout limits the duration of every Generate call of the module. The deadline sent by
+// the engine through gRPC is always applied to the Generate context, and the earlier one wins.
+func WithGenerateTimeout(timeout time.Duration) ServeOption {
+	return func(o *serveOptions) {
+		o.timeout = timeout
+	}
+}
+
+type generateResult struct {
+	resp *GeneratorResponse
+	err  error
+}
+
+// callGenerate calls the Generate method of the module and returns once it finishes or the context is
+// done, so that a module stuck in a call ignoring its context can not stall the engine. The goroutine of
+// such a module keeps running until the call returns, and only then calls release, which frees the
+// concurrency slot of the call, and lets reloads of the module proceed.
+func (f *FrameworkModuleWrapper) callGenerate(ctx context.Context, req *GeneratorRequest, release func()) (*GeneratorResponse, error) {
+	started := false
+	defer func() {
+		if !started {
+			release()
+		}
+	}()
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	ctx, releaseChaos, err := f.injectChaos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseChaos()
+	done := make(chan generateResult, 1)
+	started = true
+	f.running.add()
+	go func() {
+		var r generateResult
+		defer func() { done <- r }()
+		defer f.running.done()
+		defer release()
+		defer f.recoverPanic(&r.err)
+		r.resp, r.err = f.Module.Generate(ctx, req)
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() != nil && errors.Is(r.err, ctx.Err()) {
+			return nil, f.contextError(ctx)
+		}
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, f.contextError(ctx)
+	}
+}
+
+// contextError returns the error naming the module of a done context.
+func (f *FrameworkModuleWrapper) contextError(ctx context.Context) error {
+	name := f.Name
+	if name == "" {
+		name = "module"
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		e := NewError(ErrCodeTimeout, "%s did not finish generating before the deadline", name).
+			WithHint("check the external calls of the module, or raise the module timeout")
+		e.Err = ctx.Err()
+		return e
+	}
+	e := NewError(ErrCodeCanceled, "generating of %s was canceled", name)
+	e.Err = ctx.Err()
+	return e
+}
+
+// callTracker counts the running Generate calls of the module, including the ones that outlived their
+// deadline, so that reloads can wait for them without blocking forever.
+type callTracker struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (t *callTracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+}
+
+func (t *callTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+	}
+}
+
+// wait waits until no call is running or ctx is done.
+func (t *callTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.n == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
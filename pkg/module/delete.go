@@ -0,0 +1,113 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// Deleter is an optional interface of FrameworkModule. If implemented, OnDelete is called by the
+// OnDelete RPC of the framework service when the stack is destroyed, so that modules registering
+// resources out-of-band, such as DNS entries or SaaS resources created through REST APIs, can clean up.
+// The resources generated by the module are deleted by the engine and must not be handled here.
+//
+// OnDelete may be called again if destroying the stack is retried, so it must be idempotent.
+type Deleter interface {
+	OnDelete(ctx context.Context, req *GeneratorRequest) error
+}
+
+// OnDelete calls the OnDelete hook of the wrapped module if it implements Deleter.
+func (f *FrameworkModuleWrapper) OnDelete(ctx context.Context, req *GeneratorRequest) (err error) {
+	d, ok := f.Module.(Deleter)
+	if !ok {
+		return nil
+	}
+	defer f.recoverPanic(&err)
+	if err = f.Ready(ctx); err != nil {
+		return err
+	}
+	ctx = ContextWithLogger(ctx, f.requestLogger(req))
+	if f.Resolver != nil {
+		ctx = ContextWithResolver(ctx, f.Resolver)
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	if err = d.OnDelete(ctx, req); err != nil {
+		return asModuleError(err, ErrCodeInternal, "delete hook failed")
+	}
+	return nil
+}
+
+// onDeleteRPC serves OnDelete. The request is a JSON encoded proto GeneratorRequest, carrying the
+// same headers as Generate.
+func (f *FrameworkModuleWrapper) onDeleteRPC(ctx context.Context, in []byte) ([]byte, error) {
+	protoReq := &proto.GeneratorRequest{}
+	if err := json.Unmarshal(in, protoReq); err != nil {
+		return nil, NewError(ErrCodeInvalidRequest, "unmarshal delete request failed: %v", err)
+	}
+	req, err := NewGeneratorRequest(protoReq)
+	if err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid delete request")
+	}
+	req.strict = f.StrictDecoding
+	req.secretKey = f.SecretKey
+	req.secretStores = f.SecretStores
+	req.Operation = OperationDestroy
+	req.Module = incomingMetadata(ctx, ModuleNameMetadataKey)
+	if req.PriorState, err = decodePriorState(ctx); err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid prior state")
+	}
+	if err = decodeObjectMeta(ctx, req); err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid object meta")
+	}
+	if f.ModuleInfo.RequiresWorkspace {
+		req.workspaceAccess = true
+		req.workspace = []byte(incomingMetadata(ctx, WorkspaceMetadataKey))
+	}
+	return nil, f.OnDelete(ctx, req)
+}
+
+// OnDelete routes the request to the requested module if it implements Deleter.
+func (r *Registry) OnDelete(ctx context.Context, req *GeneratorRequest) error {
+	m, err := r.Lookup(req)
+	if err != nil {
+		return err
+	}
+	if d, ok := m.(Deleter); ok {
+		return d.OnDelete(ctx, req)
+	}
+	return nil
+}
+
+// CallOnDelete calls the OnDelete RPC of the module plugin served on conn, used by hosts when the
+// stack is destroyed. Plugins whose module does not implement Deleter return nil.
+func CallOnDelete(ctx context.Context, conn grpc.ClientConnInterface, req *proto.GeneratorRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal delete request failed. %w", err)
+	}
+	if _, err = invokeFramework(ctx, conn, "OnDelete", data); err != nil {
+		if e, ok := ErrorFromStatus(err); ok {
+			return e
+		}
+		return err
+	}
+	return nil
+}
+
+// OnDelete implements Deleter by calling the OnDelete RPC of the plugin.
+func (m *pluginModule) OnDelete(ctx context.Context, req *GeneratorRequest) error {
+	protoReq, err := req.ToProto()
+	if err != nil {
+		return err
+	}
+	ctx = ContextWithModuleName(ctx, req.Module)
+	ctx = ContextWithSDKVersion(ctx, SDKVersion)
+	return CallOnDelete(ctx, m.conn, protoReq)
+}
@@ -0,0 +1,59 @@
+package module
+
+import (
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// DependsOn declares that res depends on the resources of ids, so that the engine creates them
+// before res. Duplicated IDs are ignored.
+func DependsOn(res *v1.Resource, ids ...string) {
+	for _, id := range ids {
+		if id == "" || id == res.ID || containsString(res.DependsOn, id) {
+			continue
+		}
+		res.DependsOn = append(res.DependsOn, id)
+	}
+}
+
+// DependsOnResources declares that res depends on others.
+func DependsOnResources(res *v1.Resource, others ...*v1.Resource) {
+	for _, other := range others {
+		DependsOn(res, other.ID)
+	}
+}
+
+// AddDependency declares that the resource of id depends on the resources of dependsOn. All of them
+// must be in the response.
+func (r *GeneratorResponse) AddDependency(id string, dependsOn ...string) error {
+	res := r.resource(id)
+	if res == nil {
+		return fmt.Errorf("resource %s not found in the response", id)
+	}
+	for _, dep := range dependsOn {
+		if r.resource(dep) == nil {
+			return fmt.Errorf("dependency %s of resource %s not found in the response", dep, id)
+		}
+	}
+	DependsOn(res, dependsOn...)
+	return nil
+}
+
+func (r *GeneratorResponse) resource(id string) *v1.Resource {
+	for i := range r.Resources {
+		if r.Resources[i].ID == id {
+			return &r.Resources[i]
+		}
+	}
+	return nil
+}
+
+func containsString(s []string, target string) bool {
+	for _, v := range s {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+// Package discovery detects the capabilities of the target cluster through the API discovery endpoints,
+// so that modules degrade gracefully when an operator is not installed, e.g. skip ServiceMonitors if
+// monitoring.coreos.com is not served. Results are cached per API server and user for the lifetime of
+// the plugin, as users may see different APIs.
+//
+// Discovery calls the cluster while generating, which makes the output depend on the cluster state.
+// Prefer declaring capabilities in the platform module config, and use discovery as a fallback.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Default settings of discovery clients.
+const (
+	DefaultCacheTTL = 5 * time.Minute
+	DefaultTimeout  = 10 * time.Second
+)
+
+// Client discovers the APIs served by a Kubernetes cluster.
+type Client struct {
+	rc    *restConfig
+	cache *serverCache
+}
+
+type serverCache struct {
+	// exec are the credentials of the exec plugin of the user, shared by the clients of the cache
+	exec      *execCredentials
+	mu        sync.Mutex
+	ttl       time.Duration
+	fetched   time.Time
+	groups    map[string][]string
+	resources map[string]*metav1.APIResourceList
+}
+
+// cacheKey identifies the discovery results of a user of an API server.
+type cacheKey struct {
+	server string
+	user   string
+}
+
+var (
+	cachesMu sync.Mutex
+	caches   = map[cacheKey]*serverCache{}
+)
+
+// NewClient returns a client of the target cluster of the request, configured by the kubeconfig of the
+// Kubernetes runtime. It fails if no kubeconfig is configured.
+func NewClient(req *module.GeneratorRequest) (*Client, error) {
+	cluster, err := req.TargetCluster()
+	if err != nil {
+		return nil, err
+	}
+	if cluster.KubeConfig == "" {
+		return nil, fmt.Errorf("no kubeconfig configured in the kubernetes runtime")
+	}
+	return NewClientFromKubeConfig(cluster.KubeConfig)
+}
+
+// NewClientFromKubeConfig returns a client of the current context of the kubeconfig at path.
+func NewClientFromKubeConfig(path string) (*Client, error) {
+	rc, err := loadRESTConfig(path, DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	key := cacheKey{server: rc.server, user: rc.user}
+	cache, ok := caches[key]
+	if !ok {
+		cache = &serverCache{ttl: DefaultCacheTTL, resources: map[string]*metav1.APIResourceList{}}
+		caches[key] = cache
+	}
+	if rc.exec != nil {
+		// reuse the cached credential unless the plugin config changed
+		if cache.exec == nil || !reflect.DeepEqual(cache.exec.config, rc.exec.config) {
+			cache.exec = rc.exec
+		}
+		rc.exec = cache.exec
+	}
+	return &Client{rc: rc, cache: cache}, nil
+}
+
+// HasGroup reports whether the cluster serves any version of the API group, e.g. gateway.networking.k8s.io.
+func (c *Client) HasGroup(ctx context.Context, group string) (bool, error) {
+	groups, err := c.groups(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := groups[group]
+	return ok, nil
+}
+
+// HasGroupVersion reports whether the cluster serves the group version, e.g. monitoring.coreos.com/v1.
+func (c *Client) HasGroupVersion(ctx context.Context, groupVersion string) (bool, error) {
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return false, err
+	}
+	groups, err := c.groups(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range groups[gv.Group] {
+		if v == gv.Version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasKind reports whether the cluster serves the kind in the group version, e.g. a CRD installed by an operator.
+func (c *Client) HasKind(ctx context.Context, gvk schema.GroupVersionKind) (bool, error) {
+	ok, err := c.HasGroupVersion(ctx, gvk.GroupVersion().String())
+	if err != nil || !ok {
+		return false, err
+	}
+	list, err := c.resourceList(ctx, gvk.GroupVersion())
+	if err != nil {
+		return false, err
+	}
+	for _, r := range list.APIResources {
+		if r.Kind == gvk.Kind && !strings.Contains(r.Name, "/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Supports is like HasGroup but treats discovery failures as unsupported, logging them, for modules
+// that only skip optional resources.
+func (c *Client) Supports(ctx context.Context, group string) bool {
+	ok, err := c.HasGroup(ctx, group)
+	if err != nil {
+		module.LoggerFrom(ctx).Warn("discover api group failed, assuming it is not served", "group", group, "error", err)
+		return false
+	}
+	return ok
+}
+
+// groups returns the served versions by API group, including the core group "".
+func (c *Client) groups(ctx context.Context) (map[string][]string, error) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if c.cache.groups != nil && time.Since(c.cache.fetched) < c.cache.ttl {
+		return c.cache.groups, nil
+	}
+	groups := map[string][]string{}
+	core := &metav1.APIVersions{}
+	if err := c.get(ctx, "/api", core); err != nil {
+		return nil, err
+	}
+	groups[""] = core.Versions
+	list := &metav1.APIGroupList{}
+	if err := c.get(ctx, "/apis", list); err != nil {
+		return nil, err
+	}
+	for _, g := range list.Groups {
+		for _, v := range g.Versions {
+			groups[g.Name] = append(groups[g.Name], v.Version)
+		}
+	}
+	c.cache.groups = groups
+	c.cache.resources = map[string]*metav1.APIResourceList{}
+	c.cache.fetched = time.Now()
+	return groups, nil
+}
+
+func (c *Client) resourceList(ctx context.Context, gv schema.GroupVersion) (*metav1.APIResourceList, error) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if list, ok := c.cache.resources[gv.String()]; ok {
+		return list, nil
+	}
+	path := "/apis/" + gv.String()
+	if gv.Group == "" {
+		path = "/api/" + gv.Version
+	}
+	list := &metav1.APIResourceList{}
+	if err := c.get(ctx, path, list); err != nil {
+		return nil, err
+	}
+	c.cache.resources[gv.String()] = list
+	return list, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.rc.server, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	token, err := c.rc.bearerToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get credentials of user %s failed. %w", c.rc.user, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.rc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discover %s failed. %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("discover %s failed with status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s failed. %w", path, err)
+	}
+	return nil
+}
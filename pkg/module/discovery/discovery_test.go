@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeKubeConfig writes a kubeconfig of server whose user runs the exec plugin script, returning its path.
+func writeKubeConfig(t *testing.T, server, user, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plugin.sh"), []byte("#!/bin/sh\n"+script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	config := `current-context: test
+contexts:
+- name: test
+  context: {cluster: test, user: ` + user + `}
+clusters:
+- name: test
+  cluster: {server: "` + server + `"}
+users:
+- name: ` + user + `
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: ./plugin.sh
+      env: [{name: TOKEN, value: ` + user + `-token}]
+`
+	path := filepath.Join(dir, "kubeconfig")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExecCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer admin-token":
+			if r.URL.Path == "/apis" {
+				_, _ = w.Write([]byte(`{"groups": [{"name": "monitoring.coreos.com", "versions": [{"version": "v1"}]}]}`))
+				return
+			}
+		case "Bearer viewer-token":
+			if r.URL.Path == "/apis" {
+				_, _ = w.Write([]byte(`{"groups": []}`))
+				return
+			}
+		default:
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"versions": ["v1"]}`))
+	}))
+	defer server.Close()
+
+	countFile := filepath.Join(t.TempDir(), "runs")
+	script := `echo run >> ` + countFile + `
+case "$KUBERNETES_EXEC_INFO" in *'"interactive":false'*) ;; *) exit 1 ;; esac
+echo '{"apiVersion": "client.authentication.k8s.io/v1", "kind": "ExecCredential", "status": {"token": "'$TOKEN'"}}'
+`
+	users := []struct {
+		user string
+		want bool
+	}{
+		{user: "admin", want: true},
+		{user: "viewer", want: false},
+	}
+	for _, tt := range users {
+		path := writeKubeConfig(t, server.URL, tt.user, script)
+		for i := 0; i < 2; i++ {
+			c, err := NewClientFromKubeConfig(path)
+			if err != nil {
+				t.Fatalf("NewClientFromKubeConfig() error = %v", err)
+			}
+			got, err := c.HasGroup(context.Background(), "monitoring.coreos.com")
+			if err != nil {
+				t.Fatalf("HasGroup() of user %s error = %v", tt.user, err)
+			}
+			if got != tt.want {
+				t.Errorf("HasGroup() of user %s = %v, want %v", tt.user, got, tt.want)
+			}
+		}
+	}
+	data, err := os.ReadFile(countFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "run"); got != len(users) {
+		t.Errorf("exec plugins ran %d times, want once per user", got)
+	}
+}
+
+func TestExecCredentialsFailure(t *testing.T) {
+	path := writeKubeConfig(t, "https://127.0.0.1:1", "broken", "echo 'login required' >&2\nexit 1\n")
+	c, err := NewClientFromKubeConfig(path)
+	if err != nil {
+		t.Fatalf("NewClientFromKubeConfig() error = %v", err)
+	}
+	if _, err = c.HasGroup(context.Background(), "apps"); err == nil || !strings.Contains(err.Error(), "login required") {
+		t.Errorf("HasGroup() error = %v, want the error of the exec plugin", err)
+	}
+}
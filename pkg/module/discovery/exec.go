@@ -0,0 +1,117 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultExecAPIVersion is the version of the ExecCredential API used if the kubeconfig sets none.
+const defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// execConfig configures a credential plugin of a kubeconfig user, e.g. aws eks get-token or
+// gke-gcloud-auth-plugin.
+type execConfig struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// execCredential is the credential returned by an exec plugin.
+type execCredential struct {
+	token  string
+	cert   *tls.Certificate
+	expiry time.Time
+}
+
+// execCredentials runs the exec plugin of a user and caches the credential until it expires. Plugins are
+// not interactive, so plugins prompting for input fail.
+type execCredentials struct {
+	config execConfig
+	mu     sync.Mutex
+	cred   *execCredential
+}
+
+// get returns the cached credential, or runs the plugin if there is none or it expired.
+func (e *execCredentials) get(ctx context.Context) (*execCredential, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cred != nil && (e.cred.expiry.IsZero() || time.Now().Before(e.cred.expiry)) {
+		return e.cred, nil
+	}
+	cred, err := e.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.cred = cred
+	return cred, nil
+}
+
+func (e *execCredentials) run(ctx context.Context) (*execCredential, error) {
+	apiVersion := e.config.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultExecAPIVersion
+	}
+	info, err := json.Marshal(map[string]any{
+		"apiVersion": apiVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, e.config.Command, e.config.Args...)
+	cmd.Env = append(os.Environ(), "KUBERNETES_EXEC_INFO="+string(info))
+	for _, env := range e.config.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run exec plugin %s failed: %s. %w", e.config.Command, strings.TrimSpace(stderr.String()), err)
+	}
+
+	var resp struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Status     *struct {
+			Token                 string     `json:"token"`
+			ClientCertificateData string     `json:"clientCertificateData"`
+			ClientKeyData         string     `json:"clientKeyData"`
+			ExpirationTimestamp   *time.Time `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err = json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("decode output of exec plugin %s failed. %w", e.config.Command, err)
+	}
+	if resp.Kind != "ExecCredential" || resp.APIVersion != apiVersion || resp.Status == nil {
+		return nil, fmt.Errorf("exec plugin %s returned no %s ExecCredential", e.config.Command, apiVersion)
+	}
+	cred := &execCredential{token: resp.Status.Token}
+	if resp.Status.ExpirationTimestamp != nil {
+		cred.expiry = *resp.Status.ExpirationTimestamp
+	}
+	if resp.Status.ClientCertificateData != "" || resp.Status.ClientKeyData != "" {
+		pair, err := tls.X509KeyPair([]byte(resp.Status.ClientCertificateData), []byte(resp.Status.ClientKeyData))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate of exec plugin %s. %w", e.config.Command, err)
+		}
+		cred.cert = &pair
+	}
+	if cred.token == "" && cred.cert == nil {
+		return nil, fmt.Errorf("exec plugin %s returned neither a token nor a client certificate", e.config.Command)
+	}
+	return cred, nil
+}
@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kubeConfig is the subset of a kubeconfig file needed to call the API server. Exec credential plugins
+// are supported, legacy auth provider plugins are not.
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string      `yaml:"token"`
+			TokenFile             string      `yaml:"tokenFile"`
+			ClientCertificate     string      `yaml:"client-certificate"`
+			ClientCertificateData string      `yaml:"client-certificate-data"`
+			ClientKey             string      `yaml:"client-key"`
+			ClientKeyData         string      `yaml:"client-key-data"`
+			Exec                  *execConfig `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// restConfig is the resolved connection to an API server.
+type restConfig struct {
+	server string
+	user   string
+	token  string
+	exec   *execCredentials
+	client *http.Client
+}
+
+// bearerToken returns the token of the user, running the exec plugin if configured.
+func (rc *restConfig) bearerToken(ctx context.Context) (string, error) {
+	if rc.exec == nil {
+		return strings.TrimSpace(rc.token), nil
+	}
+	cred, err := rc.exec.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cred.token, nil
+}
+
+// loadRESTConfig resolves the connection of the current context of the kubeconfig at path.
+func loadRESTConfig(path string, timeout time.Duration) (*restConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read kubeconfig %s failed. %w", path, err)
+	}
+	kc := &kubeConfig{}
+	if err = yaml.Unmarshal(data, kc); err != nil {
+		return nil, fmt.Errorf("unmarshal kubeconfig %s failed. %w", path, err)
+	}
+	var clusterName, userName string
+	for _, c := range kc.Contexts {
+		if c.Name == kc.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("current context %q of kubeconfig %s not found", kc.CurrentContext, path)
+	}
+
+	rc := &restConfig{user: userName}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		rc.server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify //nolint:gosec // as configured in the kubeconfig
+		ca, err := dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("load certificate authority of cluster %s failed. %w", clusterName, err)
+		}
+		if len(ca) > 0 {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("invalid certificate authority of cluster %s", clusterName)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if rc.server == "" {
+		return nil, fmt.Errorf("server of cluster %s not found in kubeconfig %s", clusterName, path)
+	}
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil {
+			if u.User.Exec.Command == "" {
+				return nil, fmt.Errorf("command of the exec plugin of user %s is empty", userName)
+			}
+			config := *u.User.Exec
+			if !filepath.IsAbs(config.Command) && strings.ContainsRune(config.Command, filepath.Separator) {
+				// relative paths are relative to the kubeconfig, as resolved by kubectl
+				config.Command = filepath.Join(filepath.Dir(path), config.Command)
+			}
+			rc.exec = &execCredentials{config: config}
+			tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				cred, err := rc.exec.get(context.Background())
+				if err != nil || cred.cert == nil {
+					// an empty certificate makes the handshake proceed without client authentication
+					return &tls.Certificate{}, err
+				}
+				return cred.cert, nil
+			}
+		}
+		rc.token = u.User.Token
+		if rc.token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(u.User.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("read token file of user %s failed. %w", userName, err)
+			}
+			rc.token = string(token)
+		}
+		cert, err := dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate of user %s failed. %w", userName, err)
+		}
+		key, err := dataOrFile(u.User.ClientKeyData, u.User.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client key of user %s failed. %w", userName, err)
+		}
+		if len(cert) > 0 && len(key) > 0 {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate of user %s. %w", userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+	rc.client = &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+	return rc, nil
+}
+
+// dataOrFile returns the base64 decoded data, or the contents of the file if data is empty.
+func dataOrFile(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package module
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// PlatformConfigEnvironmentKey is the key of the platform module config setting the environment of the
+// stack, e.g. prod for a stack named prod-us-east-1, read by Environment.
+const PlatformConfigEnvironmentKey = "environment"
+
+// EnvironmentDefaults are default config bundles keyed by environment, e.g. dev, staging and prod, which
+// are merged beneath the platform module config, so that modules do not embed if stack == "prod"
+// conditionals. Keys may be path.Match patterns such as prod-*.
+type EnvironmentDefaults map[string]map[string]any
+
+// WithEnvironmentDefaults merges the bundle of the environment of every request beneath its platform
+// module config. The platform config overrides the bundle, and maps are merged deeply.
+func WithEnvironmentDefaults(defaults EnvironmentDefaults) ServeOption {
+	return func(o *serveOptions) {
+		o.envDefaults = defaults
+	}
+}
+
+// Environment returns the environment of the request, which is the environment set in the platform
+// module config, or the stack name.
+func (r *GeneratorRequest) Environment() string {
+	if env, ok := r.PlatformModuleConfig[PlatformConfigEnvironmentKey].(string); ok && env != "" {
+		return env
+	}
+	return r.Stack
+}
+
+// For returns the merged bundles matching env. Bundles of patterns are merged beneath the bundle of
+// env itself, more specific patterns (with more literal characters) overriding less specific ones.
+func (d EnvironmentDefaults) For(env string) map[string]any {
+	var keys []string
+	for key := range d {
+		if ok, err := path.Match(key, env); err == nil && ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		si, sj := specificity(keys[i], env), specificity(keys[j], env)
+		if si != sj {
+			return si < sj
+		}
+		return keys[i] < keys[j]
+	})
+	var merged interface{} = map[string]interface{}{}
+	for _, key := range keys {
+		merged = mergeValues(merged, d[key])
+	}
+	m, _ := asStringMap(merged)
+	return m
+}
+
+// specificity ranks the exact key above all patterns, and patterns by their literal characters.
+func specificity(key, env string) int {
+	if key == env {
+		return len(env) + 1
+	}
+	return len(key) - strings.Count(key, "*") - strings.Count(key, "?")
+}
+
+// applyEnvironmentDefaults merges the bundle of the environment of req beneath its platform module config.
+func (f *FrameworkModuleWrapper) applyEnvironmentDefaults(req *GeneratorRequest) {
+	if len(f.EnvironmentDefaults) == 0 {
+		return
+	}
+	bundle := f.EnvironmentDefaults.For(req.Environment())
+	if len(bundle) == 0 {
+		return
+	}
+	merged, _ := asStringMap(mergeValues(bundle, map[string]interface{}(req.PlatformModuleConfig)))
+	req.PlatformModuleConfig = merged
+}
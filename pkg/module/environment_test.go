@@ -0,0 +1,74 @@
+package module
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+func TestApplyEnvironmentDefaults(t *testing.T) {
+	defaults := EnvironmentDefaults{
+		"prod-*": {"replicas": 3, "resources": map[string]any{"cpu": "1", "memory": "1Gi"}},
+		"prod":   {"replicas": 5},
+		"dev":    {"replicas": 1},
+	}
+	tests := []struct {
+		name     string
+		stack    string
+		platform v1.GenericConfig
+		want     v1.GenericConfig
+	}{
+		{
+			name:  "request without platform config",
+			stack: "dev",
+			want:  v1.GenericConfig{"replicas": 1},
+		},
+		{
+			name:     "request with empty platform config",
+			stack:    "dev",
+			platform: v1.GenericConfig{},
+			want:     v1.GenericConfig{"replicas": 1},
+		},
+		{
+			name:     "platform config overrides the bundle",
+			stack:    "prod-us-east-1",
+			platform: v1.GenericConfig{"resources": map[string]any{"cpu": "2"}},
+			want:     v1.GenericConfig{"replicas": 3, "resources": map[string]any{"cpu": "2", "memory": "1Gi"}},
+		},
+		{
+			name:     "environment set in the platform config",
+			stack:    "prod-us-east-1",
+			platform: v1.GenericConfig{PlatformConfigEnvironmentKey: "prod"},
+			want:     v1.GenericConfig{PlatformConfigEnvironmentKey: "prod", "replicas": 5},
+		},
+		{
+			name:     "no matching bundle",
+			stack:    "staging",
+			platform: nil,
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &FrameworkModuleWrapper{EnvironmentDefaults: defaults}
+			req := &GeneratorRequest{Stack: tt.stack, PlatformModuleConfig: tt.platform}
+			w.applyEnvironmentDefaults(req)
+			if !reflect.DeepEqual(req.PlatformModuleConfig, tt.want) {
+				t.Errorf("PlatformModuleConfig = %#v, want %#v", req.PlatformModuleConfig, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentDefaultsFor(t *testing.T) {
+	defaults := EnvironmentDefaults{
+		"*":      {"a": 1, "b": 1, "c": 1},
+		"prod-*": {"b": 2, "c": 2},
+		"prod-1": {"c": 3},
+	}
+	want := map[string]any{"a": 1, "b": 2, "c": 3}
+	if got := defaults.For("prod-1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("For() = %v, want %v", got, want)
+	}
+}
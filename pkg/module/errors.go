@@ -0,0 +1,139 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to the gRPC status of an Error.
+const ErrorDomain = "module.kusionstack.io"
+
+// ErrorCode is a stable code classifying module errors.
+type ErrorCode string
+
+const (
+	// ErrCodeInvalidRequest means the proto request sent by the engine is malformed
+	ErrCodeInvalidRequest ErrorCode = "InvalidRequest"
+	// ErrCodeInvalidConfig means the dev or platform module config is invalid
+	ErrCodeInvalidConfig ErrorCode = "InvalidConfig"
+	// ErrCodeUnavailable means an external dependency of the module is unavailable
+	ErrCodeUnavailable ErrorCode = "Unavailable"
+	// ErrCodeInternal means an unexpected failure of the module
+	ErrCodeInternal ErrorCode = "Internal"
+	// ErrCodeTimeout means the module did not finish before the deadline of the request
+	ErrCodeTimeout ErrorCode = "Timeout"
+	// ErrCodeCanceled means the request was canceled by the engine
+	ErrCodeCanceled ErrorCode = "Canceled"
+)
+
+var grpcCodes = map[ErrorCode]codes.Code{
+	ErrCodeInvalidRequest: codes.InvalidArgument,
+	ErrCodeInvalidConfig:  codes.InvalidArgument,
+	ErrCodeUnavailable:    codes.Unavailable,
+	ErrCodeInternal:       codes.Internal,
+	ErrCodeTimeout:        codes.DeadlineExceeded,
+	ErrCodeCanceled:       codes.Canceled,
+}
+
+// Error is a structured module error. Returned from Generate, it is sent to the engine as
+// gRPC status details, so the Kusion CLI can print the code, message and remediation hint.
+type Error struct {
+	// Code is the stable error code
+	Code ErrorCode
+	// Message is the human readable error message
+	Message string
+	// Hint tells users how to fix the error, optional
+	Hint string
+	// Stack is the stack trace of a recovered panic, optional
+	Stack string
+	// Err is the underlying error, optional
+	Err error
+}
+
+// NewError returns an Error of code with the formatted message.
+func NewError(code ErrorCode, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// WithHint sets the remediation hint of the error.
+func (e *Error) WithHint(hint string) *Error {
+	e.Hint = hint
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code) + ": " + e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Hint != "" {
+		msg += " (hint: " + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus converts the error into a gRPC status carrying an ErrorInfo detail, which is
+// used by the gRPC server when the error is returned from an RPC.
+func (e *Error) GRPCStatus() *status.Status {
+	code, ok := grpcCodes[e.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	msg := e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	metadata := map[string]string{}
+	if e.Hint != "" {
+		metadata["hint"] = e.Hint
+	}
+	if e.Stack != "" {
+		metadata["stack"] = e.Stack
+	}
+	s, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(e.Code),
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return status.New(code, msg)
+	}
+	return s
+}
+
+// ErrorFromStatus extracts the Error from an error returned by a module RPC. It returns false
+// if the error does not carry module error details.
+func ErrorFromStatus(err error) (*Error, bool) {
+	s, ok := status.FromError(err)
+	if !ok || s == nil {
+		return nil, false
+	}
+	for _, d := range s.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain {
+			return &Error{
+				Code:    ErrorCode(info.GetReason()),
+				Message: s.Message(),
+				Hint:    info.GetMetadata()["hint"],
+				Stack:   info.GetMetadata()["stack"],
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// asModuleError returns err if it is or wraps an Error, otherwise wraps err into an Error of code.
+func asModuleError(err error, code ErrorCode, msg string) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Code: code, Message: msg, Err: err}
+}
@@ -0,0 +1,166 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// Event is a progress event of a module generation, e.g. "creating schema", shown live by the engine.
+type Event struct {
+	// Time is the time the event was emitted, set by Emit if zero
+	Time time.Time `json:"time"`
+	// Stage is a short machine-readable name of the step, e.g. provider-metadata
+	Stage string `json:"stage,omitempty"`
+	// Message is the human-readable description of the step
+	Message string `json:"message"`
+	// Attributes are additional key-value details of the event
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// EventHandler receives the events emitted by a module.
+type EventHandler func(Event)
+
+type eventHandlerKey struct{}
+
+// ContextWithEventHandler returns a copy of ctx whose events emitted with Emit are passed to h.
+func ContextWithEventHandler(ctx context.Context, h EventHandler) context.Context {
+	return context.WithValue(ctx, eventHandlerKey{}, h)
+}
+
+// Emit sends the progress event to the engine if it called GenerateEvents, or logs it at debug level otherwise.
+func Emit(ctx context.Context, event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if h, ok := ctx.Value(eventHandlerKey{}).(EventHandler); ok && h != nil {
+		h(event)
+		return
+	}
+	LoggerFrom(ctx).Debug(event.Message, "stage", event.Stage)
+}
+
+// eventMessage is a message of the GenerateEvents stream, carrying either an event, a marshaled resource
+// or the response header.
+type eventMessage struct {
+	Event    *Event              `json:"event,omitempty"`
+	Resource []byte              `json:"resource,omitempty"`
+	Header   map[string][][]byte `json:"header,omitempty"`
+}
+
+var generateEventsDesc = grpc.StreamDesc{
+	StreamName:    "GenerateEvents",
+	Handler:       generateEventsHandler,
+	ServerStreams: true,
+}
+
+// generateEventsHandler serves GenerateEvents, the variant of Generate streaming the events emitted by the
+// module while it generates. The request is a JSON encoded proto GeneratorRequest, the events are sent as
+// they are emitted, followed by every marshaled resource of the response in its own message and a final
+// message with the response header. The header, e.g. the patcher and outputs, can not be sent as gRPC
+// headers, which are flushed with the first event.
+func generateEventsHandler(srv any, stream grpc.ServerStream) error {
+	in := &wrapperspb.BytesValue{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	req := &proto.GeneratorRequest{}
+	if err := json.Unmarshal(in.GetValue(), req); err != nil {
+		return NewError(ErrCodeInvalidRequest, "unmarshal events generator request failed: %v", err)
+	}
+
+	// events may be emitted from other goroutines of the module, even after a timed out Generate returned
+	var mu sync.Mutex
+	closed := false
+	send := func(msg *eventMessage) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(wrapperspb.Bytes(data))
+	}
+	header := &localStream{header: metadata.MD{}}
+	ctx := grpc.NewContextWithServerTransportStream(stream.Context(), header)
+	ctx = ContextWithEventHandler(ctx, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		if err := send(&eventMessage{Event: &e}); err != nil {
+			LoggerFrom(stream.Context()).Warn("send module event failed", "error", err)
+		}
+	})
+	resp, err := srv.(*FrameworkModuleWrapper).generate(ctx, req)
+
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	if err != nil {
+		return err
+	}
+	for _, res := range resp.Resources {
+		if err = send(&eventMessage{Resource: res}); err != nil {
+			return err
+		}
+	}
+	return send(&eventMessage{Header: metadataToWire(header.header)})
+}
+
+// GenerateEvents calls the GenerateEvents RPC of the module plugin served on conn, passing the events
+// emitted by the module to onEvent as they arrive, and returns the generated resources with the response
+// header, which carries the patcher, outputs, costs and resource encoding like the header of Generate.
+func GenerateEvents(ctx context.Context, conn grpc.ClientConnInterface, req *proto.GeneratorRequest,
+	onEvent EventHandler, opts ...grpc.CallOption,
+) (*proto.GeneratorResponse, metadata.MD, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal generator request failed. %w", err)
+	}
+	stream, err := conn.NewStream(ctx, &generateEventsDesc, "/"+FrameworkServiceName+"/GenerateEvents", opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = stream.SendMsg(wrapperspb.Bytes(data)); err != nil {
+		return nil, nil, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, nil, err
+	}
+
+	resp := &proto.GeneratorResponse{}
+	header := metadata.MD{}
+	for {
+		in := &wrapperspb.BytesValue{}
+		err = stream.RecvMsg(in)
+		if errors.Is(err, io.EOF) {
+			return resp, header, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		msg := &eventMessage{}
+		if err = json.Unmarshal(in.GetValue(), msg); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal events message failed. %w", err)
+		}
+		switch {
+		case msg.Event != nil:
+			if onEvent != nil {
+				onEvent(*msg.Event)
+			}
+		case msg.Resource != nil:
+			resp.Resources = append(resp.Resources, msg.Resource)
+		case msg.Header != nil:
+			header = metadata.Join(header, metadataFromWire(msg.Header))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package module
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// newTestConn serves w in-process and returns the client connection to it, whose transport is wrapped
+// by wrap if not nil.
+func newTestConn(t testing.TB, w *FrameworkModuleWrapper, wrap func(net.Conn) net.Conn) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterServices(server, w)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			conn, err := lis.DialContext(ctx)
+			if err == nil && wrap != nil {
+				conn = wrap(conn)
+			}
+			return conn, err
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial in-process module failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+	return conn
+}
+
+type eventsTestModule struct{}
+
+func (eventsTestModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	Emit(ctx, Event{Stage: "render", Message: "rendering resources"})
+	Emit(ctx, Event{Stage: "done", Message: "rendered"})
+	return &GeneratorResponse{
+		Resources: []v1.Resource{{ID: "v1:ConfigMap:" + req.App + ":config", Type: v1.Kubernetes, Attributes: map[string]any{"data": map[string]any{"k": "v"}}}},
+		Patcher:   &Patcher{StrategicMergePatch: map[string]any{"metadata": map[string]any{"labels": map[string]any{"team": "a"}}}},
+		Outputs:   map[string]OutputValue{"endpoint": {Value: "http://config"}},
+	}, nil
+}
+
+func TestGenerateEvents(t *testing.T) {
+	conn := newTestConn(t, &FrameworkModuleWrapper{Module: eventsTestModule{}, Name: "events", Logger: hclog.NewNullLogger()}, nil)
+	req, err := (&GeneratorRequest{Project: "p", Stack: "dev", App: "app"}).ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	protoResp, header, err := GenerateEvents(context.Background(), conn, req, func(e Event) {
+		events = append(events, e.Stage)
+	})
+	if err != nil {
+		t.Fatalf("GenerateEvents() error = %v", err)
+	}
+	if want := []string{"render", "done"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	resp, err := decodeResponse(protoResp, header)
+	if err != nil {
+		t.Fatalf("decode response failed: %v", err)
+	}
+	if len(resp.Resources) != 1 || resp.Resources[0].ID != "v1:ConfigMap:app:config" {
+		t.Errorf("resources = %+v, want the config map", resp.Resources)
+	}
+	if resp.Patcher == nil || resp.Patcher.StrategicMergePatch["metadata"] == nil {
+		t.Errorf("patcher = %+v, want the strategic merge patch", resp.Patcher)
+	}
+	if got := resp.Outputs["endpoint"].Value; got != "http://config" {
+		t.Errorf("output endpoint = %v, want http://config", got)
+	}
+}
+
+func TestGenerateStreamHeader(t *testing.T) {
+	conn := newTestConn(t, &FrameworkModuleWrapper{Module: eventsTestModule{}, Name: "stream", Logger: hclog.NewNullLogger()}, nil)
+	req, err := (&GeneratorRequest{Project: "p", Stack: "dev", App: "app"}).ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protoResp, header, err := GenerateStream(context.Background(), conn, req)
+	if err != nil {
+		t.Fatalf("GenerateStream() error = %v", err)
+	}
+	resp, err := decodeResponse(protoResp, header)
+	if err != nil {
+		t.Fatalf("decode response failed: %v", err)
+	}
+	if len(resp.Resources) != 1 || resp.Resources[0].ID != "v1:ConfigMap:app:config" {
+		t.Errorf("resources = %+v, want the config map", resp.Resources)
+	}
+	if resp.Patcher == nil || resp.Patcher.StrategicMergePatch["metadata"] == nil {
+		t.Errorf("patcher = %+v, want the strategic merge patch", resp.Patcher)
+	}
+	if got := resp.Outputs["endpoint"].Value; got != "http://config" {
+		t.Errorf("output endpoint = %v, want http://config", got)
+	}
+}
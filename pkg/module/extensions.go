@@ -0,0 +1,61 @@
+package module
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// WithKubeExtensions marks res as a Kubernetes resource of gvk and sets the GVK extension expected by
+// the Kusion Kubernetes runtime. If namespace is not empty, it is also set as the namespace of the object
+// in the attributes.
+func WithKubeExtensions(res *v1.Resource, gvk schema.GroupVersionKind, namespace string) error {
+	if gvk.Version == "" || gvk.Kind == "" {
+		return fmt.Errorf("version and kind of resource %s must not be empty", res.ID)
+	}
+	res.Type = v1.Kubernetes
+	if res.Extensions == nil {
+		res.Extensions = map[string]interface{}{}
+	}
+	res.Extensions[v1.ResourceExtensionGVK] = gvk.String()
+	if namespace != "" {
+		if res.Attributes == nil {
+			res.Attributes = map[string]interface{}{}
+		}
+		meta, ok := res.Attributes["metadata"].(map[string]interface{})
+		if !ok {
+			meta = map[string]interface{}{}
+			res.Attributes["metadata"] = meta
+		}
+		meta["namespace"] = namespace
+	}
+	return nil
+}
+
+// WithTFExtensions marks res as a Terraform resource managed by provider, the provider URL in the form
+// of [host/]namespace/name/version, and sets the provider, provider meta and resource type extensions
+// expected by the Kusion Terraform runtime. The resource type is read from the ID of res, which must
+// be a Terraform resource ID.
+func WithTFExtensions(res *v1.Resource, provider string, providerMeta map[string]any) error {
+	parts, err := ParseTerraformResourceID(res.ID)
+	if err != nil {
+		return err
+	}
+	c, err := ParseTFProviderURL(provider)
+	if err != nil {
+		return err
+	}
+	if c.Namespace() != parts.ProviderNamespace || c.Name() != parts.ProviderName {
+		return fmt.Errorf("provider %s does not match the resource id %s", provider, res.ID)
+	}
+	c.Meta = providerMeta
+	res.Type = v1.Terraform
+	if res.Extensions == nil {
+		res.Extensions = map[string]interface{}{}
+	}
+	for k, v := range c.Extensions(parts.ResourceType) {
+		res.Extensions[k] = v
+	}
+	return nil
+}
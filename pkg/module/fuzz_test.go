@@ -0,0 +1,48 @@
+package module
+
+import (
+	"testing"
+
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// FuzzGeneratorRequest decodes proto requests like the wrapper, and checks that eager and lazy decoding
+// agree and that a decoded request converts back into a proto request that decodes again. The malformed
+// documents that used to crash the decoding are in testdata/fuzz/FuzzGeneratorRequest.
+func FuzzGeneratorRequest(f *testing.F) {
+	f.Add([]byte("_type: Service\ncontainers:\n  main:\n    image: nginx:latest\nports:\n- port: 80\n"), []byte("replicas: 2\n"), []byte(nil), []byte(nil))
+	f.Add([]byte("- _type: Service\n  containers: {main: {image: nginx}}\n- _type: Job\n  schedule: '* * * * *'\n"), []byte(nil), []byte(nil), []byte(nil))
+	f.Add([]byte(nil), []byte(nil), []byte("featureGates:\n  canary: true\n"), []byte("kubernetes:\n  kubeConfig: /etc/kube/config\n"))
+
+	f.Fuzz(func(t *testing.T, workload, devConfig, platformConfig, runtimeConfig []byte) {
+		req := &proto.GeneratorRequest{
+			Project:              "fuzz-project",
+			Stack:                "fuzz-stack",
+			App:                  "fuzz-app",
+			Workload:             workload,
+			DevModuleConfig:      devConfig,
+			PlatformModuleConfig: platformConfig,
+			RuntimeConfig:        runtimeConfig,
+		}
+
+		decoded, err := newGeneratorRequest(req, false)
+		lazy, lazyErr := newGeneratorRequest(req, true)
+		if lazyErr == nil {
+			_, lazyErr = lazy.LoadWorkloads()
+		}
+		if (err == nil) != (lazyErr == nil) {
+			t.Fatalf("eager and lazy decoding disagree: %v, %v", err, lazyErr)
+		}
+		if err != nil {
+			return
+		}
+
+		protoReq, err := decoded.ToProto()
+		if err != nil {
+			t.Fatalf("convert decoded request failed: %v", err)
+		}
+		if _, err = newGeneratorRequest(protoReq, false); err != nil {
+			t.Fatalf("decode converted request failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package module
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// WithHTTPAddr serves the HTTP facade of the module on addr next to the plugin, see NewHTTPHandler.
+// The facade is not served if addr is empty. devConfig, a struct decoding the dev module config or nil,
+// describes the config in the OpenAPI spec.
+//
+// The facade has no authentication, so it should listen on a local address or behind an authenticating
+// proxy.
+func WithHTTPAddr(addr string, devConfig any) ServeOption {
+	return func(o *serveOptions) {
+		o.httpAddr = addr
+		o.devConfig = devConfig
+	}
+}
+
+// NewHTTPHandler returns the HTTP/JSON facade of the module served by w, so that non-Go callers and web
+// UIs can try modules and preview configs without the engine. Its endpoints are:
+//
+//	POST /v1/generate  runs Generate with the GeneratorRequest in the body and returns the GeneratorResponse
+//	POST /v1/validate  runs Generate like /v1/generate but only reports whether it succeeds
+//	GET  /v1/info      returns the ModuleInfo
+//	GET  /openapi.json returns the OpenAPI spec of the endpoints
+//
+// Requests are YAML or JSON documents like the ones of the local mode, and errors are returned as JSON
+// objects with the code, message and hint of module errors. The values of sensitive outputs are redacted.
+func NewHTTPHandler(w *FrameworkModuleWrapper, devConfig any) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/generate", func(rw http.ResponseWriter, r *http.Request) {
+		resp, ok := httpGenerate(w, rw, r)
+		if !ok {
+			return
+		}
+		for name, output := range resp.Outputs {
+			if output.Sensitive {
+				output.Value = Redacted
+				resp.Outputs[name] = output
+			}
+		}
+		writeJSON(rw, http.StatusOK, resp)
+	})
+	mux.HandleFunc("/v1/validate", func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := httpGenerate(w, rw, r); ok {
+			writeJSON(rw, http.StatusOK, map[string]any{"valid": true})
+		}
+	})
+	mux.HandleFunc("/v1/info", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeHTTPError(rw, http.StatusMethodNotAllowed, NewError(ErrCodeInvalidRequest, "method %s is not allowed", r.Method))
+			return
+		}
+		writeJSON(rw, http.StatusOK, w.Info())
+	})
+	spec, specErr := openAPISpec(w.Info(), devConfig)
+	mux.HandleFunc("/openapi.json", func(rw http.ResponseWriter, r *http.Request) {
+		if specErr != nil {
+			writeHTTPError(rw, http.StatusInternalServerError, specErr)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(spec)
+	})
+	return mux
+}
+
+// httpGenerate decodes the request and runs it through w, writing the error response if it fails.
+func httpGenerate(w *FrameworkModuleWrapper, rw http.ResponseWriter, r *http.Request) (*GeneratorResponse, bool) {
+	if r.Method != http.MethodPost {
+		writeHTTPError(rw, http.StatusMethodNotAllowed, NewError(ErrCodeInvalidRequest, "method %s is not allowed", r.Method))
+		return nil, false
+	}
+	limit := w.MaxMessageSize
+	if limit <= 0 {
+		limit = DefaultMaxMessageSize
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, int64(limit)))
+	if err != nil {
+		writeHTTPError(rw, http.StatusRequestEntityTooLarge, NewError(ErrCodeInvalidRequest, "read request failed: %v", err))
+		return nil, false
+	}
+	req := &GeneratorRequest{}
+	if err = yaml.Unmarshal(data, req); err != nil {
+		writeHTTPError(rw, http.StatusBadRequest, NewError(ErrCodeInvalidRequest, "unmarshal request failed: %v", err))
+		return nil, false
+	}
+	resp, err := generateLocal(r.Context(), w, req)
+	if err != nil {
+		writeHTTPError(rw, 0, err)
+		return nil, false
+	}
+	return resp, true
+}
+
+// httpStatus maps the codes of module errors to HTTP status codes.
+var httpStatus = map[ErrorCode]int{
+	ErrCodeInvalidRequest: http.StatusBadRequest,
+	ErrCodeInvalidConfig:  http.StatusUnprocessableEntity,
+	ErrCodeUnavailable:    http.StatusServiceUnavailable,
+	ErrCodeInternal:       http.StatusInternalServerError,
+	ErrCodeTimeout:        http.StatusGatewayTimeout,
+	ErrCodeCanceled:       499,
+}
+
+// writeHTTPError writes err as a JSON object, with the status of its code if status is zero.
+func writeHTTPError(rw http.ResponseWriter, status int, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Code: ErrCodeInternal, Message: err.Error()}
+	}
+	if status == 0 {
+		if status = httpStatus[e.Code]; status == 0 {
+			status = http.StatusInternalServerError
+		}
+	}
+	writeJSON(rw, status, map[string]any{"code": e.Code, "message": e.Message, "hint": e.Hint})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Errorf("write http response failed: %v", err)
+	}
+}
+
+// startHTTPServer serves handler on addr in the background and returns the function stopping it.
+func startHTTPServer(addr string, handler http.Handler) func() {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("serve http facade on %s failed: %v", addr, err)
+		}
+	}()
+	return func() {
+		if err := server.Close(); err != nil {
+			log.Errorf("stop http facade failed: %v", err)
+		}
+	}
+}
+
+// startHTTP starts the HTTP facade of the wrapper if configured and returns the function stopping it.
+func (o *serveOptions) startHTTP(w *FrameworkModuleWrapper) func() {
+	if o.httpAddr == "" {
+		return func() {}
+	}
+	return startHTTPServer(o.httpAddr, NewHTTPHandler(w, o.devConfig))
+}
+
+// openAPISpec returns the OpenAPI 3.1 spec of the HTTP facade, describing the dev module config with the
+// JSON schema of devConfig if not nil.
+func openAPISpec(info ModuleInfo, devConfig any) ([]byte, error) {
+	devSchema := map[string]any{"type": "object"}
+	if devConfig != nil {
+		data, err := GenerateJSONSchema(devConfig)
+		if err != nil {
+			return nil, err
+		}
+		// the definitions of the schema are nested in the spec, so are their references
+		data = []byte(strings.ReplaceAll(string(data), `"#/$defs/`, `"#/components/schemas/DevModuleConfig/$defs/`))
+		devSchema = nil
+		if err = json.Unmarshal(data, &devSchema); err != nil {
+			return nil, err
+		}
+		delete(devSchema, "$schema")
+	}
+	object := map[string]any{"type": "object"}
+	str := map[string]any{"type": "string"}
+	ref := func(name string) map[string]any { return map[string]any{"$ref": "#/components/schemas/" + name} }
+	jsonBody := func(schema map[string]any) map[string]any {
+		return map[string]any{"content": map[string]any{"application/json": map[string]any{"schema": schema}}}
+	}
+	errorResponse := func(description string) map[string]any {
+		r := jsonBody(ref("Error"))
+		r["description"] = description
+		return r
+	}
+	responses := func(ok map[string]any) map[string]any {
+		ok["description"] = "Generate succeeded"
+		return map[string]any{
+			"200": ok,
+			"400": errorResponse("The request is malformed"),
+			"422": errorResponse("The module config is invalid"),
+			"500": errorResponse("The module failed"),
+		}
+	}
+	request := jsonBody(ref("GeneratorRequest"))
+	request["required"] = true
+	title := info.Name
+	if title == "" {
+		title = "module"
+	}
+	spec := map[string]any{
+		"openapi": "3.1.0",
+		"info":    map[string]any{"title": title, "version": info.Version},
+		"paths": map[string]any{
+			"/v1/generate": map[string]any{"post": map[string]any{
+				"summary":     "Generate the resources of a request",
+				"operationId": "generate",
+				"requestBody": request,
+				"responses":   responses(jsonBody(ref("GeneratorResponse"))),
+			}},
+			"/v1/validate": map[string]any{"post": map[string]any{
+				"summary":     "Check whether a request generates without errors",
+				"operationId": "validate",
+				"requestBody": request,
+				"responses": responses(jsonBody(map[string]any{
+					"type": "object", "properties": map[string]any{"valid": map[string]any{"type": "boolean"}},
+				})),
+			}},
+			"/v1/info": map[string]any{"get": map[string]any{
+				"summary":     "Get the module metadata",
+				"operationId": "info",
+				"responses": map[string]any{"200": map[string]any{
+					"description": "The module metadata",
+					"content":     map[string]any{"application/json": map[string]any{"schema": object}},
+				}},
+			}},
+		},
+		"components": map[string]any{"schemas": map[string]any{
+			"DevModuleConfig": devSchema,
+			"GeneratorRequest": map[string]any{
+				"type":     "object",
+				"required": []string{"project", "stack", "app"},
+				"properties": map[string]any{
+					"project":              str,
+					"stack":                str,
+					"app":                  str,
+					"module":               str,
+					"operation":            str,
+					"workload":             object,
+					"workloads":            map[string]any{"type": "array", "items": object},
+					"devModuleConfig":      ref("DevModuleConfig"),
+					"platformModuleConfig": object,
+					"runtimeConfig":        object,
+					"priorState":           map[string]any{"type": "array", "items": object},
+					"projectMeta":          object,
+					"stackMeta":            object,
+				},
+			},
+			"GeneratorResponse": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"resources": map[string]any{"type": "array", "items": object},
+					"patcher":   object,
+					"costs":     map[string]any{"type": "array", "items": object},
+					"outputs":   object,
+				},
+			},
+			"Error": map[string]any{
+				"type":     "object",
+				"required": []string{"code", "message"},
+				"properties": map[string]any{
+					"code":    str,
+					"message": str,
+					"hint":    str,
+				},
+			},
+		}},
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
@@ -0,0 +1,74 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+)
+
+// ModuleInfo is the metadata of a module, returned by the Info RPC so that the engine
+// can refuse to run incompatible modules.
+type ModuleInfo struct {
+	// Name is the module name
+	Name string `json:"name" yaml:"name"`
+	// Version is the module version, defaults to the version of the main Go module of the plugin binary
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// ProtocolVersion is the plugin protocol version supported by the module
+	ProtocolVersion uint `json:"protocolVersion" yaml:"protocolVersion"`
+	// SDKVersion is the version of requests handled by the module, defaults to the SDKVersion of the framework
+	SDKVersion int `json:"sdkVersion,omitempty" yaml:"sdkVersion,omitempty"`
+	// DocsURL is the URL of the module documentation
+	DocsURL string `json:"docsURL,omitempty" yaml:"docsURL,omitempty"`
+	// RequiresWorkspace asks the engine to send the whole workspace configuration with every request
+	RequiresWorkspace bool `json:"requiresWorkspace,omitempty" yaml:"requiresWorkspace,omitempty"`
+}
+
+// WithModuleInfo sets the module metadata returned by the Info RPC. Empty fields are filled with
+// the name set by WithModuleName, the version of the plugin binary and the handshake protocol version.
+func WithModuleInfo(info ModuleInfo) ServeOption {
+	return func(o *serveOptions) {
+		requiresWorkspace := o.info.RequiresWorkspace
+		o.info = info
+		o.info.RequiresWorkspace = o.info.RequiresWorkspace || requiresWorkspace
+	}
+}
+
+// Info returns the metadata of the wrapped module.
+func (f *FrameworkModuleWrapper) Info() ModuleInfo {
+	info := f.ModuleInfo
+	if info.Name == "" {
+		info.Name = f.Name
+	}
+	if info.Version == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.Version = bi.Main.Version
+		}
+	}
+	if info.ProtocolVersion == 0 {
+		info.ProtocolVersion = protocolVersion()
+	}
+	if info.SDKVersion == 0 {
+		info.SDKVersion = SDKVersion
+	}
+	return info
+}
+
+func (f *FrameworkModuleWrapper) infoRPC(_ context.Context, _ []byte) ([]byte, error) {
+	return json.Marshal(f.Info())
+}
+
+// GetModuleInfo calls the Info RPC of the module plugin served on conn.
+func GetModuleInfo(ctx context.Context, conn grpc.ClientConnInterface) (*ModuleInfo, error) {
+	out, err := invokeFramework(ctx, conn, "Info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("get module info failed. %w", err)
+	}
+	info := &ModuleInfo{}
+	if err = json.Unmarshal(out, info); err != nil {
+		return nil, fmt.Errorf("unmarshal module info failed. %w", err)
+	}
+	return info, nil
+}
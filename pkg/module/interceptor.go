@@ -0,0 +1,39 @@
+package module
+
+import "google.golang.org/grpc"
+
+// WithUnaryInterceptors adds unary interceptors to the gRPC server of the module, e.g. to authenticate
+// the engine, log calls or limit payload sizes on the module boundary. They run in the given order
+// around Generate and the framework RPCs, as well as the health service of go-plugin.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) ServeOption {
+	return func(o *serveOptions) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors to the gRPC server of the module, which run in the
+// given order around the streaming framework RPCs such as the chunked Generate.
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) ServeOption {
+	return func(o *serveOptions) {
+		o.streamInterceptors = append(o.streamInterceptors, interceptors...)
+	}
+}
+
+// grpcServer returns the factory of the gRPC server of the plugin with the configured interceptors.
+func (o *serveOptions) grpcServer() func([]grpc.ServerOption) *grpc.Server {
+	if len(o.unaryInterceptors) == 0 && len(o.streamInterceptors) == 0 {
+		return newGRPCServer
+	}
+	return func(opts []grpc.ServerOption) *grpc.Server {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(o.unaryInterceptors...),
+			grpc.ChainStreamInterceptor(o.streamInterceptors...),
+		)
+		return newGRPCServer(opts)
+	}
+}
+
+// newGRPCServer is the default gRPC server factory of go-plugin.
+func newGRPCServer(opts []grpc.ServerOption) *grpc.Server {
+	return grpc.NewServer(opts...)
+}
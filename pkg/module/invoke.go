@@ -0,0 +1,220 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// ModulePathEnv is the environment variable listing the directories searched for module binaries by
+// the default resolver, separated by the OS path list separator.
+const ModulePathEnv = "KUSION_MODULE_PATH"
+
+// ErrModuleNotFound is returned by resolvers if the requested module is unknown to them.
+var ErrModuleNotFound = errors.New("module not found")
+
+// Resolver resolves modules by name for Invoke.
+type Resolver interface {
+	Resolve(ctx context.Context, name string) (FrameworkModule, error)
+}
+
+// ChainResolver tries the resolvers in turn and returns the first module found.
+type ChainResolver []Resolver
+
+// Resolve implements Resolver.
+func (c ChainResolver) Resolve(ctx context.Context, name string) (FrameworkModule, error) {
+	for _, r := range c {
+		m, err := r.Resolve(ctx, name)
+		if errors.Is(err, ErrModuleNotFound) {
+			continue
+		}
+		return m, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+}
+
+// Resolve returns the module registered as name, so that modules in the same binary can invoke each other.
+func (r *Registry) Resolve(_ context.Context, name string) (FrameworkModule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.modules[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	return m, nil
+}
+
+// WithResolver sets the resolver of modules called by Invoke. Defaults to the modules registered by
+// Register, and then the module binaries in the directories of KUSION_MODULE_PATH.
+func WithResolver(r Resolver) ServeOption {
+	return func(o *serveOptions) {
+		o.resolver = r
+	}
+}
+
+type resolverKey struct{}
+
+// ContextWithResolver returns a copy of ctx in which Invoke resolves modules with r.
+func ContextWithResolver(ctx context.Context, r Resolver) context.Context {
+	return context.WithValue(ctx, resolverKey{}, r)
+}
+
+var (
+	defaultResolverOnce sync.Once
+	defaultResolver     Resolver
+)
+
+func resolverFrom(ctx context.Context) Resolver {
+	if r, ok := ctx.Value(resolverKey{}).(Resolver); ok && r != nil {
+		return r
+	}
+	defaultResolverOnce.Do(func() {
+		defaultResolver = newDefaultResolver()
+	})
+	return defaultResolver
+}
+
+// Invoke calls the module of name with req from within the Generate method of another module, so that
+// higher-level modules can delegate to lower-level modules and aggregate their resources. The module
+// is resolved by the resolver in ctx, see WithResolver. req is copied with its Module set to name.
+func Invoke(ctx context.Context, name string, req *GeneratorRequest) (*GeneratorResponse, error) {
+	m, err := resolverFrom(ctx).Resolve(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("resolve module %s failed. %w", name, err)
+	}
+	sub := *req
+	sub.Module = name
+	if v, ok := m.(Validator); ok {
+		if err = v.Validate(ctx, &sub); err != nil {
+			return nil, fmt.Errorf("validate request of module %s failed. %w", name, err)
+		}
+	}
+	resp, err := m.Generate(ctx, &sub)
+	if err != nil {
+		return nil, fmt.Errorf("invoke module %s failed. %w", name, err)
+	}
+	if resp == nil {
+		resp = &GeneratorResponse{}
+	}
+	return resp, nil
+}
+
+// NewClientModule returns a FrameworkModule calling the module plugin served on conn, which converts
+// requests and responses the same way as the engine does.
+func NewClientModule(conn grpc.ClientConnInterface, opts ...grpc.CallOption) FrameworkModule {
+	return &pluginModule{conn: conn, opts: opts}
+}
+
+// pluginModule is a FrameworkModule calling a module plugin.
+type pluginModule struct {
+	conn grpc.ClientConnInterface
+	opts []grpc.CallOption
+}
+
+func (m *pluginModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	protoReq, err := req.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	md, err := requestMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = metadata.Join(outgoing, md)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	var header metadata.MD
+	protoResp, err := proto.NewModuleClient(m.conn).Generate(ctx, protoReq, append([]grpc.CallOption{grpc.Header(&header)}, m.opts...)...)
+	if err != nil {
+		if e, ok := ErrorFromStatus(err); ok {
+			return nil, e
+		}
+		return nil, err
+	}
+	return decodeResponse(protoResp, header)
+}
+
+// decodeResponse decodes the proto response and its gRPC response header into a GeneratorResponse.
+func decodeResponse(protoResp *proto.GeneratorResponse, header metadata.MD) (*GeneratorResponse, error) {
+	// plugins built with older frameworks ignore the encoding header and return YAML
+	var serializer Serializer = yamlSerializer{}
+	if values := header.Get(ResourceEncodingMetadataKey); len(values) > 0 {
+		var err error
+		if serializer, err = SerializerFor(values[0]); err != nil {
+			return nil, err
+		}
+	}
+	resp := &GeneratorResponse{}
+	for _, out := range protoResp.Resources {
+		var res v1.Resource
+		if err := serializer.Unmarshal(out, &res); err != nil {
+			return nil, fmt.Errorf("unmarshal resource failed. %w", err)
+		}
+		resp.Resources = append(resp.Resources, res)
+	}
+	if values := header.Get(PatcherMetadataKey); len(values) > 0 {
+		resp.Patcher = &Patcher{}
+		if err := json.Unmarshal([]byte(values[0]), resp.Patcher); err != nil {
+			return nil, fmt.Errorf("unmarshal patcher failed. %w", err)
+		}
+	}
+	if values := header.Get(CostMetadataKey); len(values) > 0 {
+		if err := json.Unmarshal([]byte(values[0]), &resp.Costs); err != nil {
+			return nil, fmt.Errorf("unmarshal cost estimates failed. %w", err)
+		}
+	}
+	if values := header.Get(OutputsMetadataKey); len(values) > 0 {
+		if err := json.Unmarshal([]byte(values[0]), &resp.Outputs); err != nil {
+			return nil, fmt.Errorf("unmarshal outputs failed. %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// ToProto converts the request into the proto request sent to module plugins.
+func (r *GeneratorRequest) ToProto() (*proto.GeneratorRequest, error) {
+	req := &proto.GeneratorRequest{Project: r.Project, Stack: r.Stack, App: r.App}
+	var err error
+	if r.lazyWorkload != nil && r.lazyWorkload.decoded() {
+		// the workload may be decoded by another copy of the request
+		if err = r.decodeLazyWorkload(); err != nil {
+			return nil, fmt.Errorf("decode workload failed. %w", err)
+		}
+	}
+	switch {
+	case r.lazyWorkload != nil && !r.lazyWorkload.decoded():
+		req.Workload = r.lazyWorkload.data
+	case len(r.Workloads) > 1:
+		req.Workload, err = yaml.Marshal(r.Workloads)
+	case r.Workload != nil:
+		req.Workload, err = yaml.Marshal(r.Workload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal workload failed. %w", err)
+	}
+	if r.DevModuleConfig != nil {
+		if req.DevModuleConfig, err = yaml.Marshal(r.DevModuleConfig); err != nil {
+			return nil, fmt.Errorf("marshal dev module config failed. %w", err)
+		}
+	}
+	if r.PlatformModuleConfig != nil {
+		if req.PlatformModuleConfig, err = yaml.Marshal(r.PlatformModuleConfig); err != nil {
+			return nil, fmt.Errorf("marshal platform module config failed. %w", err)
+		}
+	}
+	if r.RuntimeConfig != nil {
+		if req.RuntimeConfig, err = yaml.Marshal(r.RuntimeConfig); err != nil {
+			return nil, fmt.Errorf("marshal runtime config failed. %w", err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,168 @@
+//go:build !wasip1
+
+package module
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"kusionstack.io/kusion/pkg/modules"
+)
+
+// newDefaultResolver resolves the registered modules, then the plugins in the directories of ModulePathEnv.
+func newDefaultResolver() Resolver {
+	return ChainResolver{defaultRegistry, NewPluginResolver(filepath.SplitList(os.Getenv(ModulePathEnv))...)}
+}
+
+// PluginResolver resolves modules by starting their plugin binaries, named kusion-module-<name> or <name>
+// in one of the directories. Started plugins are reused until Close is called.
+type PluginResolver struct {
+	// Dirs is the directories searched for module binaries in order
+	Dirs []string
+	// Logger is the logger of plugin clients, a logger discarding logs is used if nil
+	Logger hclog.Logger
+	// Compression enables gzip compression of the requests and responses of plugins
+	Compression bool
+	// TLS is the client TLS configuration of plugins served over TLS, see WithTLS
+	TLS *TLSConfig
+
+	mu      sync.Mutex
+	clients map[string]*plugin.Client
+}
+
+// NewPluginResolver returns a PluginResolver searching dirs.
+func NewPluginResolver(dirs ...string) *PluginResolver {
+	return &PluginResolver{Dirs: dirs}
+}
+
+// Resolve implements Resolver.
+func (p *PluginResolver) Resolve(_ context.Context, name string) (FrameworkModule, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[name]; ok && !c.Exited() {
+		return p.dispense(c)
+	}
+	path := p.lookup(name)
+	if path == "" {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	var tlsConfig *tls.Config
+	if p.TLS != nil {
+		var err error
+		if tlsConfig, err = p.TLS.ClientConfig(); err != nil {
+			return nil, err
+		}
+	}
+	c := newPluginClient(path, p.Logger, tlsConfig)
+	if p.clients == nil {
+		p.clients = map[string]*plugin.Client{}
+	}
+	p.clients[name] = c
+	return p.dispense(c)
+}
+
+// Close kills all started plugins.
+func (p *PluginResolver) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, c := range p.clients {
+		c.Kill()
+		delete(p.clients, name)
+	}
+}
+
+// Cleanup implements Cleaner by closing the started plugins.
+func (p *PluginResolver) Cleanup(_ context.Context) error {
+	p.Close()
+	return nil
+}
+
+func (p *PluginResolver) lookup(name string) string {
+	if strings.ContainsAny(name, `/\`) {
+		return ""
+	}
+	for _, dir := range p.Dirs {
+		for _, file := range []string{"kusion-module-" + name, name} {
+			path := filepath.Join(dir, file)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func (p *PluginResolver) dispense(c *plugin.Client) (FrameworkModule, error) {
+	conn, err := dispenseConn(c)
+	if err != nil {
+		return nil, err
+	}
+	m := &pluginModule{conn: conn}
+	if p.Compression {
+		m.opts = append(m.opts, CompressedCall())
+	}
+	return m, nil
+}
+
+// LaunchPlugin starts the module plugin binary at path and returns the gRPC connection to it, on which
+// both the module and the framework services can be called. Call kill to stop the plugin. A logger
+// discarding logs is used if logger is nil.
+func LaunchPlugin(path string, logger hclog.Logger) (conn *grpc.ClientConn, kill func(), err error) {
+	c := newPluginClient(path, logger, nil)
+	conn, err = dispenseConn(c)
+	if err != nil {
+		c.Kill()
+		return nil, nil, err
+	}
+	return conn, c.Kill, nil
+}
+
+func newPluginClient(path string, logger hclog.Logger, tlsConfig *tls.Config) *plugin.Client {
+	if logger == nil {
+		logger = hclog.NewNullLogger()
+	}
+	return plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  HandshakeConfig,
+		Plugins:          map[string]plugin.Plugin{modules.PluginKey: &invokePlugin{}},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		Logger:           logger,
+		TLSConfig:        tlsConfig,
+	})
+}
+
+func dispenseConn(c *plugin.Client) (*grpc.ClientConn, error) {
+	rpcClient, err := c.Client()
+	if err != nil {
+		return nil, fmt.Errorf("start module plugin failed. %w", err)
+	}
+	raw, err := rpcClient.Dispense(modules.PluginKey)
+	if err != nil {
+		return nil, fmt.Errorf("dispense module plugin failed. %w", err)
+	}
+	return raw.(*grpc.ClientConn), nil
+}
+
+// invokePlugin is the client side of module plugins, which dispenses the gRPC connection itself so that
+// both the module and the framework services can be called.
+type invokePlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+}
+
+func (p *invokePlugin) GRPCServer(_ *plugin.GRPCBroker, _ *grpc.Server) error {
+	return errors.New("invoke plugin is client only")
+}
+
+func (p *invokePlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return c, nil
+}
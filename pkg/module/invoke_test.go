@@ -0,0 +1,61 @@
+package module
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// TestMain serves priorStateModule when the test binary is started as a module plugin by the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv(HandshakeConfig.MagicCookieKey) == HandshakeConfig.MagicCookieValue {
+		Serve(&priorStateModule{})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// priorStateModule generates the resources of the prior state of requests.
+type priorStateModule struct{}
+
+func (m *priorStateModule) Generate(_ context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	return &GeneratorResponse{Resources: req.PriorState}, nil
+}
+
+func TestPluginResolverSendsPriorState(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Symlink(exe, filepath.Join(dir, "kusion-module-prior-state")); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewPluginResolver(dir)
+	defer resolver.Close()
+	m, err := resolver.Resolve(context.Background(), "prior-state")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	prior := []v1.Resource{{
+		ID:   "v1:ConfigMap:app:config",
+		Type: v1.Kubernetes,
+		Attributes: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "config", "namespace": "app"},
+		},
+	}}
+	resp, err := m.Generate(context.Background(), &GeneratorRequest{Project: "p", Stack: "dev", App: "app", Module: "prior-state", PriorState: prior})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !reflect.DeepEqual(resp.Resources, prior) {
+		t.Errorf("generated resources = %+v, want the prior state %+v", resp.Resources, prior)
+	}
+}
@@ -0,0 +1,172 @@
+package module
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"kusionstack.io/kusion-module-framework/pkg/internal/yamlfield"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateJSONSchema generates the JSON schema of configStruct, a struct or a pointer to a struct
+// decoding dev_module_config or platform_module_config, so that registries and IDEs can validate configs.
+//
+// Property names follow the yaml tags. Fields without omitempty or a default that are not pointers are required.
+// The `description` and `default` tags set the description and default value of a property, and
+// the `enum` tag lists the comma separated allowed values, e.g. `enum:"small,medium,large"`.
+// Nested structs are emitted as definitions referenced with $ref.
+func GenerateJSONSchema(configStruct interface{}) ([]byte, error) {
+	t := reflect.TypeOf(configStruct)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("generate json schema failed: expected a struct, got %T", configStruct)
+	}
+	g := &jsonSchemaGenerator{defs: map[string]any{}}
+	root, err := g.object(t)
+	if err != nil {
+		return nil, fmt.Errorf("generate json schema failed: %w", err)
+	}
+	root["$schema"] = jsonSchemaDraft
+	if t.Name() != "" {
+		root["title"] = t.Name()
+	}
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+type jsonSchemaGenerator struct {
+	defs map[string]any
+}
+
+func (g *jsonSchemaGenerator) object(t reflect.Type) (map[string]any, error) {
+	properties := map[string]any{}
+	var required []string
+	if err := g.properties(t, properties, &required); err != nil {
+		return nil, err
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+func (g *jsonSchemaGenerator) properties(t reflect.Type, properties map[string]any, required *[]string) error {
+	return yamlfield.Walk(t, func(sf yamlfield.Field) error {
+		if sf.YAML.Inline {
+			return fmt.Errorf("inlined field %s.%s must be a struct", t.Name(), sf.Name)
+		}
+		prop, err := g.schema(sf.Type)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), sf.Name, err)
+		}
+		if desc := sf.Tag.Get("description"); desc != "" {
+			prop["description"] = desc
+		}
+		def, hasDefault := sf.Tag.Lookup("default")
+		if hasDefault {
+			if prop["default"], err = typedValue(sf.Type, def); err != nil {
+				return fmt.Errorf("default of field %s.%s: %w", t.Name(), sf.Name, err)
+			}
+		}
+		if enum, ok := sf.Tag.Lookup("enum"); ok {
+			var values []any
+			for _, e := range strings.Split(enum, ",") {
+				v, err := typedValue(sf.Type, strings.TrimSpace(e))
+				if err != nil {
+					return fmt.Errorf("enum of field %s.%s: %w", t.Name(), sf.Name, err)
+				}
+				values = append(values, v)
+			}
+			prop["enum"] = values
+		}
+		properties[sf.YAML.Name] = prop
+		if !sf.YAML.OmitEmpty && !hasDefault && sf.Type.Kind() != reflect.Pointer {
+			*required = append(*required, sf.YAML.Name)
+		}
+		return nil
+	})
+}
+
+func (g *jsonSchemaGenerator) schema(t reflect.Type) (map[string]any, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key of %s must be a string", t)
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// reserve the definition before generating it to support recursive types
+			g.defs[t.Name()] = nil
+			def, err := g.object(t)
+			if err != nil {
+				return nil, err
+			}
+			g.defs[t.Name()] = def
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// typedValue converts the tag value into a value of the JSON type corresponding to t.
+func typedValue(t reflect.Type, value string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	case reflect.String:
+		return value, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return nil, fmt.Errorf("%q is not a valid JSON value of %s", value, t)
+	}
+	return v, nil
+}
@@ -0,0 +1,47 @@
+package module
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type schemaTestConfig struct {
+	schemaTestMeta `yaml:",inline"`
+	Image          string            `yaml:"image" description:"image of the app"`
+	Replicas       int               `yaml:"replicas" default:"1"`
+	Size           string            `yaml:"size,omitempty" enum:"small,large"`
+	Port           *int              `yaml:"port"`
+	Labels         map[string]string `yaml:"labels,omitempty"`
+	Internal       string            `yaml:"-"`
+}
+
+type schemaTestMeta struct {
+	Name string `yaml:"name"`
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	data, err := GenerateJSONSchema(&schemaTestConfig{})
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema() error = %v", err)
+	}
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err = json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "image"}; !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("required = %v, want %v without fields with defaults, omitempty or pointers", schema.Required, want)
+	}
+	if got := len(schema.Properties); got != 6 {
+		t.Errorf("schema has %d properties, want 6", got)
+	}
+	if got := schema.Properties["replicas"]["default"]; got != float64(1) {
+		t.Errorf("default of replicas = %v, want 1", got)
+	}
+	if _, ok := schema.Properties["internal"]; ok {
+		t.Errorf("field tagged - is in the schema")
+	}
+}
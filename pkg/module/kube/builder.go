@@ -0,0 +1,163 @@
+package kube
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Builder builds a Kubernetes object and wraps it into a Kusion resource.
+// The zero value is not usable, use New or one of the kind-specific constructors.
+type Builder struct {
+	obj *unstructured.Unstructured
+}
+
+// New returns a Builder of the Kubernetes object with the given apiVersion, kind, namespace and name.
+// The namespace can be empty for cluster-scoped objects.
+func New(apiVersion, kind, namespace, name string) *Builder {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	if namespace != "" {
+		obj.SetNamespace(namespace)
+	}
+	return &Builder{obj: obj}
+}
+
+// Deployment returns a Builder of an apps/v1 Deployment.
+func Deployment(namespace, name string) *Builder {
+	return New("apps/v1", "Deployment", namespace, name)
+}
+
+// StatefulSet returns a Builder of an apps/v1 StatefulSet.
+func StatefulSet(namespace, name string) *Builder {
+	return New("apps/v1", "StatefulSet", namespace, name)
+}
+
+// Service returns a Builder of a v1 Service.
+func Service(namespace, name string) *Builder {
+	return New("v1", "Service", namespace, name)
+}
+
+// ConfigMap returns a Builder of a v1 ConfigMap.
+func ConfigMap(namespace, name string) *Builder {
+	return New("v1", "ConfigMap", namespace, name)
+}
+
+// Secret returns a Builder of a v1 Secret.
+func Secret(namespace, name string) *Builder {
+	return New("v1", "Secret", namespace, name)
+}
+
+// Ingress returns a Builder of a networking.k8s.io/v1 Ingress.
+func Ingress(namespace, name string) *Builder {
+	return New("networking.k8s.io/v1", "Ingress", namespace, name)
+}
+
+// Job returns a Builder of a batch/v1 Job.
+func Job(namespace, name string) *Builder {
+	return New("batch/v1", "Job", namespace, name)
+}
+
+// CronJob returns a Builder of a batch/v1 CronJob.
+func CronJob(namespace, name string) *Builder {
+	return New("batch/v1", "CronJob", namespace, name)
+}
+
+// WithLabels merges labels into the labels of the object.
+func (b *Builder) WithLabels(labels map[string]string) *Builder {
+	b.obj.SetLabels(mergeStringMap(b.obj.GetLabels(), labels))
+	return b
+}
+
+// WithAnnotations merges annotations into the annotations of the object.
+func (b *Builder) WithAnnotations(annotations map[string]string) *Builder {
+	b.obj.SetAnnotations(mergeStringMap(b.obj.GetAnnotations(), annotations))
+	return b
+}
+
+// WithSpec sets the spec of the object.
+func (b *Builder) WithSpec(spec map[string]interface{}) *Builder {
+	return b.WithField(spec, "spec")
+}
+
+// WithReplicas sets spec.replicas of workload objects such as Deployment and StatefulSet.
+func (b *Builder) WithReplicas(replicas int32) *Builder {
+	return b.WithField(int64(replicas), "spec", "replicas")
+}
+
+// WithData sets the data of ConfigMap or Secret objects. Data of Secret objects must be base64 encoded,
+// use WithStringData for plain text.
+func (b *Builder) WithData(data map[string]string) *Builder {
+	return b.WithField(toInterfaceMap(data), "data")
+}
+
+// WithStringData sets the stringData of Secret objects.
+func (b *Builder) WithStringData(data map[string]string) *Builder {
+	return b.WithField(toInterfaceMap(data), "stringData")
+}
+
+// WithField sets the value of the nested field specified by fields, creating intermediate maps as needed.
+func (b *Builder) WithField(value interface{}, fields ...string) *Builder {
+	if len(fields) == 0 {
+		return b
+	}
+	m := b.obj.Object
+	for _, field := range fields[:len(fields)-1] {
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[field] = next
+		}
+		m = next
+	}
+	m[fields[len(fields)-1]] = value
+	return b
+}
+
+// Object returns the underlying unstructured object.
+func (b *Builder) Object() *unstructured.Unstructured {
+	return b.obj
+}
+
+// ID returns the Kusion resource ID of the object.
+func (b *Builder) ID() string {
+	return module.KubernetesResourceID(
+		metav1.TypeMeta{APIVersion: b.obj.GetAPIVersion(), Kind: b.obj.GetKind()},
+		metav1.ObjectMeta{Namespace: b.obj.GetNamespace(), Name: b.obj.GetName()},
+	)
+}
+
+// Build wraps the object into a Kusion resource.
+func (b *Builder) Build() (*v1.Resource, error) {
+	if b.obj.GetAPIVersion() == "" || b.obj.GetKind() == "" {
+		return nil, fmt.Errorf("apiVersion and kind of the kubernetes object must not be empty")
+	}
+	if b.obj.GetName() == "" {
+		return nil, fmt.Errorf("name of the %s must not be empty", b.obj.GetKind())
+	}
+	return module.WrapK8sResourceToKusionResource(b.ID(), b.obj)
+}
+
+func mergeStringMap(dst, src map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
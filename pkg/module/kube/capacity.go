@@ -0,0 +1,223 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/workloadutil"
+)
+
+// AllContainers is the key of Capacity.Resources applying to every container of the workload.
+const AllContainers = "*"
+
+// ResourceRequirements are the requests and limits of a container, e.g. {"cpu": "500m", "memory": "1Gi"}.
+type ResourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// Toleration is a toleration of node taints.
+type Toleration struct {
+	Key               string `json:"key,omitempty" yaml:"key,omitempty"`
+	Operator          string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value             string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect            string `json:"effect,omitempty" yaml:"effect,omitempty"`
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty" yaml:"tolerationSeconds,omitempty"`
+}
+
+// Capacity is the replicas, resources and scheduling constraints a capacity or scheduling module sets
+// on the workload. It is sent as a strategic-merge patch, so the module does not need to know the JSON
+// paths in the generated Deployment or Job. Unset fields are not changed.
+type Capacity struct {
+	// Replicas is the number of replicas of service workloads
+	Replicas *int32
+	// Resources are the requirements by container name, AllContainers applies to every container and is
+	// overridden by the requirements of the container itself
+	Resources map[string]ResourceRequirements
+	// NodeSelector is merged with the node selector of the pod
+	NodeSelector map[string]string
+	// Tolerations replace the tolerations of the pod, as tolerations have no merge key
+	Tolerations []Toleration
+	// Affinity is merged with the affinity of the pod, e.g. {"nodeAffinity": {...}}
+	Affinity map[string]any
+}
+
+// SetReplicas returns the patcher setting the replicas of the workload.
+func SetReplicas(replicas int32) (*module.Patcher, error) {
+	return (&Capacity{Replicas: &replicas}).Patcher(nil)
+}
+
+// SetResources returns the patcher setting the requirements of the containers of w, see Capacity.Resources.
+func SetResources(w *workload.Workload, resources map[string]ResourceRequirements) (*module.Patcher, error) {
+	return (&Capacity{Resources: resources}).Patcher(w)
+}
+
+// Patcher validates the capacity and converts it into a patcher of the workload w, whose container
+// names are needed to patch resources.
+func (c *Capacity) Patcher(w *workload.Workload) (*module.Patcher, error) {
+	spec := map[string]any{}
+	podSpec := map[string]any{}
+	if c.Replicas != nil {
+		if *c.Replicas < 0 {
+			return nil, fmt.Errorf("replicas must not be negative, got %d", *c.Replicas)
+		}
+		if w != nil && w.Header.Type != workload.TypeService {
+			return nil, fmt.Errorf("replicas can only be set on service workloads, got %s", w.Header.Type)
+		}
+		spec["replicas"] = int64(*c.Replicas)
+	}
+	if len(c.Resources) > 0 {
+		containers, err := c.containerResources(w)
+		if err != nil {
+			return nil, err
+		}
+		podSpec["containers"] = containers
+	}
+	if len(c.NodeSelector) > 0 {
+		podSpec["nodeSelector"] = toInterfaceMap(c.NodeSelector)
+	}
+	if c.Tolerations != nil {
+		tolerations := make([]any, 0, len(c.Tolerations))
+		for i, t := range c.Tolerations {
+			if err := t.validate(); err != nil {
+				return nil, fmt.Errorf("invalid toleration at index %d: %w", i, err)
+			}
+			m, err := toMap(t)
+			if err != nil {
+				return nil, err
+			}
+			tolerations = append(tolerations, m)
+		}
+		podSpec["tolerations"] = tolerations
+	}
+	if len(c.Affinity) > 0 {
+		for k := range c.Affinity {
+			switch k {
+			case "nodeAffinity", "podAffinity", "podAntiAffinity":
+			default:
+				return nil, fmt.Errorf("unknown affinity %q, expected nodeAffinity, podAffinity or podAntiAffinity", k)
+			}
+		}
+		affinity, err := toMap(c.Affinity)
+		if err != nil {
+			return nil, err
+		}
+		podSpec["affinity"] = affinity
+	}
+	if len(podSpec) > 0 {
+		spec["template"] = map[string]any{"spec": podSpec}
+	}
+	if len(spec) == 0 {
+		return &module.Patcher{}, nil
+	}
+	return &module.Patcher{StrategicMergePatch: map[string]any{"spec": spec}}, nil
+}
+
+// containerResources returns the containers of the patch with their merged requirements.
+func (c *Capacity) containerResources(w *workload.Workload) ([]any, error) {
+	names := workloadutil.ContainerNames(w)
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+	for name, r := range c.Resources {
+		if name != AllContainers && !known[name] {
+			return nil, fmt.Errorf("container %q not found in the workload", name)
+		}
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("invalid resources of container %s: %w", name, err)
+		}
+	}
+	var containers []any
+	for _, name := range names {
+		all, own := c.Resources[AllContainers], c.Resources[name]
+		merged := ResourceRequirements{
+			Requests: mergeStrings(all.Requests, own.Requests),
+			Limits:   mergeStrings(all.Limits, own.Limits),
+		}
+		if len(merged.Requests) == 0 && len(merged.Limits) == 0 {
+			continue
+		}
+		if err := merged.validate(); err != nil {
+			return nil, fmt.Errorf("invalid resources of container %s: %w", name, err)
+		}
+		resources, err := toMap(merged)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, map[string]any{"name": name, "resources": resources})
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("no containers found in the workload to set resources on")
+	}
+	return containers, nil
+}
+
+// validate checks the quantities and that the requests do not exceed the limits.
+func (r ResourceRequirements) validate() error {
+	limits := map[string]resource.Quantity{}
+	for name, v := range r.Limits {
+		q, err := module.ParseQuantity(map[string]any{name: v}, name, "")
+		if err != nil {
+			return err
+		}
+		limits[name] = q
+	}
+	names := make([]string, 0, len(r.Requests))
+	for name := range r.Requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		q, err := module.ParseQuantity(map[string]any{name: r.Requests[name]}, name, "")
+		if err != nil {
+			return err
+		}
+		if limit, ok := limits[name]; ok && q.Cmp(limit) > 0 {
+			return fmt.Errorf("request %s of %s exceeds the limit %s", r.Requests[name], name, r.Limits[name])
+		}
+	}
+	return nil
+}
+
+func (t Toleration) validate() error {
+	switch t.Operator {
+	case "", "Equal":
+		if t.Key == "" && t.Operator == "Equal" {
+			return fmt.Errorf("key is required by the Equal operator")
+		}
+	case "Exists":
+		if t.Value != "" {
+			return fmt.Errorf("value must be empty with the Exists operator")
+		}
+	default:
+		return fmt.Errorf("unknown operator %q, expected Equal or Exists", t.Operator)
+	}
+	switch t.Effect {
+	case "", "NoSchedule", "PreferNoSchedule", "NoExecute":
+	default:
+		return fmt.Errorf("unknown effect %q, expected NoSchedule, PreferNoSchedule or NoExecute", t.Effect)
+	}
+	if t.TolerationSeconds != nil && t.Effect != "NoExecute" {
+		return fmt.Errorf("tolerationSeconds requires the NoExecute effect")
+	}
+	return nil
+}
+
+func mergeStrings(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		out[k] = v
+	}
+	return out
+}
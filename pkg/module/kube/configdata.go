@@ -0,0 +1,164 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// ChecksumAnnotationPrefix is the prefix of the pod template annotations carrying the content hash of
+// mounted ConfigMaps and Secrets, so that changing their contents rolls out the workload.
+const ChecksumAnnotationPrefix = "checksum.kusion.io/"
+
+// ConfigData collects the entries of a ConfigMap or Secret from literals, files and templates.
+// Errors are kept until Data, ConfigMap or Secret is called, so calls can be chained.
+type ConfigData struct {
+	data map[string]string
+	err  error
+}
+
+// NewConfigData returns an empty ConfigData.
+func NewConfigData() *ConfigData {
+	return &ConfigData{data: map[string]string{}}
+}
+
+// WithLiteral adds the entries of data.
+func (d *ConfigData) WithLiteral(data map[string]string) *ConfigData {
+	for k, v := range data {
+		d.set(k, v)
+	}
+	return d
+}
+
+// WithFile adds the file at name in fsys, e.g. an embed.FS, keyed by its base name.
+func (d *ConfigData) WithFile(fsys fs.FS, name string) *ConfigData {
+	if d.err != nil {
+		return d
+	}
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		d.err = fmt.Errorf("read config file %s failed. %w", name, err)
+		return d
+	}
+	d.set(path.Base(name), string(content))
+	return d
+}
+
+// WithFiles adds the files matching pattern in fsys, keyed by their base names.
+func (d *ConfigData) WithFiles(fsys fs.FS, pattern string) *ConfigData {
+	if d.err != nil {
+		return d
+	}
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		d.err = fmt.Errorf("match config files %s failed. %w", pattern, err)
+		return d
+	}
+	if len(names) == 0 {
+		d.err = fmt.Errorf("no config files match %s", pattern)
+		return d
+	}
+	for _, name := range names {
+		d.WithFile(fsys, name)
+	}
+	return d
+}
+
+// WithTemplate adds the Go template at name in fsys rendered with data, e.g. the generator request
+// or the decoded module config, with the module.TemplateFuncs. The entry is keyed by the base name
+// without the .tmpl suffix, and referencing missing map keys fails the rendering.
+func (d *ConfigData) WithTemplate(fsys fs.FS, name string, data any) *ConfigData {
+	if d.err != nil {
+		return d
+	}
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		d.err = fmt.Errorf("read config template %s failed. %w", name, err)
+		return d
+	}
+	out, err := module.RenderTemplate(string(content), data)
+	if err != nil {
+		d.err = fmt.Errorf("render config template %s failed. %w", name, err)
+		return d
+	}
+	d.set(strings.TrimSuffix(path.Base(name), ".tmpl"), out)
+	return d
+}
+
+func (d *ConfigData) set(key, value string) {
+	if d.err != nil {
+		return
+	}
+	if _, ok := d.data[key]; ok {
+		d.err = fmt.Errorf("duplicated config key %q", key)
+		return
+	}
+	d.data[key] = value
+}
+
+// Data returns the collected entries, or the first error.
+func (d *ConfigData) Data() (map[string]string, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.data, nil
+}
+
+// Hash returns the hex SHA-256 of the entries, which is stable regardless of the order they were added.
+func (d *ConfigData) Hash() (string, error) {
+	data, err := d.Data()
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%d:%s%d:%s", len(k), k, len(data[k]), data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ConfigMap returns the ConfigMap of the entries.
+func (d *ConfigData) ConfigMap(namespace, name string) (*v1.Resource, error) {
+	data, err := d.Data()
+	if err != nil {
+		return nil, err
+	}
+	return ConfigMap(namespace, name).WithData(data).Build()
+}
+
+// Secret returns the Opaque Secret of the entries.
+func (d *ConfigData) Secret(namespace, name string) (*v1.Resource, error) {
+	data, err := d.Data()
+	if err != nil {
+		return nil, err
+	}
+	encoded := make(map[string]string, len(data))
+	for k, v := range data {
+		encoded[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	return Secret(namespace, name).WithField("Opaque", "type").WithData(encoded).Build()
+}
+
+// RolloutPatch returns the patcher annotating the pod template of the workload with the hash of the
+// entries under ChecksumAnnotationPrefix + name, so the workload rolls out when the contents change.
+func (d *ConfigData) RolloutPatch(name string) (*module.Patcher, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return (&Injection{Annotations: map[string]string{ChecksumAnnotationPrefix + name: hash}}).Patcher()
+}
@@ -0,0 +1,298 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// CustomResource returns a Builder of a custom resource with the given spec, which is either a map or
+// a struct with json tags.
+func CustomResource(apiVersion, kind, namespace, name string, spec interface{}) (*Builder, error) {
+	b := New(apiVersion, kind, namespace, name)
+	if spec == nil {
+		return b, nil
+	}
+	if m, ok := spec.(map[string]interface{}); ok {
+		return b.WithSpec(m), nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal spec of %s %s failed. %w", kind, name, err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("spec of %s %s is not an object. %w", kind, name, err)
+	}
+	return b.WithSpec(m), nil
+}
+
+// CRD is the OpenAPI schemas of a CustomResourceDefinition, used to validate custom resources before apply.
+type CRD struct {
+	// Group is the API group of the custom resources
+	Group string
+	// Kind is the kind of the custom resources
+	Kind string
+
+	versions map[string]*Schema
+}
+
+// Schema is the subset of OpenAPI v3 schemas of CRDs checked by CRD.Validate.
+type Schema struct {
+	Type                  string             `json:"type,omitempty"`
+	Properties            map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties  *SchemaOrBool      `json:"additionalProperties,omitempty"`
+	Items                 *Schema            `json:"items,omitempty"`
+	Required              []string           `json:"required,omitempty"`
+	Enum                  []interface{}      `json:"enum,omitempty"`
+	Pattern               string             `json:"pattern,omitempty"`
+	Minimum               *float64           `json:"minimum,omitempty"`
+	Maximum               *float64           `json:"maximum,omitempty"`
+	Nullable              bool               `json:"nullable,omitempty"`
+	IntOrString           bool               `json:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool               `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	EmbeddedResource      bool               `json:"x-kubernetes-embedded-resource,omitempty"`
+}
+
+// SchemaOrBool is the value of additionalProperties, either a boolean or a schema.
+type SchemaOrBool struct {
+	Allows bool
+	Schema *Schema
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SchemaOrBool) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Allows); err == nil {
+		return nil
+	}
+	s.Allows = true
+	s.Schema = &Schema{}
+	return json.Unmarshal(data, s.Schema)
+}
+
+type crdManifest struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Schema struct {
+				OpenAPIV3Schema *Schema `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// LoadCRDs decodes the CustomResourceDefinitions in multi-document YAML or JSON manifests, such as
+// the CRD bundle of an operator embedded in the module. Other documents are skipped.
+func LoadCRDs(data []byte) ([]*CRD, error) {
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var crds []*CRD
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read crd document %d failed. %w", i, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		m := &crdManifest{}
+		if err = k8syaml.Unmarshal(doc, m); err != nil {
+			return nil, fmt.Errorf("unmarshal crd document %d failed. %w", i, err)
+		}
+		if m.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		crd := &CRD{Group: m.Spec.Group, Kind: m.Spec.Names.Kind, versions: map[string]*Schema{}}
+		for _, v := range m.Spec.Versions {
+			crd.versions[v.Name] = v.Schema.OpenAPIV3Schema
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
+
+// LoadCRDsFS is like LoadCRDs but reads the manifests from the file path in fsys, typically an embed.FS.
+func LoadCRDsFS(fsys fs.FS, path string) ([]*CRD, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("read crd file %s failed. %w", path, err)
+	}
+	return LoadCRDs(data)
+}
+
+// Validate checks the custom resource against the schema of its version. Unknown fields are reported
+// unless the schema preserves them, so that typos are caught before apply.
+func (c *CRD) Validate(obj *unstructured.Unstructured) error {
+	group, version, _ := strings.Cut(obj.GetAPIVersion(), "/")
+	if group != c.Group || obj.GetKind() != c.Kind {
+		return fmt.Errorf("%s %s is not defined by the crd of %s.%s", obj.GetAPIVersion(), obj.GetKind(), c.Kind, c.Group)
+	}
+	schema, ok := c.versions[version]
+	if !ok {
+		return fmt.Errorf("version %s is not served by the crd of %s.%s", version, c.Kind, c.Group)
+	}
+	if schema == nil {
+		return nil
+	}
+	root := make(map[string]interface{}, len(obj.Object))
+	for k, v := range obj.Object {
+		if !isObjectMetaField(k) {
+			root[k] = v
+		}
+	}
+	errs := schema.validateObject("", root)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid %s %s: %s", c.Kind, obj.GetName(), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateWith validates the object of the builder against the schema of crd.
+func (b *Builder) ValidateWith(crd *CRD) error {
+	return crd.Validate(b.obj)
+}
+
+func isObjectMetaField(field string) bool {
+	return field == "apiVersion" || field == "kind" || field == "metadata"
+}
+
+func (s *Schema) validate(path string, v interface{}) []string {
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must not be null", path)}
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, v) {
+		return []string{fmt.Sprintf("%s: unsupported value %v, expected one of %v", path, v, s.Enum)}
+	}
+	if s.IntOrString {
+		switch v.(type) {
+		case string, int, int32, int64, float64:
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must be an integer or a string", path)}
+	}
+	switch s.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an object", path)}
+		}
+		return s.validateObject(path, m)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an array", path)}
+		}
+		var errs []string
+		if s.Items != nil {
+			for i, item := range items {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+		return errs
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be a string", path)}
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(str) {
+				return []string{fmt.Sprintf("%s: %q does not match %s", path, str, s.Pattern)}
+			}
+		}
+	case "integer", "number":
+		f, ok := toFloat(v)
+		if !ok || (s.Type == "integer" && f != float64(int64(f))) {
+			return []string{fmt.Sprintf("%s: must be of type %s", path, s.Type)}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return []string{fmt.Sprintf("%s: %v is less than %v", path, v, *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return []string{fmt.Sprintf("%s: %v is greater than %v", path, v, *s.Maximum)}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: must be a boolean", path)}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(path string, m map[string]interface{}) []string {
+	var errs []string
+	for _, field := range s.Required {
+		if _, ok := m[field]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: required field is missing", joinPath(path, field)))
+		}
+	}
+	for k, v := range m {
+		fieldPath := joinPath(path, k)
+		if prop, ok := s.Properties[k]; ok {
+			errs = append(errs, prop.validate(fieldPath, v)...)
+			continue
+		}
+		switch {
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			errs = append(errs, s.AdditionalProperties.Schema.validate(fieldPath, v)...)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Allows,
+			s.PreserveUnknownFields,
+			s.EmbeddedResource && isObjectMetaField(k):
+		default:
+			errs = append(errs, fmt.Sprintf("%s: unknown field", fieldPath))
+		}
+	}
+	return errs
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if fmt.Sprint(value) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
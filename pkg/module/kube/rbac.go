@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// RBACAPIVersion is the API version of the RBAC objects.
+const RBACAPIVersion = "rbac.authorization.k8s.io/v1"
+
+// PolicyRule is a rule of a Role or ClusterRole.
+type PolicyRule struct {
+	APIGroups     []string `json:"apiGroups,omitempty" yaml:"apiGroups,omitempty"`
+	Resources     []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	ResourceNames []string `json:"resourceNames,omitempty" yaml:"resourceNames,omitempty"`
+	Verbs         []string `json:"verbs" yaml:"verbs"`
+}
+
+// Common verb sets of policy rules.
+var (
+	ReadVerbs  = []string{"get", "list", "watch"}
+	WriteVerbs = []string{"create", "update", "patch", "delete"}
+)
+
+// ReadOnlyRule allows reading the resources of the API group, e.g. ReadOnlyRule("", "configmaps").
+func ReadOnlyRule(apiGroup string, resources ...string) PolicyRule {
+	return PolicyRule{APIGroups: []string{apiGroup}, Resources: resources, Verbs: ReadVerbs}
+}
+
+// SecretReaderRule allows getting the named Secrets only, which is the least privilege to read the
+// credentials generated by a module.
+func SecretReaderRule(names ...string) PolicyRule {
+	return PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: names, Verbs: []string{"get"}}
+}
+
+// ConfigMapReaderRule allows getting and watching the named ConfigMaps only.
+func ConfigMapReaderRule(names ...string) PolicyRule {
+	return PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: names, Verbs: []string{"get", "watch"}}
+}
+
+// ServiceAccount returns a Builder of a v1 ServiceAccount. Annotations bind it to cloud identities,
+// e.g. eks.amazonaws.com/role-arn.
+func ServiceAccount(namespace, name string) *Builder {
+	return New("v1", "ServiceAccount", namespace, name)
+}
+
+// Role returns a Builder of a Role with the rules.
+func Role(namespace, name string, rules ...PolicyRule) (*Builder, error) {
+	return roleOf("Role", namespace, name, rules)
+}
+
+// ClusterRole returns a Builder of a ClusterRole with the rules.
+func ClusterRole(name string, rules ...PolicyRule) (*Builder, error) {
+	return roleOf("ClusterRole", "", name, rules)
+}
+
+func roleOf(kind, namespace, name string, rules []PolicyRule) (*Builder, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%s %s has no rules", kind, name)
+	}
+	list := make([]interface{}, 0, len(rules))
+	for i, r := range rules {
+		if len(r.Verbs) == 0 {
+			return nil, fmt.Errorf("rule %d of %s %s has no verbs", i, kind, name)
+		}
+		for _, verb := range r.Verbs {
+			if verb == "*" {
+				return nil, fmt.Errorf("rule %d of %s %s grants all verbs, list the needed verbs instead", i, kind, name)
+			}
+		}
+		m, err := toMap(r)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return New(RBACAPIVersion, kind, namespace, name).WithField(list, "rules"), nil
+}
+
+// RoleBinding returns a Builder of a RoleBinding granting the Role or ClusterRole roleName of roleKind
+// to the ServiceAccount serviceAccount in the namespace.
+func RoleBinding(namespace, name, roleKind, roleName, serviceAccount string) *Builder {
+	return New(RBACAPIVersion, "RoleBinding", namespace, name).
+		WithField(map[string]interface{}{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     roleKind,
+			"name":     roleName,
+		}, "roleRef").
+		WithField([]interface{}{map[string]interface{}{
+			"kind":      "ServiceAccount",
+			"name":      serviceAccount,
+			"namespace": namespace,
+		}}, "subjects")
+}
+
+// GrantToServiceAccount returns the Role with the rules and the RoleBinding granting it to the
+// ServiceAccount of the workload, both named name.
+func GrantToServiceAccount(namespace, name, serviceAccount string, rules ...PolicyRule) ([]v1.Resource, error) {
+	role, err := Role(namespace, name, rules...)
+	if err != nil {
+		return nil, err
+	}
+	var resources []v1.Resource
+	for _, b := range []*Builder{role, RoleBinding(namespace, name, "Role", name, serviceAccount)} {
+		res, err := b.Build()
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *res)
+	}
+	return resources, nil
+}
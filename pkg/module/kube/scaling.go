@@ -0,0 +1,207 @@
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Update modes of the VerticalPodAutoscaler.
+const (
+	VPAUpdateModeOff     = "Off"
+	VPAUpdateModeInitial = "Initial"
+	VPAUpdateModeAuto    = "Auto"
+)
+
+// ScalingPolicy is the declarative scaling config of a workload, translated into a HorizontalPodAutoscaler,
+// a VerticalPodAutoscaler and a PodDisruptionBudget.
+type ScalingPolicy struct {
+	// MinReplicas and MaxReplicas enable the HorizontalPodAutoscaler if MaxReplicas is set
+	MinReplicas int32 `yaml:"minReplicas,omitempty" json:"minReplicas,omitempty"`
+	MaxReplicas int32 `yaml:"maxReplicas,omitempty" json:"maxReplicas,omitempty"`
+	// TargetCPUUtilization and TargetMemoryUtilization are the average utilization percentages targeted by the HPA
+	TargetCPUUtilization    int32 `yaml:"targetCPUUtilization,omitempty" json:"targetCPUUtilization,omitempty"`
+	TargetMemoryUtilization int32 `yaml:"targetMemoryUtilization,omitempty" json:"targetMemoryUtilization,omitempty"`
+	// VerticalUpdateMode enables the VerticalPodAutoscaler with one of the VPAUpdateMode values
+	VerticalUpdateMode string `yaml:"verticalUpdateMode,omitempty" json:"verticalUpdateMode,omitempty"`
+	// MinAvailable or MaxUnavailable enable the PodDisruptionBudget, as a number or a percentage like 50%
+	MinAvailable   string `yaml:"minAvailable,omitempty" json:"minAvailable,omitempty"`
+	MaxUnavailable string `yaml:"maxUnavailable,omitempty" json:"maxUnavailable,omitempty"`
+}
+
+// ScaleTarget is the workload the scaling resources are bound to.
+type ScaleTarget struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	// Selector selects the pods of the workload, used by the PodDisruptionBudget
+	Selector map[string]string
+}
+
+// ScaleTargetFromID returns the scale target of the workload with the Kusion resource ID.
+func ScaleTargetFromID(id string, selector map[string]string) (*ScaleTarget, error) {
+	parts, err := module.ParseKubernetesResourceID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &ScaleTarget{
+		APIVersion: parts.GVK.GroupVersion().String(),
+		Kind:       parts.GVK.Kind,
+		Namespace:  parts.Namespace,
+		Name:       parts.Name,
+		Selector:   selector,
+	}, nil
+}
+
+// Validate checks the policy for invalid values and conflicting settings.
+func (p *ScalingPolicy) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return module.NewError(module.ErrCodeInvalidConfig, format, args...)
+	}
+	hpa := p.MaxReplicas > 0
+	if hpa {
+		if p.MinReplicas < 1 {
+			return invalid("minReplicas must be at least 1 when maxReplicas is set")
+		}
+		if p.MinReplicas > p.MaxReplicas {
+			return invalid("minReplicas %d must not exceed maxReplicas %d", p.MinReplicas, p.MaxReplicas)
+		}
+		if p.TargetCPUUtilization == 0 && p.TargetMemoryUtilization == 0 {
+			return invalid("targetCPUUtilization or targetMemoryUtilization is required when maxReplicas is set")
+		}
+	} else if p.MinReplicas > 0 || p.TargetCPUUtilization > 0 || p.TargetMemoryUtilization > 0 {
+		return invalid("maxReplicas is required to autoscale horizontally")
+	}
+	for _, m := range p.utilizationTargets() {
+		if m.value < 0 || m.value > 100 {
+			return invalid("%s %d must be between 1 and 100", m.field, m.value)
+		}
+	}
+	switch p.VerticalUpdateMode {
+	case "", VPAUpdateModeOff, VPAUpdateModeInitial:
+	case VPAUpdateModeAuto:
+		if hpa {
+			return module.NewError(module.ErrCodeInvalidConfig, "verticalUpdateMode Auto conflicts with horizontal autoscaling on cpu and memory").
+				WithHint("use verticalUpdateMode Off or Initial together with maxReplicas")
+		}
+	default:
+		return invalid("unsupported verticalUpdateMode %q", p.VerticalUpdateMode)
+	}
+	if p.MinAvailable != "" && p.MaxUnavailable != "" {
+		return invalid("minAvailable and maxUnavailable are mutually exclusive")
+	}
+	for _, b := range []struct{ field, value string }{{"minAvailable", p.MinAvailable}, {"maxUnavailable", p.MaxUnavailable}} {
+		if b.value == "" {
+			continue
+		}
+		if _, err := parseIntOrPercent(b.value); err != nil {
+			return invalid("%s %s", b.field, err)
+		}
+	}
+	// a budget keeping all replicas available blocks every voluntary eviction, e.g. node drains
+	if minAvailable, err := strconv.Atoi(p.MinAvailable); err == nil && hpa && int32(minAvailable) >= p.MinReplicas {
+		return invalid("minAvailable %d must be less than minReplicas %d", minAvailable, p.MinReplicas)
+	}
+	if p.MinAvailable == "100%" || p.MaxUnavailable == "0" || p.MaxUnavailable == "0%" {
+		return invalid("the disruption budget allows no voluntary disruption")
+	}
+	return nil
+}
+
+// Resources validates the policy and returns its scaling resources bound to target.
+func (p *ScalingPolicy) Resources(target *ScaleTarget) ([]v1.Resource, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if target.Name == "" || target.Kind == "" || target.APIVersion == "" {
+		return nil, fmt.Errorf("apiVersion, kind and name of the scale target must not be empty")
+	}
+	targetRef := map[string]interface{}{
+		"apiVersion": target.APIVersion,
+		"kind":       target.Kind,
+		"name":       target.Name,
+	}
+	var builders []*Builder
+	if p.MaxReplicas > 0 {
+		var metrics []interface{}
+		for _, m := range p.utilizationTargets() {
+			if m.value == 0 {
+				continue
+			}
+			metrics = append(metrics, map[string]interface{}{
+				"type": "Resource",
+				"resource": map[string]interface{}{
+					"name":   m.name,
+					"target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(m.value)},
+				},
+			})
+		}
+		builders = append(builders, New("autoscaling/v2", "HorizontalPodAutoscaler", target.Namespace, target.Name).WithSpec(map[string]interface{}{
+			"scaleTargetRef": targetRef,
+			"minReplicas":    int64(p.MinReplicas),
+			"maxReplicas":    int64(p.MaxReplicas),
+			"metrics":        metrics,
+		}))
+	}
+	if p.VerticalUpdateMode != "" {
+		builders = append(builders, New("autoscaling.k8s.io/v1", "VerticalPodAutoscaler", target.Namespace, target.Name).WithSpec(map[string]interface{}{
+			"targetRef":    targetRef,
+			"updatePolicy": map[string]interface{}{"updateMode": p.VerticalUpdateMode},
+		}))
+	}
+	if p.MinAvailable != "" || p.MaxUnavailable != "" {
+		if len(target.Selector) == 0 {
+			return nil, fmt.Errorf("selector of the scale target is required by the disruption budget")
+		}
+		spec := map[string]interface{}{"selector": map[string]interface{}{"matchLabels": toInterfaceMap(target.Selector)}}
+		if p.MinAvailable != "" {
+			spec["minAvailable"], _ = parseIntOrPercent(p.MinAvailable)
+		} else {
+			spec["maxUnavailable"], _ = parseIntOrPercent(p.MaxUnavailable)
+		}
+		builders = append(builders, New("policy/v1", "PodDisruptionBudget", target.Namespace, target.Name).WithSpec(spec))
+	}
+	resources := make([]v1.Resource, 0, len(builders))
+	for _, b := range builders {
+		res, err := b.Build()
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *res)
+	}
+	return resources, nil
+}
+
+type utilizationTarget struct {
+	name  string
+	field string
+	value int32
+}
+
+func (p *ScalingPolicy) utilizationTargets() []utilizationTarget {
+	return []utilizationTarget{
+		{"cpu", "targetCPUUtilization", p.TargetCPUUtilization},
+		{"memory", "targetMemoryUtilization", p.TargetMemoryUtilization},
+	}
+}
+
+// parseIntOrPercent returns the value as an int64 or a percentage string like 50%.
+func parseIntOrPercent(v string) (interface{}, error) {
+	if strings.HasSuffix(v, "%") {
+		n, err := strconv.Atoi(strings.TrimSuffix(v, "%"))
+		if err != nil || n < 0 || n > 100 {
+			return nil, fmt.Errorf("%q is not a valid percentage", v)
+		}
+		return v, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%q is not a non-negative number or percentage", v)
+	}
+	return int64(n), nil
+}
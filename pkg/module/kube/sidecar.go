@@ -0,0 +1,175 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Container is the subset of a Kubernetes container spec used by injected sidecars and init containers.
+type Container struct {
+	Name            string          `json:"name" yaml:"name"`
+	Image           string          `json:"image" yaml:"image"`
+	Command         []string        `json:"command,omitempty" yaml:"command,omitempty"`
+	Args            []string        `json:"args,omitempty" yaml:"args,omitempty"`
+	Env             []EnvVar        `json:"env,omitempty" yaml:"env,omitempty"`
+	Ports           []ContainerPort `json:"ports,omitempty" yaml:"ports,omitempty"`
+	VolumeMounts    []VolumeMount   `json:"volumeMounts,omitempty" yaml:"volumeMounts,omitempty"`
+	Resources       map[string]any  `json:"resources,omitempty" yaml:"resources,omitempty"`
+	SecurityContext map[string]any  `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
+	// RestartPolicy Always makes an init container a native sidecar on Kubernetes 1.29+
+	RestartPolicy string `json:"restartPolicy,omitempty" yaml:"restartPolicy,omitempty"`
+}
+
+// EnvVar is an environment variable of a container, with either a Value or a ValueFrom source.
+type EnvVar struct {
+	Name      string         `json:"name" yaml:"name"`
+	Value     string         `json:"value,omitempty" yaml:"value,omitempty"`
+	ValueFrom map[string]any `json:"valueFrom,omitempty" yaml:"valueFrom,omitempty"`
+}
+
+// ContainerPort is a port exposed by a container.
+type ContainerPort struct {
+	Name          string `json:"name,omitempty" yaml:"name,omitempty"`
+	ContainerPort int32  `json:"containerPort" yaml:"containerPort"`
+	Protocol      string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+}
+
+// VolumeMount mounts a pod volume into a container.
+type VolumeMount struct {
+	Name      string `json:"name" yaml:"name"`
+	MountPath string `json:"mountPath" yaml:"mountPath"`
+	SubPath   string `json:"subPath,omitempty" yaml:"subPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+}
+
+// Volume is a pod volume. Source is the volume source keyed by its type, e.g.
+// {"emptyDir": {}} or {"secret": {"secretName": "vault-token"}}.
+type Volume struct {
+	Name   string
+	Source map[string]any
+}
+
+// EmptyDirVolume returns an emptyDir volume, commonly shared between an app container and a sidecar.
+func EmptyDirVolume(name string) Volume {
+	return Volume{Name: name, Source: map[string]any{"emptyDir": map[string]any{}}}
+}
+
+// SecretVolume returns a volume of the Secret secretName in the workload namespace.
+func SecretVolume(name, secretName string) Volume {
+	return Volume{Name: name, Source: map[string]any{"secret": map[string]any{"secretName": secretName}}}
+}
+
+// ConfigMapVolume returns a volume of the ConfigMap configMapName in the workload namespace.
+func ConfigMapVolume(name, configMapName string) Volume {
+	return Volume{Name: name, Source: map[string]any{"configMap": map[string]any{"name": configMapName}}}
+}
+
+// Injection is the containers and volumes a module injects into the pod template of the workload.
+// It is sent as a strategic-merge patch, so containers and volumes are merged by name with the
+// existing ones and the module does not need to know the JSON patch paths of the workload.
+type Injection struct {
+	// Sidecars are appended to the containers of the pod
+	Sidecars []Container
+	// InitContainers are appended to the init containers of the pod
+	InitContainers []Container
+	// Volumes are appended to the volumes of the pod
+	Volumes []Volume
+	// Annotations are added to the pod template, e.g. to opt in to a mesh
+	Annotations map[string]string
+}
+
+// InjectSidecar returns the patcher injecting the sidecar c and the volumes into the workload.
+func InjectSidecar(c Container, volumes ...Volume) (*module.Patcher, error) {
+	return (&Injection{Sidecars: []Container{c}, Volumes: volumes}).Patcher()
+}
+
+// InjectInitContainer returns the patcher injecting the init container c and the volumes into the workload.
+func InjectInitContainer(c Container, volumes ...Volume) (*module.Patcher, error) {
+	return (&Injection{InitContainers: []Container{c}, Volumes: volumes}).Patcher()
+}
+
+// Patcher validates the injection and converts it into a patcher of the workload.
+func (i *Injection) Patcher() (*module.Patcher, error) {
+	podSpec := map[string]any{}
+	if len(i.Sidecars) > 0 {
+		containers, err := containerList(i.Sidecars)
+		if err != nil {
+			return nil, err
+		}
+		podSpec["containers"] = containers
+	}
+	if len(i.InitContainers) > 0 {
+		containers, err := containerList(i.InitContainers)
+		if err != nil {
+			return nil, err
+		}
+		podSpec["initContainers"] = containers
+	}
+	if len(i.Volumes) > 0 {
+		volumes := make([]any, 0, len(i.Volumes))
+		seen := map[string]bool{}
+		for _, v := range i.Volumes {
+			if v.Name == "" {
+				return nil, fmt.Errorf("volume name is empty")
+			}
+			if seen[v.Name] {
+				return nil, fmt.Errorf("duplicated volume %q", v.Name)
+			}
+			seen[v.Name] = true
+			volume := map[string]any{"name": v.Name}
+			for k, source := range v.Source {
+				volume[k] = source
+			}
+			volumes = append(volumes, volume)
+		}
+		podSpec["volumes"] = volumes
+	}
+	template := map[string]any{}
+	if len(podSpec) > 0 {
+		template["spec"] = podSpec
+	}
+	if len(i.Annotations) > 0 {
+		template["metadata"] = map[string]any{"annotations": toInterfaceMap(i.Annotations)}
+	}
+	if len(template) == 0 {
+		return &module.Patcher{}, nil
+	}
+	return &module.Patcher{
+		StrategicMergePatch: map[string]any{"spec": map[string]any{"template": template}},
+	}, nil
+}
+
+func containerList(containers []Container) ([]any, error) {
+	list := make([]any, 0, len(containers))
+	seen := map[string]bool{}
+	for _, c := range containers {
+		if c.Name == "" || c.Image == "" {
+			return nil, fmt.Errorf("container name and image are required")
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("duplicated container %q", c.Name)
+		}
+		seen[c.Name] = true
+		m, err := toMap(c)
+		if err != nil {
+			return nil, fmt.Errorf("convert container %s failed. %w", c.Name, err)
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// toMap converts v into its generic JSON form, as patches are sent as JSON.
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,142 @@
+// Package kustomize renders kustomizations into Kusion resources, so that modules can deliver
+// pre-existing kustomize stacks through the Kusion pipeline.
+//
+// Rendering runs the kustomize binary, or kubectl kustomize if kustomize is not installed, which
+// also resolves remote bases.
+package kustomize
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Options customizes how kustomizations are built.
+type Options struct {
+	// Binary is the path of the kustomize binary, looked up in PATH if empty
+	Binary string
+	// Args are additional arguments of kustomize build, e.g. --enable-helm
+	Args []string
+	// Namespace is set on namespaced resources without a namespace, ignored if empty
+	Namespace string
+}
+
+// Build builds the kustomization in dir, which may reference remote bases, and returns the
+// rendered resources.
+func Build(ctx context.Context, dir string, opts *Options) ([]v1.Resource, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	name, args, err := command(opts)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, dir)
+	args = append(args, opts.Args...)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("kustomize build %s failed: %w: %s", dir, err, stderr.String())
+	}
+
+	resources, err := module.DecodeManifests(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("decode kustomize output of %s failed. %w", dir, err)
+	}
+	if opts.Namespace != "" {
+		for i := range resources {
+			setDefaultNamespace(&resources[i], opts.Namespace)
+		}
+	}
+	return resources, nil
+}
+
+// BuildFS builds the kustomization in dir of fsys, such as an embed.FS bundled with the module.
+func BuildFS(ctx context.Context, fsys fs.FS, dir string, opts *Options) ([]v1.Resource, error) {
+	tmp, err := os.MkdirTemp("", "kustomize-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(sub, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(tmp, path)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := fs.ReadFile(sub, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copy kustomization %s failed. %w", dir, err)
+	}
+	return Build(ctx, tmp, opts)
+}
+
+func command(opts *Options) (string, []string, error) {
+	if opts.Binary != "" {
+		return opts.Binary, []string{"build"}, nil
+	}
+	if path, err := exec.LookPath("kustomize"); err == nil {
+		return path, []string{"build"}, nil
+	}
+	if path, err := exec.LookPath("kubectl"); err == nil {
+		return path, []string{"kustomize"}, nil
+	}
+	return "", nil, fmt.Errorf("neither kustomize nor kubectl is found in PATH")
+}
+
+// setDefaultNamespace sets the namespace of res if it has none. Cluster-scoped kinds are not
+// distinguishable without API discovery, so only resources with a metadata map are updated and
+// well-known cluster-scoped kinds are skipped.
+func setDefaultNamespace(res *v1.Resource, namespace string) {
+	kind, _ := res.Attributes["kind"].(string)
+	if clusterScoped[kind] {
+		return
+	}
+	metadata, ok := res.Attributes["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if ns, _ := metadata["namespace"].(string); ns != "" {
+		return
+	}
+	metadata["namespace"] = namespace
+	name, _ := metadata["name"].(string)
+	apiVersion, _ := res.Attributes["apiVersion"].(string)
+	res.ID = module.KubernetesResourceIDFromGVK(schema.FromAPIVersionAndKind(apiVersion, kind), namespace, name)
+}
+
+var clusterScoped = map[string]bool{
+	"Namespace":                      true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"PersistentVolume":               true,
+	"StorageClass":                   true,
+	"PriorityClass":                  true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+}
@@ -0,0 +1,195 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// ObjectMetaMetadataKey is the gRPC request header carrying the YAML encoded labels and annotations of the
+// project and stack, which the proto request has no fields for.
+const ObjectMetaMetadataKey = "kusion-module-object-meta-bin"
+
+// ObjectMeta is the labels and annotations of a project or stack in the Kusion project config.
+type ObjectMeta struct {
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+func (m ObjectMeta) isEmpty() bool {
+	return len(m.Labels) == 0 && len(m.Annotations) == 0
+}
+
+type objectMetas struct {
+	Project ObjectMeta `yaml:"project,omitempty"`
+	Stack   ObjectMeta `yaml:"stack,omitempty"`
+}
+
+// ContextWithObjectMeta returns a copy of ctx sending the labels and annotations of the project and stack
+// to module plugins, used by hosts of modules.
+func ContextWithObjectMeta(ctx context.Context, project, stack ObjectMeta) (context.Context, error) {
+	out, err := yaml.Marshal(objectMetas{Project: project, Stack: stack})
+	if err != nil {
+		return nil, fmt.Errorf("marshal object meta failed. %w", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, ObjectMetaMetadataKey, string(out)), nil
+}
+
+// decodeObjectMeta sets the labels and annotations of the project and stack sent in the request metadata.
+func decodeObjectMeta(ctx context.Context, req *GeneratorRequest) error {
+	data := incomingMetadata(ctx, ObjectMetaMetadataKey)
+	if data == "" {
+		return nil
+	}
+	metas := objectMetas{}
+	if err := yaml.Unmarshal([]byte(data), &metas); err != nil {
+		return fmt.Errorf("unmarshal object meta failed. %w", err)
+	}
+	req.ProjectMeta, req.StackMeta = metas.Project, metas.Stack
+	return nil
+}
+
+// Labels returns the labels of the project merged with the labels of the stack, which take precedence.
+func (r *GeneratorRequest) Labels() map[string]string {
+	return mergeStringMaps(r.ProjectMeta.Labels, r.StackMeta.Labels)
+}
+
+// Annotations returns the annotations of the project merged with the annotations of the stack, which
+// take precedence.
+func (r *GeneratorRequest) Annotations() map[string]string {
+	return mergeStringMaps(r.ProjectMeta.Annotations, r.StackMeta.Annotations)
+}
+
+// PropagateObjectMeta adds the Labels and Annotations of req to every resource of resp, for ownership and
+// chargeback tagging. Kubernetes resources get them as labels and annotations, including the pod
+// templates of workloads, and Terraform resources with a tags map get the labels as tags. Values set
+// on the resources are kept.
+func (r *GeneratorResponse) PropagateObjectMeta(req *GeneratorRequest) {
+	labels, annotations := req.Labels(), req.Annotations()
+	if len(labels) == 0 && len(annotations) == 0 {
+		return
+	}
+	for i := range r.Resources {
+		addLabels(&r.Resources[i], labels, annotations)
+	}
+}
+
+// Well-known labels of the resources generated by modules, see CommonLabels.
+const (
+	// LabelManagedBy is the recommended Kubernetes label of the tool managing the resource
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	// LabelPartOf is the recommended Kubernetes label of the higher-level application, the project
+	LabelPartOf = "app.kubernetes.io/part-of"
+	// LabelName is the recommended Kubernetes label of the application name
+	LabelName = "app.kubernetes.io/name"
+	// LabelProject is the label of the Kusion project
+	LabelProject = "kusion.io/project"
+	// LabelStack is the label of the Kusion stack
+	LabelStack = "kusion.io/stack"
+	// LabelApp is the label of the Kusion application
+	LabelApp = "kusion.io/app"
+	// LabelModule is the label of the module generating the resource
+	LabelModule = "kusion.io/module"
+
+	// ManagedByKusion is the value of LabelManagedBy
+	ManagedByKusion = "kusion"
+)
+
+// CommonLabels returns the well-known labels of the resources generated for req. Labels whose values
+// are not valid Kubernetes label values, e.g. names longer than 63 characters, are left out.
+func CommonLabels(req *GeneratorRequest) map[string]string {
+	labels := map[string]string{LabelManagedBy: ManagedByKusion}
+	for k, v := range map[string]string{
+		LabelPartOf:  req.Project,
+		LabelName:    req.App,
+		LabelProject: req.Project,
+		LabelStack:   req.Stack,
+		LabelApp:     req.App,
+		LabelModule:  req.Module,
+	} {
+		if v != "" && len(validation.IsValidLabelValue(v)) == 0 {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// ApplyCommonLabels adds the CommonLabels of req to res, like PropagateObjectMeta, so that resources
+// across modules carry consistent metadata. Labels set on the resource are kept.
+func ApplyCommonLabels(res *v1.Resource, req *GeneratorRequest) {
+	addLabels(res, CommonLabels(req), nil)
+}
+
+// addLabels adds the labels and annotations missing on res. Kubernetes resources get them as labels
+// and annotations, with the labels on the pod templates of workloads too, and Terraform resources with
+// a tags map get the labels as tags.
+func addLabels(res *v1.Resource, labels, annotations map[string]string) {
+	switch res.Type {
+	case v1.Kubernetes:
+		addToMap(res.Attributes, labels, "metadata", "labels")
+		addToMap(res.Attributes, annotations, "metadata", "annotations")
+		if hasPath(res.Attributes, "spec", "template", "metadata") || hasPath(res.Attributes, "spec", "template", "spec") {
+			addToMap(res.Attributes, labels, "spec", "template", "metadata", "labels")
+		}
+	case v1.Terraform:
+		if hasPath(res.Attributes, "tags") {
+			addToMap(res.Attributes, labels, "tags")
+		}
+	}
+}
+
+func mergeStringMaps(maps ...map[string]string) map[string]string {
+	out := map[string]string{}
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// addToMap adds the entries missing in the map at the path of attrs, creating the maps on the path.
+func addToMap(attrs map[string]interface{}, entries map[string]string, path ...string) {
+	if attrs == nil || len(entries) == 0 {
+		return
+	}
+	cur := attrs
+	for _, key := range path {
+		next, ok := asStringMap(cur[key])
+		if !ok {
+			next = map[string]interface{}{}
+		}
+		cur[key] = next
+		cur = next
+	}
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := cur[k]; !ok {
+			cur[k] = entries[k]
+		}
+	}
+}
+
+func hasPath(attrs map[string]interface{}, path ...string) bool {
+	var cur interface{} = attrs
+	for _, key := range path {
+		m, ok := asStringMap(cur)
+		if !ok {
+			return false
+		}
+		if cur, ok = m[key]; !ok {
+			return false
+		}
+	}
+	_, ok := asStringMap(cur)
+	return ok
+}
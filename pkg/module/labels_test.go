@@ -0,0 +1,101 @@
+package module
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// TestLabelValues pins the well-known labels, which are read by selectors and tag policies outside of
+// modules and must never change.
+func TestLabelValues(t *testing.T) {
+	for got, want := range map[string]string{
+		LabelManagedBy:  "app.kubernetes.io/managed-by",
+		LabelPartOf:     "app.kubernetes.io/part-of",
+		LabelName:       "app.kubernetes.io/name",
+		LabelProject:    "kusion.io/project",
+		LabelStack:      "kusion.io/stack",
+		LabelApp:        "kusion.io/app",
+		LabelModule:     "kusion.io/module",
+		ManagedByKusion: "kusion",
+	} {
+		if got != want {
+			t.Errorf("label = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestCommonLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *GeneratorRequest
+		want map[string]string
+	}{
+		{
+			name: "all labels",
+			req:  &GeneratorRequest{Project: "shop", Stack: "prod", App: "web", Module: "service"},
+			want: map[string]string{
+				"app.kubernetes.io/managed-by": "kusion",
+				"app.kubernetes.io/part-of":    "shop",
+				"app.kubernetes.io/name":       "web",
+				"kusion.io/project":            "shop",
+				"kusion.io/stack":              "prod",
+				"kusion.io/app":                "web",
+				"kusion.io/module":             "service",
+			},
+		},
+		{
+			name: "empty and invalid values are left out",
+			req:  &GeneratorRequest{Project: "shop", Stack: "prod/eu", App: strings.Repeat("a", 64)},
+			want: map[string]string{
+				"app.kubernetes.io/managed-by": "kusion",
+				"app.kubernetes.io/part-of":    "shop",
+				"kusion.io/project":            "shop",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommonLabels(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CommonLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCommonLabels(t *testing.T) {
+	req := &GeneratorRequest{Project: "shop", Stack: "prod", App: "web"}
+	deployment := v1.Resource{
+		ID:   "apps/v1:Deployment:web:web",
+		Type: v1.Kubernetes,
+		Attributes: map[string]any{
+			"metadata": map[string]any{"labels": map[string]any{LabelName: "custom"}},
+			"spec":     map[string]any{"template": map[string]any{"spec": map[string]any{}}},
+		},
+	}
+	ApplyCommonLabels(&deployment, req)
+	labels := deployment.Attributes["metadata"].(map[string]any)["labels"].(map[string]any)
+	if labels[LabelName] != "custom" {
+		t.Errorf("label %s = %v, want the value set on the resource", LabelName, labels[LabelName])
+	}
+	if labels[LabelStack] != "prod" || labels[LabelManagedBy] != ManagedByKusion {
+		t.Errorf("labels = %v, want the common labels", labels)
+	}
+	if !hasPath(deployment.Attributes, "spec", "template", "metadata", "labels") {
+		t.Errorf("pod template has no labels")
+	}
+
+	bucket := v1.Resource{ID: "hashicorp:aws:aws_s3_bucket:web", Type: v1.Terraform, Attributes: map[string]any{"tags": map[string]any{}}}
+	ApplyCommonLabels(&bucket, req)
+	if tags := bucket.Attributes["tags"].(map[string]any); tags[LabelProject] != "shop" {
+		t.Errorf("tags = %v, want the common labels", tags)
+	}
+
+	untagged := v1.Resource{ID: "hashicorp:random:random_password:web", Type: v1.Terraform, Attributes: map[string]any{}}
+	ApplyCommonLabels(&untagged, req)
+	if _, ok := untagged.Attributes["tags"]; ok {
+		t.Errorf("tags are added to a Terraform resource without tags")
+	}
+}
@@ -0,0 +1,77 @@
+package module
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+)
+
+// WithLazyWorkload defers decoding the workload of requests until the module calls LoadWorkload, so that
+// modules never reading the workload, such as most infrastructure modules, skip decoding and logging it.
+// The Workload and Workloads fields of requests are empty until LoadWorkload is called.
+func WithLazyWorkload() ServeOption {
+	return func(o *serveOptions) {
+		o.lazyWorkload = true
+	}
+}
+
+// lazyWorkload is the encoded workload of a request, decoded once. The decoded workloads are kept here
+// rather than in the request, so that the copies of the request sharing it, such as the requests of
+// Invoke, see the workloads decoded by any of them.
+type lazyWorkload struct {
+	data      []byte
+	once      sync.Once
+	done      atomic.Bool
+	workloads []*workload.Workload
+	err       error
+}
+
+func (l *lazyWorkload) decoded() bool {
+	return l.done.Load()
+}
+
+func (l *lazyWorkload) decode() ([]*workload.Workload, error) {
+	l.once.Do(func() {
+		l.workloads, l.err = decodeWorkloads(l.data)
+		if l.err == nil {
+			l.done.Store(true)
+		}
+	})
+	return l.workloads, l.err
+}
+
+// LoadWorkload returns the workload of the request, decoding it on the first call if the module is
+// served with WithLazyWorkload, which also populates the Workload and Workloads fields. Without lazy
+// decoding the Workload field is returned. The workload is nil for standalone infra modules.
+func (r *GeneratorRequest) LoadWorkload() (*workload.Workload, error) {
+	if err := r.decodeLazyWorkload(); err != nil {
+		return nil, err
+	}
+	return r.Workload, nil
+}
+
+// LoadWorkloads is like LoadWorkload but returns all workloads of the request.
+func (r *GeneratorRequest) LoadWorkloads() ([]*workload.Workload, error) {
+	if err := r.decodeLazyWorkload(); err != nil {
+		return nil, err
+	}
+	return r.Workloads, nil
+}
+
+func (r *GeneratorRequest) decodeLazyWorkload() error {
+	l := r.lazyWorkload
+	if l == nil {
+		return nil
+	}
+	workloads, err := l.decode()
+	if err != nil {
+		return err
+	}
+	r.Workloads = workloads
+	r.Workload = nil
+	if len(workloads) > 0 {
+		r.Workload = workloads[0]
+	}
+	return nil
+}
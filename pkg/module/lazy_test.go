@@ -0,0 +1,64 @@
+package module
+
+import (
+	"testing"
+
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+const lazyTestWorkload = "_type: Service\nreplicas: 2\n"
+
+func TestLazyWorkload(t *testing.T) {
+	req, err := newGeneratorRequest(&proto.GeneratorRequest{Project: "p", Stack: "dev", App: "app", Workload: []byte(lazyTestWorkload)}, true)
+	if err != nil {
+		t.Fatalf("newGeneratorRequest() error = %v", err)
+	}
+	if req.Workload != nil || req.Workloads != nil {
+		t.Fatalf("workload is decoded before LoadWorkload")
+	}
+
+	// the copy made by Invoke decodes the workload shared with the request
+	sub := *req
+	w, err := sub.LoadWorkload()
+	if err != nil {
+		t.Fatalf("LoadWorkload() error = %v", err)
+	}
+	if w == nil || w.Header.Type != "Service" {
+		t.Fatalf("LoadWorkload() = %+v, want the service", w)
+	}
+
+	got, err := req.LoadWorkload()
+	if err != nil {
+		t.Fatalf("LoadWorkload() of the original request error = %v", err)
+	}
+	if got != w {
+		t.Errorf("LoadWorkload() of the original request = %p, want the workload decoded by the copy %p", got, w)
+	}
+	if workloads, _ := req.LoadWorkloads(); len(workloads) != 1 || workloads[0] != w {
+		t.Errorf("LoadWorkloads() = %v, want the decoded workload", workloads)
+	}
+
+	copied := *req
+	copied.Workload, copied.Workloads = nil, nil
+	protoReq, err := copied.ToProto()
+	if err != nil {
+		t.Fatalf("ToProto() error = %v", err)
+	}
+	if len(protoReq.Workload) == 0 {
+		t.Errorf("ToProto() of a request whose workload is decoded by a copy has no workload")
+	}
+}
+
+func TestLazyWorkloadError(t *testing.T) {
+	req, err := newGeneratorRequest(&proto.GeneratorRequest{Workload: []byte("- _type: Service\n- null\n")}, true)
+	if err != nil {
+		t.Fatalf("newGeneratorRequest() error = %v", err)
+	}
+	sub := *req
+	if _, err = sub.LoadWorkload(); err == nil {
+		t.Fatalf("LoadWorkload() of an invalid workload succeeded")
+	}
+	if _, err = req.LoadWorkloads(); err == nil {
+		t.Errorf("LoadWorkloads() of the original request succeeded after the copy failed")
+	}
+}
@@ -0,0 +1,175 @@
+package module
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Limits limit the Generate calls of a module, protecting modules calling throttled cloud APIs when the
+// engine fans out many stacks in parallel. Calls over the limits wait in a queue until the deadline of
+// the request.
+type Limits struct {
+	// MaxConcurrent is the max number of Generate calls running at once, unlimited if not positive
+	MaxConcurrent int
+	// MaxQueued is the max number of calls waiting for the limits, unlimited if not positive. Calls
+	// beyond it fail with ErrCodeUnavailable
+	MaxQueued int
+	// Rate is the number of Generate calls per second allowed per caller, unlimited if not positive
+	Rate float64
+	// Burst is the number of calls a caller can make at once without waiting, 1 if not positive
+	Burst int
+	// Caller returns the caller of a request the rate is counted for, the peer address of the gRPC
+	// call if nil
+	Caller func(ctx context.Context, req *GeneratorRequest) string
+}
+
+// WithLimits limits the concurrency and the rate of the Generate calls of the module.
+func WithLimits(limits Limits) ServeOption {
+	return func(o *serveOptions) {
+		o.limits = limits
+	}
+}
+
+// limiter enforces the Limits of a wrapper.
+type limiter struct {
+	limits Limits
+	slots  chan struct{}
+
+	mu      sync.Mutex
+	queued  int
+	buckets map[string]*tokenBucket
+}
+
+// maxIdleBuckets is the number of buckets of callers above which the full buckets are dropped.
+const maxIdleBuckets = 1024
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{limits: limits, buckets: map[string]*tokenBucket{}}
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	if l.limits.Burst <= 0 {
+		l.limits.Burst = 1
+	}
+	return l
+}
+
+// acquire waits until req is allowed by the limits of the wrapper and returns the function releasing
+// its concurrency slot. The wait is reported as the queue phase of GenerateTimings.
+func (f *FrameworkModuleWrapper) acquire(ctx context.Context, req *GeneratorRequest) (release func(), err error) {
+	if f.Limits.MaxConcurrent <= 0 && f.Limits.Rate <= 0 {
+		return func() {}, nil
+	}
+	f.limiterOnce.Do(func() { f.limiter = newLimiter(f.Limits) })
+	l := f.limiter
+
+	l.mu.Lock()
+	if l.limits.MaxQueued > 0 && l.queued >= l.limits.MaxQueued {
+		l.mu.Unlock()
+		observeRejected(f.Name)
+		return nil, NewError(ErrCodeUnavailable, "too many Generate calls of %s are queued", f.Name).
+			WithHint("retry later, or lower the parallelism of the engine")
+	}
+	l.queued++
+	l.mu.Unlock()
+	observeQueued(f.Name, 1)
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+		observeQueued(f.Name, -1)
+	}()
+
+	if l.limits.Rate > 0 {
+		if err = l.wait(ctx, l.caller(ctx, req)); err != nil {
+			return nil, f.contextError(ctx)
+		}
+	}
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, f.contextError(ctx)
+	}
+	observeInFlight(f.Name, 1)
+	return func() {
+		<-l.slots
+		observeInFlight(f.Name, -1)
+	}, nil
+}
+
+func (l *limiter) caller(ctx context.Context, req *GeneratorRequest) string {
+	if l.limits.Caller != nil {
+		return l.limits.Caller(ctx, req)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// wait waits until the bucket of caller has a token, or ctx is done.
+func (l *limiter) wait(ctx context.Context, caller string) error {
+	now := time.Now()
+	l.mu.Lock()
+	b, ok := l.buckets[caller]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			for key, idle := range l.buckets {
+				if idle.full(now, l.limits) {
+					delete(l.buckets, key)
+				}
+			}
+		}
+		b = &tokenBucket{tokens: float64(l.limits.Burst), last: now}
+		l.buckets[caller] = b
+	}
+	delay := b.take(now, l.limits)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// tokenBucket is the rate limit state of a caller. Tokens go negative for the calls waiting for them.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time, limits Limits) {
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*limits.Rate, float64(limits.Burst))
+	b.last = now
+}
+
+// take takes a token and returns the delay until it is available.
+func (b *tokenBucket) take(now time.Time, limits Limits) time.Duration {
+	b.refill(now, limits)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / limits.Rate * float64(time.Second))
+}
+
+func (b *tokenBucket) full(now time.Time, limits Limits) bool {
+	b.refill(now, limits)
+	return b.tokens >= float64(limits.Burst)
+}
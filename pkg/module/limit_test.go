@@ -0,0 +1,181 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// blockingModule blocks Generate ignoring its context until unblock is closed.
+type blockingModule struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func newBlockingModule() *blockingModule {
+	return &blockingModule{started: make(chan struct{}, 16), unblock: make(chan struct{})}
+}
+
+func (m *blockingModule) Generate(_ context.Context, _ *GeneratorRequest) (*GeneratorResponse, error) {
+	m.started <- struct{}{}
+	<-m.unblock
+	return &GeneratorResponse{}, nil
+}
+
+func (m *blockingModule) Reload(context.Context, EnvironmentDefaults) error {
+	return nil
+}
+
+func errorCode(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+func generateWithTimeout(w *FrameworkModuleWrapper, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := w.Generate(ctx, &proto.GeneratorRequest{Project: "p", Stack: "dev", App: "app"})
+	return err
+}
+
+func TestLimitsReleaseSlotWhenModuleReturns(t *testing.T) {
+	m := newBlockingModule()
+	w := &FrameworkModuleWrapper{Module: m, Name: "limited", Logger: hclog.NewNullLogger(), Limits: Limits{MaxConcurrent: 1}}
+
+	if err := generateWithTimeout(w, 20*time.Millisecond); errorCode(err) != ErrCodeTimeout {
+		t.Fatalf("Generate() of a stuck module error = %v, want %s", err, ErrCodeTimeout)
+	}
+	// the stuck call still holds the slot, so the next call times out in the queue
+	if err := generateWithTimeout(w, 20*time.Millisecond); errorCode(err) != ErrCodeTimeout {
+		t.Fatalf("Generate() while the stuck call runs error = %v, want %s", err, ErrCodeTimeout)
+	}
+	if len(m.started) != 1 {
+		t.Fatalf("module started %d times, want 1 while the slot is held", len(m.started))
+	}
+
+	close(m.unblock)
+	if err := generateWithTimeout(w, time.Second); err != nil {
+		t.Fatalf("Generate() after the stuck call returned error = %v", err)
+	}
+}
+
+func TestReloadWaitsForStuckGenerate(t *testing.T) {
+	m := newBlockingModule()
+	w := &FrameworkModuleWrapper{Module: m, Name: "reload", Logger: hclog.NewNullLogger(), Timeout: 50 * time.Millisecond}
+
+	if err := generateWithTimeout(w, time.Second); errorCode(err) != ErrCodeTimeout {
+		t.Fatalf("Generate() of a stuck module error = %v, want %s", err, ErrCodeTimeout)
+	}
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- w.reloadDefaults(context.Background(), EnvironmentDefaults{"dev": {"replicas": 1}})
+	}()
+	select {
+	case err := <-reloaded:
+		t.Fatalf("reload finished while the module was still generating, error = %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(m.unblock)
+	if err := <-reloaded; err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if w.EnvironmentDefaults["dev"] == nil {
+		t.Errorf("defaults = %v, want the reloaded defaults", w.EnvironmentDefaults)
+	}
+}
+
+func TestReloadDoesNotBlockOnModuleThatNeverReturns(t *testing.T) {
+	m := newBlockingModule()
+	t.Cleanup(func() { close(m.unblock) })
+	w := &FrameworkModuleWrapper{Module: m, Name: "reload", Logger: hclog.NewNullLogger(), Timeout: 10 * time.Millisecond}
+
+	if err := generateWithTimeout(w, time.Second); errorCode(err) != ErrCodeTimeout {
+		t.Fatalf("Generate() of a stuck module error = %v, want %s", err, ErrCodeTimeout)
+	}
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- w.reloadDefaults(context.Background(), EnvironmentDefaults{"dev": {"replicas": 1}})
+	}()
+	select {
+	case err := <-reloaded:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("reload error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reload blocked on the call that never returns")
+	}
+	if w.EnvironmentDefaults != nil {
+		t.Errorf("defaults = %v, want them kept after the failed reload", w.EnvironmentDefaults)
+	}
+	// later calls are not held back by the failed reload
+	if err := generateWithTimeout(w, time.Second); errorCode(err) != ErrCodeTimeout {
+		t.Fatalf("Generate() after the failed reload error = %v, want %s", err, ErrCodeTimeout)
+	}
+}
+
+func TestLimitsMaxQueued(t *testing.T) {
+	m := newBlockingModule()
+	w := &FrameworkModuleWrapper{Module: m, Name: "queued", Logger: hclog.NewNullLogger(), Limits: Limits{MaxConcurrent: 1, MaxQueued: 1}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = generateWithTimeout(w, time.Second)
+	}()
+	<-m.started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = generateWithTimeout(w, time.Second)
+	}()
+	waitQueued(t, w, 1)
+
+	if err := generateWithTimeout(w, time.Second); errorCode(err) != ErrCodeUnavailable {
+		t.Errorf("Generate() beyond MaxQueued error = %v, want %s", err, ErrCodeUnavailable)
+	}
+	close(m.unblock)
+	wg.Wait()
+}
+
+func waitQueued(t *testing.T, w *FrameworkModuleWrapper, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if l := w.limiter; l != nil {
+			l.mu.Lock()
+			queued := l.queued
+			l.mu.Unlock()
+			if queued == n {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d calls are not queued", n)
+}
+
+func TestTokenBucket(t *testing.T) {
+	limits := Limits{Rate: 10, Burst: 2}
+	now := time.Now()
+	b := &tokenBucket{tokens: float64(limits.Burst), last: now}
+	for i := 0; i < limits.Burst; i++ {
+		if delay := b.take(now, limits); delay != 0 {
+			t.Fatalf("take() %d within the burst delay = %v, want 0", i, delay)
+		}
+	}
+	if delay := b.take(now, limits); delay != 100*time.Millisecond {
+		t.Errorf("take() beyond the burst delay = %v, want 100ms", delay)
+	}
+	if !b.full(now.Add(time.Second), limits) {
+		t.Errorf("bucket is not full after refilling for a second")
+	}
+}
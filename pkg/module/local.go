@@ -0,0 +1,117 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+)
+
+// LocalFlag is the command line flag running a module plugin in the local mode, in which Serve reads a
+// GeneratorRequest in YAML or JSON from stdin, writes the GeneratorResponse as JSON to stdout and exits,
+// without the plugin handshake. It is meant for manual testing and scripts, e.g.
+//
+//	./my-module --local < request.yaml
+const LocalFlag = "--local"
+
+// localMode reports whether args contain LocalFlag.
+func localMode(args []string) bool {
+	for _, arg := range args {
+		if arg == LocalFlag || arg == "-local" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveLocal runs a single request read from in through the wrapper and writes the response to out.
+func serveLocal(ctx context.Context, w *FrameworkModuleWrapper, in io.Reader, out io.Writer) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("read request failed. %w", err)
+	}
+	req := &GeneratorRequest{}
+	if err = yaml.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("unmarshal request failed. %w", err)
+	}
+	resp, err := generateLocal(ctx, w, req)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(resp); err != nil {
+		return fmt.Errorf("write response failed. %w", err)
+	}
+	return nil
+}
+
+// generateLocal runs req through the wrapper outside of a gRPC call, with the metadata the engine would
+// send, and decodes the response, shared by the local mode and the HTTP facade.
+func generateLocal(ctx context.Context, w *FrameworkModuleWrapper, req *GeneratorRequest) (*GeneratorResponse, error) {
+	protoReq, err := req.ToProto()
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := requestMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = metadata.NewIncomingContext(metadata.NewOutgoingContext(ctx, metadata.MD{}), md)
+	stream := &localStream{header: metadata.MD{}}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+	protoResp, err := w.generate(ctx, protoReq)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse(protoResp, stream.header)
+}
+
+// requestMetadata returns the gRPC metadata the engine sends with req, shared by all hosts of modules.
+func requestMetadata(ctx context.Context, req *GeneratorRequest) (metadata.MD, error) {
+	ctx = metadata.NewOutgoingContext(ctx, metadata.MD{})
+	var err error
+	ctx = ContextWithModuleName(ctx, req.Module)
+	ctx = ContextWithResourceEncoding(ctx, EncodingJSON)
+	ctx = ContextWithSDKVersion(ctx, SDKVersion)
+	if req.Operation != "" {
+		ctx = ContextWithOperation(ctx, req.Operation)
+	}
+	if len(req.PriorState) > 0 {
+		if ctx, err = ContextWithPriorState(ctx, req.PriorState); err != nil {
+			return nil, err
+		}
+	}
+	if !req.ProjectMeta.isEmpty() || !req.StackMeta.isEmpty() {
+		if ctx, err = ContextWithObjectMeta(ctx, req.ProjectMeta, req.StackMeta); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.workspace) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, WorkspaceMetadataKey, string(req.workspace))
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md, nil
+}
+
+// localStream collects the response headers set by the wrapper in the local mode.
+type localStream struct {
+	header metadata.MD
+}
+
+func (s *localStream) Method() string { return "/" + FrameworkServiceName + "/Local" }
+
+func (s *localStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *localStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *localStream) SetTrailer(metadata.MD) error { return nil }
@@ -0,0 +1,47 @@
+package module
+
+import (
+	"context"
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+type loggerKey struct{}
+
+// defaultLogger writes JSON logs to stderr, which go-plugin forwards to the log stream of the Kusion engine.
+var defaultLogger = hclog.New(&hclog.LoggerOptions{
+	Level:      hclog.Info,
+	Output:     os.Stderr,
+	JSONFormat: true,
+})
+
+// ContextWithLogger returns a copy of ctx carrying logger.
+func ContextWithLogger(ctx context.Context, logger hclog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFrom returns the logger carried by ctx. Inside Generate, the logger is tagged with the
+// project, stack, app and module name of the request, and its messages are visible to the Kusion engine.
+// A default logger is returned if ctx carries none.
+func LoggerFrom(ctx context.Context) hclog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(hclog.Logger); ok && logger != nil {
+		return logger
+	}
+	return defaultLogger
+}
+
+// requestLogger returns the logger tagged with the request information.
+func (f *FrameworkModuleWrapper) requestLogger(req *GeneratorRequest) hclog.Logger {
+	logger := f.Logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	args := []interface{}{"project", req.Project, "stack", req.Stack, "app", req.App}
+	if name := req.Module; name != "" {
+		args = append(args, "module", name)
+	} else if f.Name != "" {
+		args = append(args, "module", f.Name)
+	}
+	return logger.With(args...)
+}
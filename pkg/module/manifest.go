@@ -0,0 +1,101 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// DecodeManifests decodes multi-document YAML or JSON Kubernetes manifests into Kusion resources.
+// Empty documents are skipped, and List objects are expanded into their items.
+func DecodeManifests(data []byte) ([]v1.Resource, error) {
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var resources []v1.Resource
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read manifest document %d failed. %w", i, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err = k8syaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, fmt.Errorf("unmarshal manifest document %d failed. %w", i, err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objs := []*unstructured.Unstructured{obj}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("convert manifest document %d to list failed. %w", i, err)
+			}
+			objs = objs[:0]
+			for j := range list.Items {
+				objs = append(objs, &list.Items[j])
+			}
+		}
+		for _, o := range objs {
+			res, err := WrapUnstructured(o)
+			if err != nil {
+				return nil, fmt.Errorf("manifest document %d: %w", i, err)
+			}
+			resources = append(resources, *res)
+		}
+	}
+	return resources, nil
+}
+
+// LoadManifests decodes the .yaml, .yml and .json manifests under dir of fsys, e.g. operators or CRD
+// bundles embedded in the module binary, into Kusion resources with their IDs and extensions populated.
+// Files are loaded in lexical order of their paths, so ordering prefixes such as 00-crds.yaml are kept.
+func LoadManifests(fsys fs.FS, dir string) ([]v1.Resource, error) {
+	var resources []v1.Resource
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch path.Ext(p) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		res, err := DecodeManifests(data)
+		if err != nil {
+			return fmt.Errorf("decode manifest %s failed. %w", p, err)
+		}
+		resources = append(resources, res...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load manifests from %s failed. %w", dir, err)
+	}
+	return resources, nil
+}
+
+// WrapUnstructured wraps an unstructured Kubernetes object into a Kusion resource with its ID
+// and extensions populated.
+func WrapUnstructured(obj *unstructured.Unstructured) (*v1.Resource, error) {
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+		return nil, fmt.Errorf("apiVersion, kind and metadata.name of kubernetes object must not be empty")
+	}
+	id := KubernetesResourceIDFromGVK(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())
+	return WrapK8sResourceToKusionResource(id, obj)
+}
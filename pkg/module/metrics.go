@@ -0,0 +1,148 @@
+//go:build !wasip1
+
+package module
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+const metricsNamespace = "kusion_module"
+
+var (
+	// MetricsRegistry is the registry of the metrics exposed on the metrics listener. Modules may
+	// register their own collectors.
+	MetricsRegistry = prometheus.NewRegistry()
+
+	generateTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_total",
+		Help:      "Number of Generate calls by module and result.",
+	}, []string{"module", "result"})
+	generateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_duration_seconds",
+		Help:      "Duration of Generate calls by module.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"module"})
+	generatedResources = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "generated_resources_total",
+		Help:      "Number of resources generated by module.",
+	}, []string{"module"})
+	generatePhaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_phase_duration_seconds",
+		Help:      "Duration of the phases of Generate calls by module and phase.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+	}, []string{"module", "phase"})
+	generateInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_in_flight",
+		Help:      "Number of Generate calls running by module.",
+	}, []string{"module"})
+	generateQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_queued",
+		Help:      "Number of Generate calls waiting for the limits by module.",
+	}, []string{"module"})
+	generateRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_rejected_total",
+		Help:      "Number of Generate calls rejected by the limits by module.",
+	}, []string{"module"})
+	chaosInjected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "generate_chaos_injected_total",
+		Help:      "Number of faults injected into Generate calls by module and fault.",
+	}, []string{"module", "fault"})
+)
+
+func init() {
+	MetricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		generateTotal,
+		generateDuration,
+		generatedResources,
+		generatePhaseDuration,
+		generateInFlight,
+		generateQueued,
+		generateRejected,
+		chaosInjected,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of hits of the shared module cache.",
+		}, func() float64 { return float64(defaultCache.Stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of misses of the shared module cache.",
+		}, func() float64 { return float64(defaultCache.Stats().Misses) }),
+	)
+}
+
+// observeGenerate records the metrics of a Generate call started at start.
+func observeGenerate(module string, start time.Time, resources int, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	generateTotal.WithLabelValues(module, result).Inc()
+	generateDuration.WithLabelValues(module).Observe(time.Since(start).Seconds())
+	generatedResources.WithLabelValues(module).Add(float64(resources))
+}
+
+// observePhases records the phase durations of a Generate call.
+func observePhases(module string, t *GenerateTimings) {
+	for phase, d := range map[string]time.Duration{
+		"decode":   t.Decode,
+		"validate": t.Validate,
+		"queue":    t.Queue,
+		"generate": t.Generate,
+		"marshal":  t.Marshal,
+	} {
+		generatePhaseDuration.WithLabelValues(module, phase).Observe(d.Seconds())
+	}
+}
+
+// observeQueued adds delta to the Generate calls waiting for the limits of the module.
+func observeQueued(module string, delta float64) {
+	generateQueued.WithLabelValues(module).Add(delta)
+}
+
+// observeInFlight adds delta to the Generate calls running within the limits of the module.
+func observeInFlight(module string, delta float64) {
+	generateInFlight.WithLabelValues(module).Add(delta)
+}
+
+// observeRejected counts a Generate call rejected by the limits of the module.
+func observeRejected(module string) {
+	generateRejected.WithLabelValues(module).Inc()
+}
+
+// observeChaos counts a fault injected into a Generate call of the module.
+func observeChaos(module, fault string) {
+	chaosInjected.WithLabelValues(module, fault).Inc()
+}
+
+// startMetricsServer serves the metrics on addr in the background and returns the function stopping it.
+func startMetricsServer(addr string) func(context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(MetricsRegistry, promhttp.HandlerOpts{Registry: MetricsRegistry}))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("serve metrics on %s failed: %v", addr, err)
+		}
+	}()
+	return server.Shutdown
+}
@@ -0,0 +1,27 @@
+//go:build wasip1
+
+package module
+
+import (
+	"context"
+	"time"
+)
+
+// Modules built for wasip1 serve a single request and export no metrics, so that they do not link the
+// Prometheus client.
+
+func observeGenerate(string, time.Time, int, error) {}
+
+func observePhases(string, *GenerateTimings) {}
+
+func observeQueued(string, float64) {}
+
+func observeInFlight(string, float64) {}
+
+func observeRejected(string) {}
+
+func observeChaos(string, string) {}
+
+func startMetricsServer(string) func(context.Context) error {
+	return func(context.Context) error { return nil }
+}
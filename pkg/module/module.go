@@ -1,13 +1,20 @@
 package module
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"gopkg.in/yaml.v2"
 	"kusionstack.io/kusion/pkg/apis/core/v1"
 	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
-	"kusionstack.io/kusion/pkg/log"
 	"kusionstack.io/kusion/pkg/modules/proto"
 )
 
@@ -15,40 +22,227 @@ type FrameworkModule interface {
 	Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error)
 }
 
+// Validator is an optional interface of FrameworkModule. If implemented, Validate is called
+// before Generate to fail fast on invalid configs.
+type Validator interface {
+	Validate(ctx context.Context, req *GeneratorRequest) error
+}
+
+// Cleaner is an optional interface of FrameworkModule. If implemented, Cleanup is called
+// when the plugin shuts down to release resources held by the module.
+type Cleaner interface {
+	Cleanup(ctx context.Context) error
+}
+
 // FrameworkModuleWrapper is a module that implements the proto Module interface.
 // It wraps a dev-centric FrameworkModule into a proto Module
 type FrameworkModuleWrapper struct {
 	// Module is the actual FrameworkModule implemented by platform engineers
 	Module FrameworkModule
+	// Name is the module name used to tag logs, optional
+	Name string
+	// Logger is the root logger of module logs, a JSON logger writing to stderr is used if nil
+	Logger hclog.Logger
+	// ModuleInfo is the metadata of the module returned by the Info RPC
+	ModuleInfo ModuleInfo
+	// MaxMessageSize is the max size of responses of Generate, DefaultMaxMessageSize is used if zero
+	MaxMessageSize int
+	// Resolver resolves the modules called by Invoke, the default resolver is used if nil
+	Resolver Resolver
+	// StrictDecoding rejects requests with duplicated keys and module configs with unknown fields
+	StrictDecoding bool
+	// Mutators post-process every generated resource in order
+	Mutators []ResourceMutator
+	// Checks check the response after the mutators
+	Checks []ResponseCheck
+	// EnvironmentDefaults are merged beneath the platform module config by the environment of requests
+	EnvironmentDefaults EnvironmentDefaults
+	// OnTimings is called with the phase durations of every Generate call, used by benchmarks
+	OnTimings func(GenerateTimings)
+	// LazyWorkload defers decoding the workload of requests until LoadWorkload is called
+	LazyWorkload bool
+	// RedactKeys match the config keys redacted from request logs in addition to DefaultSensitiveKeys
+	RedactKeys []*regexp.Regexp
+	// RequestLogLevel is the level of the request logs, hclog.Info is used if zero
+	RequestLogLevel hclog.Level
+	// Sandbox restricts the process before the code of the module runs if not nil
+	Sandbox *Sandbox
+	// Limits limit the concurrency and the rate of Generate calls of the module
+	Limits Limits
+	// Chaos are the faults injected around Generate of the module if not nil, for tests only
+	Chaos *Chaos
+	// Timeout limits the duration of Generate of the module if positive
+	Timeout time.Duration
+	// CrashDir is the directory crash reports of panics are written to, CrashDirEnv is read if empty
+	CrashDir string
+	// SecretKey is the key the salts of SecretGenerator are derived from, overridden by the platform config
+	SecretKey []byte
+	// SecretStores are the secret stores of the module by provider, see WithSecretStore
+	SecretStores map[string]SecretStore
+
+	ready       atomic.Bool
+	reloadMu    sync.RWMutex
+	running     callTracker
+	limiterOnce sync.Once
+	limiter     *limiter
+	sandboxOnce sync.Once
+	sandboxErr  error
+	sandboxTemp string
+	chaosOnce   sync.Once
+	chaosSource *chaosSource
 }
 
 func (f *FrameworkModuleWrapper) Generate(ctx context.Context, req *proto.GeneratorRequest) (*proto.GeneratorResponse, error) {
-	request, err := NewGeneratorRequest(req)
+	resp, err := f.generate(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	fwResources, err := f.Module.Generate(ctx, request)
+	if err = f.checkMessageSize(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// generate runs the module and marshals the generated resources, shared by Generate and GenerateStream.
+func (f *FrameworkModuleWrapper) generate(ctx context.Context, req *proto.GeneratorRequest) (resp *proto.GeneratorResponse, err error) {
+	start := time.Now()
+	timings := &GenerateTimings{}
+	lap := stopwatch()
+	defer func() {
+		resources := 0
+		if resp != nil {
+			resources = len(resp.Resources)
+		}
+		observeGenerate(f.Name, start, resources, err)
+		f.observeTimings(timings)
+	}()
+	defer f.recoverPanic(&err)
+	f.reloadMu.RLock()
+	defer f.reloadMu.RUnlock()
+	f.record(ctx, req)
+	if err = f.Ready(ctx); err != nil {
+		return nil, err
+	}
+	if err = adaptRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	if f.StrictDecoding {
+		if err = checkRequestKeys(req); err != nil {
+			return nil, asModuleError(err, ErrCodeInvalidConfig, "invalid generator request")
+		}
+	}
+	request, err := newGeneratorRequest(req, f.LazyWorkload)
+	if err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid generator request")
+	}
+	logRequest(request, f.RequestLogLevel, f.sensitiveKeys())
+	request.strict = f.StrictDecoding
+	request.secretKey = f.SecretKey
+	request.secretStores = f.SecretStores
+	f.applyEnvironmentDefaults(request)
+	request.Operation = Operation(incomingMetadata(ctx, OperationMetadataKey))
+	request.Module = incomingMetadata(ctx, ModuleNameMetadataKey)
+	if request.PriorState, err = decodePriorState(ctx); err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid prior state")
+	}
+	if err = decodeObjectMeta(ctx, request); err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid object meta")
+	}
+	if f.ModuleInfo.RequiresWorkspace {
+		request.workspaceAccess = true
+		request.workspace = []byte(incomingMetadata(ctx, WorkspaceMetadataKey))
+	}
+	logger := f.requestLogger(request)
+	ctx = ContextWithLogger(ctx, logger)
+	if f.Resolver != nil {
+		ctx = ContextWithResolver(ctx, f.Resolver)
+	}
+	ctx, span := startGenerateSpan(ctx, request, f.Name)
+	defer func() { endSpan(span, err) }()
+	timings.Decode = lap()
+	if v, ok := f.Module.(Validator); ok {
+		if err = v.Validate(ctx, request); err != nil {
+			return nil, asModuleError(err, ErrCodeInvalidConfig, "validate generator request failed")
+		}
+	}
+	timings.Validate = lap()
+	release, err := f.acquire(ctx, request)
+	timings.Queue = lap()
+	if err != nil {
+		return nil, err
+	}
+	fwResources, err := f.callGenerate(ctx, request, release)
+	timings.Generate = lap()
 	if err != nil {
 		return nil, err
 	}
+	defer func() { timings.Marshal = lap() }()
+	if fwResources != nil {
+		if err = sendPatcher(ctx, fwResources.Patcher); err != nil {
+			return nil, fmt.Errorf("invalid patcher: %w", err)
+		}
+		if err = sendOutputs(ctx, fwResources.Outputs); err != nil {
+			return nil, err
+		}
+	}
 	if fwResources == nil || fwResources.Resources == nil {
-		log.Info("no resources generated by request:%v", request)
+		logger.Info("no resources generated by request")
 		return EmptyResponse(), nil
 	}
+	if err = f.mutateResources(fwResources); err != nil {
+		return nil, err
+	}
+	moduleName := f.Name
+	if moduleName == "" {
+		moduleName = request.Module
+	}
+	if err = validateResources(moduleName, fwResources.Resources); err != nil {
+		return nil, err
+	}
+	fwResources.AddRefDependencies()
+	if err = f.checkResponse(ctx, request, fwResources); err != nil {
+		return nil, err
+	}
+	f.estimateCost(ctx, request, fwResources)
+	if err = sendCosts(ctx, fwResources.Costs); err != nil {
+		return nil, err
+	}
+	if !fwResources.PreserveOrder {
+		fwResources.Sort()
+	}
 
-	var resources [][]byte
-	for _, res := range fwResources.Resources {
-		out, err := yaml.Marshal(res)
-		if err != nil {
-			return nil, fmt.Errorf("marshal resource failed: %w. res:%v", err, res)
-		}
-		resources = append(resources, out)
+	serializer, err := negotiateSerializer(ctx)
+	if err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid resource encoding")
+	}
+	resources, err := marshalResources(serializer, fwResources.Resources)
+	if err != nil {
+		return nil, err
 	}
 	return &proto.GeneratorResponse{
 		Resources: resources,
 	}, nil
 }
 
+// Cleanup calls the Cleanup hooks of the wrapped module and the resolver if they implement Cleaner.
+func (f *FrameworkModuleWrapper) Cleanup(ctx context.Context) error {
+	var errs []error
+	if c, ok := f.Module.(Cleaner); ok {
+		if err := f.enterSandbox(); err != nil {
+			errs = append(errs, err)
+		} else {
+			errs = append(errs, c.Cleanup(ctx))
+		}
+	}
+	if c, ok := f.Resolver.(Cleaner); ok {
+		errs = append(errs, c.Cleanup(ctx))
+	}
+	if f.sandboxTemp != "" {
+		errs = append(errs, os.RemoveAll(f.sandboxTemp))
+	}
+	return errors.Join(errs...)
+}
+
 type GeneratorRequest struct {
 	// Project represents the project name
 	Project string `json:"project,omitempty" yaml:"project"`
@@ -56,51 +250,100 @@ type GeneratorRequest struct {
 	Stack string `json:"stack,omitempty" yaml:"stack"`
 	// App represents the application name, which is typically the same as the namespace of Kubernetes resources
 	App string `json:"app,omitempty" yaml:"app"`
-	// Workload represents the workload configuration
+	// Workload represents the workload configuration, which is nil for standalone infra modules
+	// and the first workload if the request carries several
 	Workload *workload.Workload `json:"workload,omitempty" yaml:"workload"`
+	// Workloads represents all workloads of the request
+	Workloads []*workload.Workload `json:"workloads,omitempty" yaml:"workloads,omitempty"`
 	// DevModuleConfig is the developer's inputs of this module
 	DevModuleConfig v1.Accessory `json:"dev_module_config,omitempty" yaml:"devModuleConfig"`
 	// PlatformModuleConfig is the platform engineer's inputs of this module
 	PlatformModuleConfig v1.GenericConfig `json:"platform_module_config,omitempty" yaml:"platformModuleConfig"`
-	// RuntimeConfig is the runtime configurations defined in the workspace config
+	// RuntimeConfig is the runtime configurations defined in the workspace config, use Runtime to read it safely
 	RuntimeConfig *v1.RuntimeConfigs `json:"runtime_config,omitempty" yaml:"runtimeConfig"`
+	// Operation is the engine operation the request is made for
+	Operation Operation `json:"operation,omitempty" yaml:"operation,omitempty"`
+	// Module is the name of the requested module, used to route requests in multi-module binaries
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+	// PriorState is the resources of the stack in the state backend, which is empty for the first apply
+	// or if the engine does not send it
+	PriorState []v1.Resource `json:"priorState,omitempty" yaml:"priorState,omitempty"`
+	// ProjectMeta is the labels and annotations of the project, use Labels and Annotations to read the
+	// labels and annotations merged with the stack's
+	ProjectMeta ObjectMeta `json:"projectMeta,omitempty" yaml:"projectMeta,omitempty"`
+	// StackMeta is the labels and annotations of the stack
+	StackMeta ObjectMeta `json:"stackMeta,omitempty" yaml:"stackMeta,omitempty"`
+
+	// workspace is the YAML encoded workspace configuration, read by Workspace if workspaceAccess is enabled
+	workspace       []byte
+	workspaceAccess bool
+	// strict makes DecodeDevConfig and DecodePlatformConfig reject unknown fields
+	strict bool
+	// secretKey is the key of the module the salts of SecretGenerator are derived from
+	secretKey []byte
+	// secretStores are the secret stores of the module by provider
+	secretStores map[string]SecretStore
+	// lazyWorkload is the encoded workload decoded by LoadWorkload if the module is served with WithLazyWorkload
+	lazyWorkload *lazyWorkload
 }
 
 type GeneratorResponse struct {
 	// Resources represents the generated resources
 	Resources []v1.Resource `json:"resources,omitempty" yaml:"resources"`
+	// Patcher contains the patches applied to the workload
+	Patcher *Patcher `json:"patcher,omitempty" yaml:"patcher,omitempty"`
+	// Costs are the cost estimates of the resources, see CostEstimator
+	Costs []CostEstimate `json:"costs,omitempty" yaml:"costs,omitempty"`
+	// Outputs are the named outputs of the module, see OutputValue
+	Outputs map[string]OutputValue `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	// PreserveOrder makes the wrapper keep the order of Resources instead of sorting them by ID
+	PreserveOrder bool `json:"-" yaml:"-"`
 }
 
 func NewGeneratorRequest(req *proto.GeneratorRequest) (*GeneratorRequest, error) {
+	result, err := newGeneratorRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+	logRequest(result, hclog.Info, DefaultSensitiveKeys)
+	return result, nil
+}
 
-	log.Infof("module proto request received:%s", req.String())
-
-	// validate workload
-	if req.Workload == nil {
-		return nil, fmt.Errorf("workload in the request is nil")
+// newGeneratorRequest converts the proto request, leaving the workload encoded for LoadWorkload if lazy.
+func newGeneratorRequest(req *proto.GeneratorRequest, lazy bool) (*GeneratorRequest, error) {
+	if req == nil {
+		return nil, errors.New("generator request is nil")
 	}
-	w := &workload.Workload{}
-	if err := yaml.Unmarshal(req.Workload, w); err != nil {
-		return nil, fmt.Errorf("unmarshal workload failed. %w", err)
+	var workloads []*workload.Workload
+	if !lazy {
+		var err error
+		if workloads, err = decodeWorkloads(req.Workload); err != nil {
+			return nil, err
+		}
+	}
+	var w *workload.Workload
+	if len(workloads) > 0 {
+		w = workloads[0]
 	}
 
 	var dc v1.Accessory
 	if req.DevModuleConfig != nil {
-		if err := yaml.Unmarshal(req.DevModuleConfig, &dc); err != nil {
+		if err := unmarshalYAML(req.DevModuleConfig, &dc); err != nil {
 			return nil, fmt.Errorf("unmarshal dev module config failed. %w", err)
 		}
 	}
 
 	var pc v1.GenericConfig
 	if req.PlatformModuleConfig != nil {
-		if err := yaml.Unmarshal(req.PlatformModuleConfig, &pc); err != nil {
+		if err := unmarshalYAML(req.PlatformModuleConfig, &pc); err != nil {
 			return nil, fmt.Errorf("unmarshal platform module config failed. %w", err)
 		}
 	}
 
 	var rc *v1.RuntimeConfigs
 	if req.RuntimeConfig != nil {
-		if err := yaml.Unmarshal(req.RuntimeConfig, rc); err != nil {
+		rc = &v1.RuntimeConfigs{}
+		if err := unmarshalYAML(req.RuntimeConfig, rc); err != nil {
 			return nil, fmt.Errorf("unmarshal runtime config failed. %w", err)
 		}
 	}
@@ -110,18 +353,73 @@ func NewGeneratorRequest(req *proto.GeneratorRequest) (*GeneratorRequest, error)
 		Stack:                req.Stack,
 		App:                  req.App,
 		Workload:             w,
+		Workloads:            workloads,
 		DevModuleConfig:      dc,
 		PlatformModuleConfig: pc,
 		RuntimeConfig:        rc,
 	}
-	out, err := yaml.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("marshal new generator request failed. %w", err)
+	if lazy {
+		result.lazyWorkload = &lazyWorkload{data: req.Workload}
 	}
-	log.Infof("new generator request:%s", string(out))
 	return result, nil
 }
 
+// decodeWorkloads decodes the workload in the proto request, which is either empty, a single
+// workload or a list of workloads.
+func decodeWorkloads(data []byte) ([]*workload.Workload, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var raw interface{}
+	if err := unmarshalYAML(data, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal workload failed. %w", err)
+	}
+	switch raw.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		var workloads []*workload.Workload
+		if err := unmarshalYAML(data, &workloads); err != nil {
+			return nil, fmt.Errorf("unmarshal workloads failed. %w", err)
+		}
+		for i, w := range workloads {
+			if w == nil {
+				return nil, fmt.Errorf("workload %d is empty", i)
+			}
+		}
+		return workloads, nil
+	}
+	w := &workload.Workload{}
+	if err := unmarshalYAML(data, w); err != nil {
+		return nil, fmt.Errorf("unmarshal workload failed. %w", err)
+	}
+	return []*workload.Workload{w}, nil
+}
+
+// unmarshalYAML is yaml.Unmarshal returning the panics of the unmarshalers of v, e.g. on documents of
+// unexpected types, as errors, as the decoded documents come from outside the module.
+func unmarshalYAML(data []byte, v interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed document: %v", r)
+		}
+	}()
+	return yaml.Unmarshal(data, v)
+}
+
+// RequireWorkload returns the workload of the request, or an error if the request has none.
+// Modules accessing the workload should call it instead of dereferencing Workload directly.
+func (r *GeneratorRequest) RequireWorkload() (*workload.Workload, error) {
+	if _, err := r.LoadWorkload(); err != nil {
+		return nil, asModuleError(err, ErrCodeInvalidRequest, "invalid workload")
+	}
+	if r.Workload == nil {
+		return nil, NewError(ErrCodeInvalidRequest, "workload in the request is nil").
+			WithHint("this module must be used with a workload")
+	}
+	return r.Workload, nil
+}
+
 // EmptyResponse represents a legal but empty response. Interfaces should return an EmptyResponse instead of nil when the response is empty
 func EmptyResponse() *proto.GeneratorResponse {
 	return &proto.GeneratorResponse{}
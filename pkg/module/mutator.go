@@ -0,0 +1,54 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// ResourceMutator post-processes a generated resource, such as adding org-wide labels, cost-center
+// tags or enforcing naming policies. Returning an error fails the generation.
+type ResourceMutator func(res *v1.Resource) error
+
+// WithResponseMutator adds mutators run in order on every resource generated by the module, so that
+// platform teams can enforce policies without modifying each module.
+func WithResponseMutator(mutators ...ResourceMutator) ServeOption {
+	return func(o *serveOptions) {
+		o.mutators = append(o.mutators, mutators...)
+	}
+}
+
+// mutateResources runs the mutators of the wrapper on every resource of resp.
+func (f *FrameworkModuleWrapper) mutateResources(resp *GeneratorResponse) error {
+	for i := range resp.Resources {
+		for _, mutate := range f.Mutators {
+			if err := mutate(&resp.Resources[i]); err != nil {
+				return fmt.Errorf("mutate resource %s failed. %w", resp.Resources[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResponseCheck checks all resources generated for a request, such as evaluating policies across
+// resources. Returning an error fails the generation.
+type ResponseCheck func(ctx context.Context, req *GeneratorRequest, resp *GeneratorResponse) error
+
+// WithResponseCheck adds checks run in order on the response of the module after the mutators,
+// so that platform teams can enforce guardrails without modifying each module.
+func WithResponseCheck(checks ...ResponseCheck) ServeOption {
+	return func(o *serveOptions) {
+		o.checks = append(o.checks, checks...)
+	}
+}
+
+// checkResponse runs the checks of the wrapper on resp.
+func (f *FrameworkModuleWrapper) checkResponse(ctx context.Context, req *GeneratorRequest, resp *GeneratorResponse) error {
+	for _, check := range f.Checks {
+		if err := check(ctx, req, resp); err != nil {
+			return asModuleError(err, ErrCodeInvalidConfig, "check generated resources failed")
+		}
+	}
+	return nil
+}
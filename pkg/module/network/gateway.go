@@ -0,0 +1,72 @@
+package network
+
+import (
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/kube"
+)
+
+// GatewayAPIVersion is the Gateway API version of the generated HTTPRoutes.
+const GatewayAPIVersion = "gateway.networking.k8s.io/v1"
+
+func httpRoute(spec *Spec, cfg *Config) ([]v1.Resource, error) {
+	if cfg.Gateway == nil || cfg.Gateway.Name == "" {
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "gateway of network spec %s is not set", spec.Name).
+			WithHint("set the gateway name of the network platform config")
+	}
+	parent := map[string]interface{}{"name": cfg.Gateway.Name}
+	if cfg.Gateway.Namespace != "" {
+		parent["namespace"] = cfg.Gateway.Namespace
+	}
+	if cfg.Gateway.SectionName != "" {
+		parent["sectionName"] = cfg.Gateway.SectionName
+	}
+
+	// HTTPRoutes match their hostnames with every rule, so one route is generated per host
+	var resources []v1.Resource
+	for i, rule := range spec.Rules {
+		rules := make([]interface{}, 0, len(rule.Paths))
+		for _, p := range rule.Paths {
+			rules = append(rules, map[string]interface{}{
+				"matches": []interface{}{map[string]interface{}{
+					"path": map[string]interface{}{"type": gatewayPathType(p), "value": pathOf(p)},
+				}},
+				"backendRefs": []interface{}{map[string]interface{}{
+					"name": p.ServiceName,
+					"port": int64(p.ServicePort),
+				}},
+			})
+		}
+		routeSpec := map[string]interface{}{
+			"parentRefs": []interface{}{parent},
+			"rules":      rules,
+		}
+		if rule.Host != "" {
+			routeSpec["hostnames"] = []interface{}{rule.Host}
+		}
+		name := spec.Name
+		if len(spec.Rules) > 1 {
+			name = fmt.Sprintf("%s-%d", spec.Name, i)
+		}
+		res, err := kube.New(GatewayAPIVersion, "HTTPRoute", spec.Namespace, name).
+			WithLabels(spec.Labels).
+			WithAnnotations(cfg.Annotations).
+			WithSpec(routeSpec).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *res)
+	}
+	return resources, nil
+}
+
+func gatewayPathType(p Path) string {
+	if pathTypeOf(p) == PathTypeExact {
+		return "Exact"
+	}
+	return "PathPrefix"
+}
@@ -0,0 +1,53 @@
+package network
+
+import (
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/kube"
+)
+
+func ingress(spec *Spec, cfg *Config) ([]v1.Resource, error) {
+	rules := make([]interface{}, 0, len(spec.Rules))
+	var hosts []interface{}
+	for _, rule := range spec.Rules {
+		paths := make([]interface{}, 0, len(rule.Paths))
+		for _, p := range rule.Paths {
+			paths = append(paths, map[string]interface{}{
+				"path":     pathOf(p),
+				"pathType": pathTypeOf(p),
+				"backend": map[string]interface{}{
+					"service": map[string]interface{}{
+						"name": p.ServiceName,
+						"port": map[string]interface{}{"number": int64(p.ServicePort)},
+					},
+				},
+			})
+		}
+		r := map[string]interface{}{"http": map[string]interface{}{"paths": paths}}
+		if rule.Host != "" {
+			r["host"] = rule.Host
+			hosts = append(hosts, rule.Host)
+		}
+		rules = append(rules, r)
+	}
+	ingressSpec := map[string]interface{}{"rules": rules}
+	if cfg.IngressClassName != "" {
+		ingressSpec["ingressClassName"] = cfg.IngressClassName
+	}
+	if spec.TLSSecretName != "" {
+		tls := map[string]interface{}{"secretName": spec.TLSSecretName}
+		if len(hosts) > 0 {
+			tls["hosts"] = hosts
+		}
+		ingressSpec["tls"] = []interface{}{tls}
+	}
+	res, err := kube.Ingress(spec.Namespace, spec.Name).
+		WithLabels(spec.Labels).
+		WithAnnotations(cfg.Annotations).
+		WithSpec(ingressSpec).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	return []v1.Resource{*res}, nil
+}
@@ -0,0 +1,105 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/providers/aws"
+)
+
+// Terraform resource types of the ClassAWSALB resources.
+const (
+	ResourceTypeLBTargetGroup  = "aws_lb_target_group"
+	ResourceTypeLBListenerRule = "aws_lb_listener_rule"
+)
+
+// defaultRulePriority is the priority of the first listener rule if not set in the platform config.
+const defaultRulePriority = 100
+
+func albRules(req *module.GeneratorRequest, spec *Spec, cfg *Config) ([]v1.Resource, error) {
+	lb := cfg.LoadBalancer
+	if lb == nil || lb.ListenerARN == "" || lb.VPCID == "" {
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "load balancer of network spec %s is not set", spec.Name).
+			WithHint("set the listenerArn and vpcId of the network platform config")
+	}
+	provider, err := aws.Provider(req)
+	if err != nil {
+		return nil, err
+	}
+	tags := aws.Tags(req, spec.Labels)
+	priority := lb.Priority
+	if priority <= 0 {
+		priority = defaultRulePriority
+	}
+
+	var resources []v1.Resource
+	targetGroups := map[string]string{}
+	for _, rule := range spec.Rules {
+		for _, p := range rule.Paths {
+			backend := fmt.Sprintf("%s-%s-%d", spec.Name, p.ServiceName, p.ServicePort)
+			tgID, ok := targetGroups[backend]
+			if !ok {
+				tg, err := provider.WrapResource(ResourceTypeLBTargetGroup, backend, map[string]interface{}{
+					"name":        targetGroupName(backend),
+					"port":        int64(p.ServicePort),
+					"protocol":    "HTTP",
+					"target_type": "ip",
+					"vpc_id":      lb.VPCID,
+					"tags":        tags,
+				})
+				if err != nil {
+					return nil, err
+				}
+				tgID = tg.ID
+				targetGroups[backend] = tgID
+				resources = append(resources, tg)
+			}
+
+			conditions := []interface{}{map[string]interface{}{
+				"path_pattern": map[string]interface{}{"values": []interface{}{albPathPattern(p)}},
+			}}
+			if rule.Host != "" {
+				conditions = append(conditions, map[string]interface{}{
+					"host_header": map[string]interface{}{"values": []interface{}{rule.Host}},
+				})
+			}
+			listenerRule, err := provider.WrapResource(ResourceTypeLBListenerRule, fmt.Sprintf("%s-%d", spec.Name, priority), map[string]interface{}{
+				"listener_arn": lb.ListenerARN,
+				"priority":     int64(priority),
+				"action": []interface{}{map[string]interface{}{
+					"type":             "forward",
+					"target_group_arn": module.RefAttr(tgID, "arn"),
+				}},
+				"condition": conditions,
+				"tags":      tags,
+			})
+			if err != nil {
+				return nil, err
+			}
+			module.DependsOn(&listenerRule, tgID)
+			resources = append(resources, listenerRule)
+			priority++
+		}
+	}
+	return resources, nil
+}
+
+// albPathPattern converts the path into an ALB path pattern, which matches prefixes with wildcards.
+func albPathPattern(p Path) string {
+	path := pathOf(p)
+	if pathTypeOf(p) == PathTypeExact {
+		return path
+	}
+	return strings.TrimSuffix(path, "/") + "/*"
+}
+
+// targetGroupName shortens name to the 32 characters allowed for target group names.
+func targetGroupName(name string) string {
+	if len(name) <= 32 {
+		return name
+	}
+	return strings.TrimRight(name[:32], "-")
+}
@@ -0,0 +1,153 @@
+// Package network generates the routing resources of a workload from one declarative Spec. The
+// platform config selects the Class, so routing modules support Ingress, Gateway API and cloud load
+// balancer setups with a single code path.
+package network
+
+import (
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Class is the kind of routing resources generated for a Spec.
+type Class string
+
+const (
+	// ClassIngress generates a networking.k8s.io/v1 Ingress
+	ClassIngress Class = "ingress"
+	// ClassGateway generates a Gateway API HTTPRoute attached to an existing Gateway
+	ClassGateway Class = "gateway"
+	// ClassAWSALB generates Terraform target groups and listener rules on an existing AWS ALB listener
+	ClassAWSALB Class = "aws-alb"
+)
+
+// Path types of the routes.
+const (
+	PathTypePrefix = "Prefix"
+	PathTypeExact  = "Exact"
+)
+
+// Config is the platform config of the routing resources, which modules embed into their platform
+// config, e.g. under a network key.
+type Config struct {
+	// Class selects the generated resources, which is ingress by default
+	Class Class `yaml:"class,omitempty" json:"class,omitempty"`
+	// IngressClassName is the ingress class of the ClassIngress resources
+	IngressClassName string `yaml:"ingressClassName,omitempty" json:"ingressClassName,omitempty"`
+	// Gateway is the parent Gateway of the ClassGateway resources
+	Gateway *GatewayRef `yaml:"gateway,omitempty" json:"gateway,omitempty"`
+	// LoadBalancer is the listener of the ClassAWSALB resources
+	LoadBalancer *LoadBalancerRef `yaml:"loadBalancer,omitempty" json:"loadBalancer,omitempty"`
+	// Annotations are added to the Kubernetes routing resources, e.g. controller-specific settings
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// GatewayRef refers to a Gateway API Gateway.
+type GatewayRef struct {
+	Name        string `yaml:"name" json:"name"`
+	Namespace   string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	SectionName string `yaml:"sectionName,omitempty" json:"sectionName,omitempty"`
+}
+
+// LoadBalancerRef refers to the listener of an AWS application load balancer.
+type LoadBalancerRef struct {
+	// ListenerARN is the ARN of the listener the rules are added to
+	ListenerARN string `yaml:"listenerArn" json:"listenerArn"`
+	// VPCID is the VPC of the target groups
+	VPCID string `yaml:"vpcId" json:"vpcId"`
+	// Priority is the priority of the first rule, following rules get increasing priorities
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+// Spec is the declarative routing of a workload.
+type Spec struct {
+	// Name is the name of the generated resources
+	Name string
+	// Namespace is the namespace of the Kubernetes routing resources and the backend services
+	Namespace string
+	// Rules are the routed hosts
+	Rules []Rule
+	// TLSSecretName is the Secret holding the certificate of the hosts, TLS is disabled if empty
+	TLSSecretName string
+	// Labels are added to the Kubernetes routing resources
+	Labels map[string]string
+}
+
+// Rule routes the paths of a host, or of all hosts if Host is empty.
+type Rule struct {
+	Host  string
+	Paths []Path
+}
+
+// Path routes a path to a backend service port.
+type Path struct {
+	// Path is the matched path, which is / by default
+	Path string
+	// PathType is PathTypePrefix by default
+	PathType string
+	// ServiceName is the name of the backend Kubernetes service
+	ServiceName string
+	// ServicePort is the port of the backend service
+	ServicePort int32
+}
+
+// Validate checks the spec is routable.
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name of the network spec must not be empty")
+	}
+	if len(s.Rules) == 0 {
+		return fmt.Errorf("network spec %s has no rules", s.Name)
+	}
+	for i, rule := range s.Rules {
+		if len(rule.Paths) == 0 {
+			return fmt.Errorf("rule %d of network spec %s has no paths", i, s.Name)
+		}
+		for j, p := range rule.Paths {
+			if p.ServiceName == "" || p.ServicePort <= 0 {
+				return fmt.Errorf("path %d of rule %d of network spec %s must have a service name and port", j, i, s.Name)
+			}
+			if p.PathType != "" && p.PathType != PathTypePrefix && p.PathType != PathTypeExact {
+				return fmt.Errorf("unsupported path type %q in network spec %s", p.PathType, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Generate returns the routing resources of spec for the class selected by cfg.
+func Generate(req *module.GeneratorRequest, spec *Spec, cfg *Config) ([]v1.Resource, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Class {
+	case "", ClassIngress:
+		return ingress(spec, cfg)
+	case ClassGateway:
+		return httpRoute(spec, cfg)
+	case ClassAWSALB:
+		return albRules(req, spec, cfg)
+	default:
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "unsupported network class %q", cfg.Class).
+			WithHint(fmt.Sprintf("set the network class of the platform config to one of %s, %s and %s", ClassIngress, ClassGateway, ClassAWSALB))
+	}
+}
+
+func pathOf(p Path) string {
+	if p.Path == "" {
+		return "/"
+	}
+	return p.Path
+}
+
+func pathTypeOf(p Path) string {
+	if p.PathType == "" {
+		return PathTypePrefix
+	}
+	return p.PathType
+}
@@ -0,0 +1,130 @@
+// Package observability builds the monitoring resources of workloads: Prometheus Operator
+// ServiceMonitors and PodMonitors scraping the workload ports, and Grafana dashboard ConfigMaps.
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+
+	"kusionstack.io/kusion-module-framework/pkg/module/kube"
+	"kusionstack.io/kusion-module-framework/pkg/module/workloadutil"
+)
+
+// MonitoringAPIVersion is the API version of the Prometheus Operator resources.
+const MonitoringAPIVersion = "monitoring.coreos.com/v1"
+
+// Default scrape settings of the endpoints.
+const (
+	DefaultMetricsPath    = "/metrics"
+	DefaultScrapeInterval = "30s"
+)
+
+// GrafanaDashboardLabel is the label with which the Grafana sidecar discovers dashboard ConfigMaps.
+const GrafanaDashboardLabel = "grafana_dashboard"
+
+// Endpoint is a scraped port of the monitored pods.
+type Endpoint struct {
+	// TargetPort is the container port scraped
+	TargetPort int32
+	// Path is the metrics path, which is DefaultMetricsPath if empty
+	Path string
+	// Interval is the scrape interval, which is DefaultScrapeInterval if empty
+	Interval string
+	// Scheme is http if empty
+	Scheme string
+}
+
+// Monitor is the spec of a ServiceMonitor or PodMonitor.
+type Monitor struct {
+	// Name and Namespace of the monitor, which also monitors the objects in Namespace only
+	Name      string
+	Namespace string
+	// Selector selects the monitored Services or Pods by labels
+	Selector map[string]string
+	// Endpoints are the scraped ports
+	Endpoints []Endpoint
+	// Labels are added to the monitor, e.g. the release label selected by the Prometheus instance
+	Labels map[string]string
+}
+
+// EndpointsFromWorkload returns an endpoint of every port exposed by w, scraped at path every interval.
+// Empty path and interval use the defaults.
+func EndpointsFromWorkload(w *workload.Workload, path, interval string) []Endpoint {
+	ports := workloadutil.Ports(w)
+	endpoints := make([]Endpoint, 0, len(ports))
+	for _, p := range ports {
+		port := p.TargetPort
+		if port == 0 {
+			port = p.Port
+		}
+		endpoints = append(endpoints, Endpoint{TargetPort: int32(port), Path: path, Interval: interval})
+	}
+	return endpoints
+}
+
+// ServiceMonitor returns the ServiceMonitor scraping the Services selected by m.
+func ServiceMonitor(m *Monitor) (*v1.Resource, error) {
+	return monitor("ServiceMonitor", "endpoints", m)
+}
+
+// PodMonitor returns the PodMonitor scraping the Pods selected by m, used for workloads without Services.
+func PodMonitor(m *Monitor) (*v1.Resource, error) {
+	return monitor("PodMonitor", "podMetricsEndpoints", m)
+}
+
+func monitor(kind, endpointsField string, m *Monitor) (*v1.Resource, error) {
+	if len(m.Selector) == 0 {
+		return nil, fmt.Errorf("selector of %s %s must not be empty", kind, m.Name)
+	}
+	if len(m.Endpoints) == 0 {
+		return nil, fmt.Errorf("%s %s has no endpoints", kind, m.Name)
+	}
+	endpoints := make([]interface{}, 0, len(m.Endpoints))
+	for _, e := range m.Endpoints {
+		if e.TargetPort <= 0 {
+			return nil, fmt.Errorf("target port of %s %s must be positive", kind, m.Name)
+		}
+		endpoint := map[string]interface{}{
+			"targetPort": int64(e.TargetPort),
+			"path":       orDefault(e.Path, DefaultMetricsPath),
+			"interval":   orDefault(e.Interval, DefaultScrapeInterval),
+		}
+		if e.Scheme != "" {
+			endpoint["scheme"] = e.Scheme
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	selector := make(map[string]interface{}, len(m.Selector))
+	for k, v := range m.Selector {
+		selector[k] = v
+	}
+	return kube.New(MonitoringAPIVersion, kind, m.Namespace, m.Name).
+		WithLabels(m.Labels).
+		WithSpec(map[string]interface{}{
+			"selector":          map[string]interface{}{"matchLabels": selector},
+			"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{m.Namespace}},
+			endpointsField:      endpoints,
+		}).
+		Build()
+}
+
+// Dashboard returns the ConfigMap of the Grafana dashboard, labeled to be discovered by the Grafana sidecar.
+func Dashboard(namespace, name string, dashboard []byte) (*v1.Resource, error) {
+	if !json.Valid(dashboard) {
+		return nil, fmt.Errorf("grafana dashboard %s is not valid JSON", name)
+	}
+	return kube.ConfigMap(namespace, name).
+		WithLabels(map[string]string{GrafanaDashboardLabel: "1"}).
+		WithData(map[string]string{name + ".json": string(dashboard)}).
+		Build()
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,61 @@
+package module
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// OperationMetadataKey is the gRPC request header carrying the engine operation. The proto
+// GeneratorRequest has no field for it, so the engine sends it as metadata.
+const OperationMetadataKey = "kusion-module-operation"
+
+// Operation is the engine operation a Generate call is made for.
+type Operation string
+
+const (
+	// OperationUnspecified means the engine did not send the operation
+	OperationUnspecified Operation = ""
+	// OperationPreview means the resources are generated to preview the changes
+	OperationPreview Operation = "preview"
+	// OperationApply means the resources are generated to be applied
+	OperationApply Operation = "apply"
+	// OperationDestroy means the resources are generated to be destroyed
+	OperationDestroy Operation = "destroy"
+)
+
+// DryRun reports whether the request is made for a preview, in which case modules must skip side
+// effects such as registering DNS records through external APIs. Only an explicit preview operation is a
+// dry run, so requests of engines not sending the operation behave as they did before it was sent.
+func (r *GeneratorRequest) DryRun() bool {
+	return r.Operation == OperationPreview
+}
+
+// ContextWithOperation returns a copy of ctx sending op to module plugins, used by hosts of modules.
+func ContextWithOperation(ctx context.Context, op Operation) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, OperationMetadataKey, string(op))
+}
+
+// incomingMetadata returns the first value of key in the incoming gRPC metadata of ctx.
+func incomingMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// setHeader sets key in the gRPC response header of the call of ctx. Outside of a gRPC call, e.g. when
+// the wrapper is called in unit tests, there is no header and nothing is set. Other errors, such as a
+// header that was already sent, are returned, as the engine would miss the value.
+func setHeader(ctx context.Context, key, value string) error {
+	if grpc.ServerTransportStreamFromContext(ctx) == nil {
+		return nil
+	}
+	return grpc.SetHeader(ctx, metadata.Pairs(key, value))
+}
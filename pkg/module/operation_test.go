@@ -0,0 +1,44 @@
+package module
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDryRun(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		want bool
+	}{
+		{op: OperationUnspecified, want: false},
+		{op: OperationPreview, want: true},
+		{op: OperationDestroy, want: false},
+		{op: Operation("unknown"), want: false},
+		{op: OperationApply, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			if got := (&GeneratorRequest{Operation: tt.op}).DryRun(); got != tt.want {
+				t.Errorf("DryRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	// outside of a gRPC call there is no header to set
+	if err := setHeader(context.Background(), OutputsMetadataKey, "{}"); err != nil {
+		t.Errorf("setHeader() outside of a call error = %v", err)
+	}
+	stream := &localStream{header: metadata.MD{}}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	if err := setHeader(ctx, OutputsMetadataKey, "{}"); err != nil {
+		t.Fatalf("setHeader() error = %v", err)
+	}
+	if got := stream.header.Get(OutputsMetadataKey); len(got) != 1 || got[0] != "{}" {
+		t.Errorf("header %s = %v, want [{}]", OutputsMetadataKey, got)
+	}
+}
@@ -0,0 +1,74 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// OutputsMetadataKey is the gRPC response header carrying the outputs of the module, which the proto
+// response has no field for.
+const OutputsMetadataKey = "kusion-module-outputs-bin"
+
+// OutputValue is a named output of a module, such as an endpoint, ARN or connection string, consumed
+// by other modules and the CLI instead of scraping the resources.
+type OutputValue struct {
+	// Value is the output value, which may be an implicit reference resolved by the engine, see RefAttr
+	Value any `json:"value" yaml:"value"`
+	// Sensitive asks consumers to hide the value, e.g. passwords in connection strings
+	Sensitive bool `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
+	// Description describes the output
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// String returns the value, or a placeholder if the value is sensitive, so that outputs can be logged.
+func (o OutputValue) String() string {
+	if o.Sensitive {
+		return "(sensitive)"
+	}
+	return fmt.Sprint(o.Value)
+}
+
+var outputNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// SetOutput sets the output of name, which must be an identifier such as endpoint or db_arn.
+func (r *GeneratorResponse) SetOutput(name string, value OutputValue) error {
+	if !outputNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid output name %q, expected an identifier", name)
+	}
+	if r.Outputs == nil {
+		r.Outputs = map[string]OutputValue{}
+	}
+	r.Outputs[name] = value
+	return nil
+}
+
+// validateOutputs checks the names of the outputs.
+func validateOutputs(outputs map[string]OutputValue) error {
+	for name := range outputs {
+		if !outputNamePattern.MatchString(name) {
+			return NewError(ErrCodeInternal, "invalid output name %q", name).
+				WithHint("output names must be identifiers such as endpoint or db_arn")
+		}
+	}
+	return nil
+}
+
+// sendOutputs sends the outputs to the engine in the gRPC response header.
+func sendOutputs(ctx context.Context, outputs map[string]OutputValue) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	if err := validateOutputs(outputs); err != nil {
+		return err
+	}
+	out, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("marshal outputs failed. %w", err)
+	}
+	if err = setHeader(ctx, OutputsMetadataKey, string(out)); err != nil {
+		return fmt.Errorf("send outputs failed. %w", err)
+	}
+	return nil
+}
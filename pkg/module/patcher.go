@@ -0,0 +1,139 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PatcherMetadataKey is the gRPC response header carrying the serialized Patcher. The proto
+// GeneratorResponse has no field for patches, so the wrapper sends them as binary metadata.
+const PatcherMetadataKey = "kusion-module-patcher-bin"
+
+// Patcher contains the patches a module applies to the workload, in addition to the net-new resources.
+type Patcher struct {
+	// JSONPatches are RFC 6902 JSON patch operations applied to the workload
+	JSONPatches []JSONPatchOperation `json:"jsonPatches,omitempty" yaml:"jsonPatches,omitempty"`
+	// StrategicMergePatch is a strategic-merge patch applied to the workload
+	StrategicMergePatch map[string]any `json:"strategicMergePatch,omitempty" yaml:"strategicMergePatch,omitempty"`
+}
+
+// JSONPatchOperation is a single RFC 6902 JSON patch operation.
+type JSONPatchOperation struct {
+	// Op is one of add, remove, replace, move, copy and test
+	Op string `json:"op" yaml:"op"`
+	// Path is the JSON pointer of the target location, e.g. /spec/template/metadata/labels/app
+	Path string `json:"path" yaml:"path"`
+	// From is the JSON pointer of the source location of move and copy operations
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	// Value is the value used by add, replace and test operations, in which nil is the JSON null
+	Value any `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of add, replace and test operations, which RFC 6902 requires even if null.
+func (o JSONPatchOperation) MarshalJSON() ([]byte, error) {
+	type operation JSONPatchOperation
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			operation
+			Value any `json:"value"`
+		}{operation(o), o.Value})
+	}
+	return json.Marshal(operation(o))
+}
+
+// IsEmpty reports whether the patcher contains no patches.
+func (p *Patcher) IsEmpty() bool {
+	return p == nil || (len(p.JSONPatches) == 0 && len(p.StrategicMergePatch) == 0)
+}
+
+// Merge adds the patches of other after the patches of p. Strategic-merge patches are merged map by
+// map and their lists are concatenated, so containers and volumes injected by both are kept.
+func (p *Patcher) Merge(other *Patcher) {
+	if other == nil {
+		return
+	}
+	p.JSONPatches = append(p.JSONPatches, other.JSONPatches...)
+	if len(other.StrategicMergePatch) == 0 {
+		return
+	}
+	if p.StrategicMergePatch == nil {
+		p.StrategicMergePatch = map[string]any{}
+	}
+	p.StrategicMergePatch = mergePatchValues(p.StrategicMergePatch, other.StrategicMergePatch).(map[string]any)
+}
+
+func mergePatchValues(base, override any) any {
+	switch o := override.(type) {
+	case map[string]any:
+		b, ok := base.(map[string]any)
+		if !ok {
+			return o
+		}
+		merged := make(map[string]any, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			merged[k] = mergePatchValues(merged[k], v)
+		}
+		return merged
+	case []any:
+		if b, ok := base.([]any); ok {
+			return append(append([]any{}, b...), o...)
+		}
+	}
+	return override
+}
+
+// Validate checks whether all patches in the patcher are well-formed.
+func (p *Patcher) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for i, op := range p.JSONPatches {
+		if err := op.Validate(); err != nil {
+			return fmt.Errorf("invalid json patch operation at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks whether the operation is a well-formed RFC 6902 operation.
+func (o JSONPatchOperation) Validate() error {
+	if !strings.HasPrefix(o.Path, "/") {
+		return fmt.Errorf("path %q must be a JSON pointer starting with /", o.Path)
+	}
+	switch o.Op {
+	case "add", "replace", "test":
+		// a nil value is the JSON null, which RFC 6902 allows
+	case "move", "copy":
+		if !strings.HasPrefix(o.From, "/") {
+			return fmt.Errorf("from %q of %s operation must be a JSON pointer starting with /", o.From, o.Op)
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("unsupported op %q", o.Op)
+	}
+	return nil
+}
+
+// sendPatcher validates the patcher and sends it to the engine in the gRPC response header.
+func sendPatcher(ctx context.Context, p *Patcher) error {
+	if p.IsEmpty() {
+		return nil
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	out, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal patcher failed. %w", err)
+	}
+	if err = setHeader(ctx, PatcherMetadataKey, string(out)); err != nil {
+		return fmt.Errorf("send patcher failed. %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package module
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONPatchOperationValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      JSONPatchOperation
+		wantErr bool
+	}{
+		{name: "add", op: JSONPatchOperation{Op: "add", Path: "/metadata/labels/app", Value: "web"}},
+		{name: "add null", op: JSONPatchOperation{Op: "add", Path: "/spec/replicas"}},
+		{name: "replace null", op: JSONPatchOperation{Op: "replace", Path: "/spec/replicas"}},
+		{name: "test null", op: JSONPatchOperation{Op: "test", Path: "/spec/replicas"}},
+		{name: "remove", op: JSONPatchOperation{Op: "remove", Path: "/spec/replicas"}},
+		{name: "move", op: JSONPatchOperation{Op: "move", Path: "/a", From: "/b"}},
+		{name: "copy without from", op: JSONPatchOperation{Op: "copy", Path: "/a"}, wantErr: true},
+		{name: "relative path", op: JSONPatchOperation{Op: "remove", Path: "spec"}, wantErr: true},
+		{name: "unsupported op", op: JSONPatchOperation{Op: "merge", Path: "/spec"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONPatchOperationMarshalJSON(t *testing.T) {
+	tests := []struct {
+		op   JSONPatchOperation
+		want string
+	}{
+		{JSONPatchOperation{Op: "add", Path: "/spec/replicas"}, `{"op":"add","path":"/spec/replicas","value":null}`},
+		{JSONPatchOperation{Op: "replace", Path: "/spec/replicas", Value: 2}, `{"op":"replace","path":"/spec/replicas","value":2}`},
+		{JSONPatchOperation{Op: "remove", Path: "/spec/replicas"}, `{"op":"remove","path":"/spec/replicas"}`},
+		{JSONPatchOperation{Op: "move", Path: "/a", From: "/b"}, `{"op":"move","path":"/a","from":"/b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op.Op, func(t *testing.T) {
+			out, err := json.Marshal(tt.op)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
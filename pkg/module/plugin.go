@@ -0,0 +1,68 @@
+package module
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// FrameworkServiceName is the name of the gRPC service serving the framework RPCs beyond Generate.
+const FrameworkServiceName = "kusion.module.framework.v1.Framework"
+
+// RegisterServices registers the module service and the framework service of the wrapper on s,
+// used to serve modules in-process without go-plugin, e.g. in tests.
+func RegisterServices(s *grpc.Server, w *FrameworkModuleWrapper) {
+	proto.RegisterModuleServer(s, w)
+	s.RegisterService(&frameworkServiceDesc, w)
+}
+
+// frameworkMethod is a unary RPC of the framework service. The request and response
+// payloads are JSON documents wrapped in BytesValue messages, so no generated code is needed.
+type frameworkMethod func(f *FrameworkModuleWrapper, ctx context.Context, in []byte) ([]byte, error)
+
+var frameworkServiceDesc = grpc.ServiceDesc{
+	ServiceName: FrameworkServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Info", Handler: frameworkHandler("Info", (*FrameworkModuleWrapper).infoRPC)},
+		{MethodName: "Ready", Handler: frameworkHandler("Ready", (*FrameworkModuleWrapper).readyRPC)},
+		{MethodName: "OnDelete", Handler: frameworkHandler("OnDelete", (*FrameworkModuleWrapper).onDeleteRPC)},
+	},
+	Streams: []grpc.StreamDesc{
+		generateStreamDesc,
+		generateEventsDesc,
+	},
+	Metadata: "framework",
+}
+
+func frameworkHandler(name string, method frameworkMethod) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	fullMethod := "/" + FrameworkServiceName + "/" + name
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := &wrapperspb.BytesValue{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			out, err := method(srv.(*FrameworkModuleWrapper), ctx, req.(*wrapperspb.BytesValue).GetValue())
+			if err != nil {
+				return nil, err
+			}
+			return wrapperspb.Bytes(out), nil
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, handler)
+	}
+}
+
+// invokeFramework calls a method of the framework service on conn, used by hosts of module plugins.
+func invokeFramework(ctx context.Context, conn grpc.ClientConnInterface, name string, in []byte) ([]byte, error) {
+	out := &wrapperspb.BytesValue{}
+	if err := conn.Invoke(ctx, "/"+FrameworkServiceName+"/"+name, wrapperspb.Bytes(in), out); err != nil {
+		return nil, err
+	}
+	return out.GetValue(), nil
+}
@@ -0,0 +1,226 @@
+// Package policy evaluates policies against the resources generated by a module and fails the
+// generation with the violations, giving platform teams built-in guardrails. Policies are written
+// in Rego and bundled with the module or supplied in the platform module config:
+//
+//	//go:embed policies
+//	var policies embed.FS
+//
+//	func main() {
+//		bundled, err := policy.LoadFS(policies, "policies")
+//		if err != nil {
+//			panic(err)
+//		}
+//		module.Serve(&MyModule{}, module.WithResponseCheck(policy.Check(bundled)))
+//	}
+//
+// Rego policies define a deny set in the kusion.policy package, whose elements are messages or objects
+// with msg and id fields:
+//
+//	package kusion.policy
+//
+//	import rego.v1
+//
+//	deny contains {"msg": "privileged containers are not allowed", "id": r.id} if {
+//		some r in input.resources
+//		r.attributes.spec.template.spec.containers[_].securityContext.privileged
+//	}
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// DefaultQuery is the Rego query of the violations.
+const DefaultQuery = "data.kusion.policy.deny"
+
+// Input is the input document of policies.
+type Input struct {
+	Project   string        `json:"project"`
+	Stack     string        `json:"stack"`
+	App       string        `json:"app"`
+	Module    string        `json:"module,omitempty"`
+	Resources []v1.Resource `json:"resources"`
+}
+
+// Violation is a policy violation of the generated resources.
+type Violation struct {
+	// Policy is the name of the violated policy
+	Policy string `json:"policy,omitempty"`
+	// ResourceID is the ID of the violating resource, empty if the violation is not about a single resource
+	ResourceID string `json:"id,omitempty"`
+	// Message describes the violation
+	Message string `json:"msg"`
+}
+
+func (v Violation) String() string {
+	var b strings.Builder
+	if v.Policy != "" {
+		b.WriteString("[" + v.Policy + "] ")
+	}
+	if v.ResourceID != "" {
+		b.WriteString(v.ResourceID + ": ")
+	}
+	b.WriteString(v.Message)
+	return b.String()
+}
+
+// Evaluator evaluates policies against the input and returns the violations.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input *Input) ([]Violation, error)
+}
+
+// Func is an Evaluator implemented in Go, for checks not worth a Rego policy.
+type Func func(ctx context.Context, input *Input) ([]Violation, error)
+
+// Evaluate implements Evaluator.
+func (f Func) Evaluate(ctx context.Context, input *Input) ([]Violation, error) {
+	return f(ctx, input)
+}
+
+// Check returns a module.ResponseCheck evaluating the evaluators and the Rego policies of the platform
+// module config, see module.PlatformConfigPoliciesKey. The generation fails with ErrCodeInvalidConfig
+// listing all violations.
+func Check(evaluators ...Evaluator) module.ResponseCheck {
+	return func(ctx context.Context, req *module.GeneratorRequest, resp *module.GeneratorResponse) error {
+		all := evaluators
+		platform, err := FromPlatformConfig(req)
+		if err != nil {
+			return err
+		}
+		if platform != nil {
+			all = append(append([]Evaluator{}, evaluators...), platform)
+		}
+		violations, err := Evaluate(ctx, NewInput(req, resp), all...)
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			return nil
+		}
+		messages := make([]string, 0, len(violations))
+		for _, v := range violations {
+			messages = append(messages, v.String())
+		}
+		return module.NewError(module.ErrCodeInvalidConfig, "%d policy violations: %s", len(violations), strings.Join(messages, "; ")).
+			WithHint("change the module config to comply with the policies, or ask platform engineers to update them")
+	}
+}
+
+// NewInput returns the policy input of the response generated for req.
+func NewInput(req *module.GeneratorRequest, resp *module.GeneratorResponse) *Input {
+	input := &Input{Project: req.Project, Stack: req.Stack, App: req.App, Module: req.Module}
+	if resp != nil {
+		input.Resources = resp.Resources
+	}
+	return input
+}
+
+// Evaluate evaluates the evaluators in turn and returns all violations sorted by resource ID.
+func Evaluate(ctx context.Context, input *Input, evaluators ...Evaluator) ([]Violation, error) {
+	var violations []Violation
+	for _, e := range evaluators {
+		out, err := e.Evaluate(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate policies failed. %w", err)
+		}
+		violations = append(violations, out...)
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].ResourceID < violations[j].ResourceID
+	})
+	return violations, nil
+}
+
+// FromPlatformConfig returns a Rego evaluator of the policies in the platform module config, or nil
+// if the config supplies no policies.
+func FromPlatformConfig(req *module.GeneratorRequest) (*Rego, error) {
+	raw, ok := req.PlatformModuleConfig[module.PlatformConfigPoliciesKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	invalid := module.NewError(module.ErrCodeInvalidConfig, "%s must be a map of policy names to Rego sources", module.PlatformConfigPoliciesKey)
+	policies := map[string]string{}
+	switch m := raw.(type) {
+	case map[string]any:
+		for name, src := range m {
+			s, ok := src.(string)
+			if !ok {
+				return nil, invalid
+			}
+			policies[name] = s
+		}
+	case map[any]any:
+		for name, src := range m {
+			s, ok := src.(string)
+			if !ok {
+				return nil, invalid
+			}
+			policies[fmt.Sprint(name)] = s
+		}
+	default:
+		return nil, invalid
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return &Rego{Modules: policies}, nil
+}
+
+// inputJSON marshals the input, converting the attributes decoded by yaml.v2 into JSON.
+func inputJSON(input *Input) ([]byte, error) {
+	s, err := module.SerializerFor(module.EncodingJSON)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("marshal policy input failed. %w", err)
+	}
+	return data, nil
+}
+
+// parseViolations converts the value of the deny set into violations. Elements are messages, or objects
+// with msg or message and id fields.
+func parseViolations(policy string, value json.RawMessage) ([]Violation, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(value, &items); err != nil {
+		return nil, fmt.Errorf("policy result must be a set of violations. %w", err)
+	}
+	violations := make([]Violation, 0, len(items))
+	for _, item := range items {
+		var msg string
+		if err := json.Unmarshal(item, &msg); err == nil {
+			violations = append(violations, Violation{Policy: policy, Message: msg})
+			continue
+		}
+		var obj struct {
+			Msg     string `json:"msg"`
+			Message string `json:"message"`
+			ID      string `json:"id"`
+			Policy  string `json:"policy"`
+		}
+		if err := json.Unmarshal(item, &obj); err != nil {
+			return nil, fmt.Errorf("invalid violation %s. %w", item, err)
+		}
+		v := Violation{Policy: policy, ResourceID: obj.ID, Message: obj.Msg}
+		if v.Message == "" {
+			v.Message = obj.Message
+		}
+		if obj.Policy != "" {
+			v.Policy = obj.Policy
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OPABinaryEnv is the environment variable overriding the path of the opa binary used by Rego.
+const OPABinaryEnv = "KUSION_OPA_BINARY"
+
+// Rego evaluates Rego policies with the opa binary, which must be installed where the module runs.
+// The framework does not embed the OPA runtime to keep module binaries small.
+type Rego struct {
+	// Modules is the Rego sources by policy name
+	Modules map[string]string
+	// Query is the query of the violations, DefaultQuery is used if empty
+	Query string
+	// Binary is the path of the opa binary, OPABinaryEnv or opa in PATH is used if empty
+	Binary string
+}
+
+// LoadFS returns a Rego evaluator of the .rego files under dir of fsys, e.g. policies embedded in the
+// module binary. Test files ending with _test.rego are skipped.
+func LoadFS(fsys fs.FS, dir string) (*Rego, error) {
+	r := &Rego{Modules: map[string]string{}}
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".rego" || strings.HasSuffix(p, "_test.rego") {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		r.Modules[strings.TrimPrefix(p, dir+"/")] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load policies from %s failed. %w", dir, err)
+	}
+	return r, nil
+}
+
+// Evaluate implements Evaluator by running opa eval with the input on stdin. Violations are attributed
+// to the policy defining them only if there is a single policy, as opa reports the union of deny sets.
+func (r *Rego) Evaluate(ctx context.Context, input *Input) ([]Violation, error) {
+	if len(r.Modules) == 0 {
+		return nil, nil
+	}
+	dir, err := os.MkdirTemp("", "kusion-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	names := make([]string, 0, len(r.Modules))
+	for name := range r.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := map[string]string{}
+	for _, name := range names {
+		// a.rego, ./a.rego and a name the same policy
+		key := strings.TrimSuffix(path.Clean(name), ".rego")
+		if other, ok := seen[key]; ok {
+			return nil, fmt.Errorf("duplicate policies %s and %s", other, name)
+		}
+		seen[key] = name
+	}
+	// the files are named by index, as policy names may contain any characters
+	for i, name := range names {
+		if err = os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.rego", i)), []byte(r.Modules[name]), 0o600); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := inputJSON(input)
+	if err != nil {
+		return nil, err
+	}
+	query := r.Query
+	if query == "" {
+		query = DefaultQuery
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.binary(), "eval", "--format", "json", "--stdin-input", "--data", dir, query)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("opa eval failed: %w: %s", err, strings.TrimSpace(stderr.String()+stdout.String()))
+	}
+
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("unmarshal opa eval result failed. %w", err)
+	}
+	policy := ""
+	if len(names) == 1 {
+		policy = names[0]
+	}
+	var violations []Violation
+	for _, result := range out.Result {
+		for _, expr := range result.Expressions {
+			v, err := parseViolations(policy, expr.Value)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, v...)
+		}
+	}
+	return violations, nil
+}
+
+func (r *Rego) binary() string {
+	if r.Binary != "" {
+		return r.Binary
+	}
+	if bin := os.Getenv(OPABinaryEnv); bin != "" {
+		return bin
+	}
+	return "opa"
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+const replicasPolicy = `package kusion.policy
+
+import rego.v1
+
+deny contains {"id": r.id, "msg": "replicas must not exceed 10"} if {
+	some r in input.resources
+	r.attributes.spec.replicas > 10
+}
+`
+
+const projectPolicy = `package kusion.policy
+
+import rego.v1
+
+deny contains "project must be set" if input.project == ""
+`
+
+func testInput(replicas int) *Input {
+	return &Input{Project: "p", Stack: "dev", App: "app", Resources: []v1.Resource{{
+		ID:         "apps/v1:Deployment:app:web",
+		Type:       v1.Kubernetes,
+		Attributes: map[string]interface{}{"spec": map[string]interface{}{"replicas": replicas}},
+	}}}
+}
+
+func TestRegoEvaluate(t *testing.T) {
+	if _, err := exec.LookPath("opa"); err != nil {
+		t.Skip("opa is not installed")
+	}
+	r := &Rego{Modules: map[string]string{"limits/replicas.rego": replicasPolicy}}
+	violations, err := r.Evaluate(context.Background(), testInput(20))
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	want := []Violation{{Policy: "limits/replicas.rego", ResourceID: "apps/v1:Deployment:app:web", Message: "replicas must not exceed 10"}}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("Evaluate() = %v, want %v", violations, want)
+	}
+	if violations, err = r.Evaluate(context.Background(), testInput(3)); err != nil || len(violations) != 0 {
+		t.Errorf("Evaluate() of compliant resources = %v, %v, want no violations", violations, err)
+	}
+
+	// the names of both policies used to be written to the same file
+	r.Modules = map[string]string{"limits/replicas.rego": replicasPolicy, "limits_replicas.rego": projectPolicy}
+	input := testInput(20)
+	input.Project = ""
+	if violations, err = r.Evaluate(context.Background(), input); err != nil || len(violations) != 2 {
+		t.Errorf("Evaluate() of two policies = %v, %v, want the violations of both", violations, err)
+	}
+}
+
+func TestRegoEvaluateFiles(t *testing.T) {
+	// the fake opa lists the policy files it is given
+	bin := filepath.Join(t.TempDir(), "opa")
+	script := "#!/bin/sh\nwhile [ \"$1\" != --data ]; do shift; done\n" +
+		"printf '{\"result\":[{\"expressions\":[{\"value\":['\n" +
+		"sep=''; for f in \"$2\"/*; do printf '%s\"%s\"' \"$sep\" \"$(basename \"$f\")\"; sep=,; done\n" +
+		"printf ']}]}]}'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	r := &Rego{Binary: bin, Modules: map[string]string{"a/b.rego": projectPolicy, "a_b.rego": projectPolicy, "c": projectPolicy}}
+	violations, err := r.Evaluate(context.Background(), testInput(1))
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	var files []string
+	for _, v := range violations {
+		files = append(files, v.Message)
+	}
+	if want := []string{"0.rego", "1.rego", "2.rego"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("policy files = %v, want %v", files, want)
+	}
+}
+
+func TestRegoEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rego    *Rego
+		wantErr string
+	}{
+		{
+			name:    "duplicate names",
+			rego:    &Rego{Binary: "opa", Modules: map[string]string{"limits.rego": projectPolicy, "./limits": replicasPolicy}},
+			wantErr: "duplicate policies ./limits and limits.rego",
+		},
+		{
+			name:    "missing binary",
+			rego:    &Rego{Binary: filepath.Join(t.TempDir(), "missing-opa"), Modules: map[string]string{"limits.rego": projectPolicy}},
+			wantErr: "opa eval failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.rego.Evaluate(context.Background(), testInput(1))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Evaluate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Server evaluates the policies loaded in an OPA server through its data API, so that platform teams
+// can manage policies centrally instead of bundling them with every module.
+type Server struct {
+	// Address is the address of the OPA server, e.g. http://localhost:8181
+	Address string
+	// Path is the path of the deny set under /v1/data, e.g. kusion/policy/deny
+	Path string
+	// Token is the bearer token sent to the server, optional
+	Token string
+	// Client is the HTTP client, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+// Evaluate implements Evaluator.
+func (s *Server) Evaluate(ctx context.Context, input *Input) ([]Violation, error) {
+	data, err := inputJSON(input)
+	if err != nil {
+		return nil, err
+	}
+	p := s.Path
+	if p == "" {
+		p = strings.ReplaceAll(strings.TrimPrefix(DefaultQuery, "data."), ".", "/")
+	}
+	body := append(append([]byte(`{"input":`), data...), '}')
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.Address, "/")+"/v1/data/"+strings.TrimPrefix(p, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query opa server failed. %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("query opa server failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode opa server response failed. %w", err)
+	}
+	return parseViolations("", out.Result)
+}
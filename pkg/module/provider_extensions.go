@@ -0,0 +1,116 @@
+package module
+
+import (
+	"fmt"
+	"os"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// CloudProvider is the name of a Terraform provider in the Terraform runtime config of workspaces.
+type CloudProvider string
+
+// Cloud providers supported by ProviderExtensions. The kits in pkg/module/providers cover AWS and Alicloud.
+const (
+	ProviderAzure  CloudProvider = "azurerm"
+	ProviderGoogle CloudProvider = "google"
+)
+
+// Keys of the provider config in the Terraform runtime config and environment variables read if unset.
+const (
+	AzureSubscriptionIDKey = "subscription_id"
+	AzureSubscriptionIDEnv = "ARM_SUBSCRIPTION_ID"
+	GoogleProjectKey       = "project"
+	GoogleProjectEnv       = "GOOGLE_PROJECT"
+	GoogleRegionEnv        = "GOOGLE_REGION"
+)
+
+// ProviderExtensionConfig is the input of ProviderExtensions.
+type ProviderExtensionConfig struct {
+	// RuntimeConfig is the runtime configs of the workspace, usually GeneratorRequest.RuntimeConfig
+	RuntimeConfig *v1.RuntimeConfigs
+	// ResourceType is the Terraform resource type, e.g. azurerm_storage_account
+	ResourceType string
+}
+
+type cloudProviderDefaults struct {
+	source  string
+	version string
+	// complete fills the provider config with the values read from the environment and checks the required ones
+	complete func(c *TFProviderConfig) error
+}
+
+var cloudProviders = map[CloudProvider]cloudProviderDefaults{
+	ProviderAzure: {
+		source:  "hashicorp/azurerm",
+		version: "3.94.0",
+		complete: func(c *TFProviderConfig) error {
+			if err := requireProviderMeta(c, AzureSubscriptionIDKey, AzureSubscriptionIDEnv); err != nil {
+				return err
+			}
+			// the azurerm provider requires the features block even if empty
+			if _, ok := c.Meta["features"]; !ok {
+				c.Meta["features"] = map[string]any{}
+			}
+			return nil
+		},
+	},
+	ProviderGoogle: {
+		source:  "hashicorp/google",
+		version: "5.19.0",
+		complete: func(c *TFProviderConfig) error {
+			if c.Region == "" {
+				c.Region = os.Getenv(GoogleRegionEnv)
+			}
+			return requireProviderMeta(c, GoogleProjectKey, GoogleProjectEnv)
+		},
+	},
+}
+
+// CloudProviderConfig returns the config of the cloud provider in the runtime config, with the default
+// source and version if not set. The Azure subscription ID and the Google project are required, which
+// fall back to the ARM_SUBSCRIPTION_ID and GOOGLE_PROJECT environment variables.
+func CloudProviderConfig(p CloudProvider, rc *v1.RuntimeConfigs) (*TFProviderConfig, error) {
+	d, ok := cloudProviders[p]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cloud provider %q", p)
+	}
+	c, err := TFProviderFromRuntimeConfig(rc, string(p), &TFProviderConfig{Source: d.source, Version: d.version})
+	if err != nil {
+		return nil, err
+	}
+	if c.Meta == nil {
+		c.Meta = map[string]any{}
+	}
+	if err = d.complete(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ProviderExtensions returns the resource extensions of a Terraform resource managed by the cloud provider,
+// so that modules do not need to build the extension maps by hand.
+func ProviderExtensions(p CloudProvider, cfg ProviderExtensionConfig) (map[string]any, error) {
+	if cfg.ResourceType == "" {
+		return nil, fmt.Errorf("resource type of %s resource is empty", p)
+	}
+	c, err := CloudProviderConfig(p, cfg.RuntimeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return c.Extensions(cfg.ResourceType), nil
+}
+
+// requireProviderMeta sets the provider meta key from the environment variable env if unset, and returns
+// an error if neither is set.
+func requireProviderMeta(c *TFProviderConfig, key, env string) error {
+	if v, ok := c.Meta[key].(string); ok && v != "" {
+		return nil
+	}
+	if v := os.Getenv(env); v != "" {
+		c.Meta[key] = v
+		return nil
+	}
+	return NewError(ErrCodeInvalidConfig, "%s of terraform provider %s is not set", key, c.Name()).
+		WithHint(fmt.Sprintf("set %s of the provider in the workspace runtime config or the %s environment variable", key, env))
+}
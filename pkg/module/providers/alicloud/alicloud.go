@@ -0,0 +1,112 @@
+// Package alicloud provides helpers for modules generating Alicloud resources with the Terraform
+// Alicloud provider, such as the provider, region and credential resolution, tagging conventions and
+// wrappers of common resources.
+package alicloud
+
+import (
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+const (
+	// ProviderName is the key of the Alicloud provider in the Terraform runtime config of workspaces
+	ProviderName = "alicloud"
+	// DefaultProviderSource is the source of the Alicloud provider used if the runtime config has none
+	DefaultProviderSource = "aliyun/alicloud"
+	// DefaultProviderVersion is the version of the Alicloud provider used if the runtime config has none
+	DefaultProviderVersion = "1.209.1"
+)
+
+// Environment variables read by the Alicloud provider, used if the runtime config does not set the values.
+const (
+	RegionEnv    = "ALICLOUD_REGION"
+	AccessKeyEnv = "ALICLOUD_ACCESS_KEY"
+	SecretKeyEnv = "ALICLOUD_SECRET_KEY"
+)
+
+// Keys of the credentials in the provider config of the Terraform runtime config.
+const (
+	ProviderMetaAccessKey = "access_key"
+	ProviderMetaSecretKey = "secret_key"
+)
+
+// Tag keys set by Tags, which are the well-known labels of the module package, so that tags and
+// labels select the same resources.
+const (
+	TagManagedBy = module.LabelManagedBy
+	TagProject   = module.LabelProject
+	TagStack     = module.LabelStack
+	TagApp       = module.LabelApp
+)
+
+// Credentials is the access key pair of Alicloud.
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// Provider returns the Alicloud provider config of the request. The provider config in the runtime config
+// of the workspace is used if set, otherwise the default provider. The region falls back to the
+// ALICLOUD_REGION environment variable and an error is returned if it is not set either.
+func Provider(req *module.GeneratorRequest) (*module.TFProviderConfig, error) {
+	c, err := module.TFProviderFromRuntimeConfig(req.RuntimeConfig, ProviderName, &module.TFProviderConfig{
+		Source:  DefaultProviderSource,
+		Version: DefaultProviderVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.Region == "" {
+		c.Region = os.Getenv(RegionEnv)
+	}
+	if c.Region == "" {
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "region of alicloud provider is not set").
+			WithHint(fmt.Sprintf("set region of the alicloud provider in the workspace runtime config or the %s environment variable", RegionEnv))
+	}
+	return c, nil
+}
+
+// Region returns the Alicloud region of the request, see Provider.
+func Region(req *module.GeneratorRequest) (string, error) {
+	c, err := Provider(req)
+	if err != nil {
+		return "", err
+	}
+	return c.Region, nil
+}
+
+// ProviderCredentials returns the credentials in the provider meta, falling back to the
+// ALICLOUD_ACCESS_KEY and ALICLOUD_SECRET_KEY environment variables.
+func ProviderCredentials(provider *module.TFProviderConfig) (*Credentials, error) {
+	c := &Credentials{}
+	c.AccessKey, _ = provider.Meta[ProviderMetaAccessKey].(string)
+	c.SecretKey, _ = provider.Meta[ProviderMetaSecretKey].(string)
+	if c.AccessKey == "" {
+		c.AccessKey = os.Getenv(AccessKeyEnv)
+	}
+	if c.SecretKey == "" {
+		c.SecretKey = os.Getenv(SecretKeyEnv)
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "credentials of alicloud provider are not set").
+			WithHint(fmt.Sprintf("set the %s and %s environment variables", AccessKeyEnv, SecretKeyEnv))
+	}
+	return c, nil
+}
+
+// Tags returns the tags of resources generated for the request, tagged with the project, stack and
+// app. The extra tags are added and take precedence.
+func Tags(req *module.GeneratorRequest, extra map[string]string) map[string]string {
+	tags := map[string]string{
+		TagManagedBy: module.ManagedByKusion,
+		TagProject:   req.Project,
+		TagStack:     req.Stack,
+		TagApp:       req.App,
+	}
+	for k, v := range extra {
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,147 @@
+package alicloud
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Terraform resource types of the wrappers.
+const (
+	ResourceTypeDBInstance      = "alicloud_db_instance"
+	ResourceTypeOSSBucket       = "alicloud_oss_bucket"
+	ResourceTypeSLBLoadBalancer = "alicloud_slb_load_balancer"
+	ResourceTypeKVStoreInstance = "alicloud_kvstore_instance"
+)
+
+// RDSInstance is the config of an alicloud_db_instance resource.
+type RDSInstance struct {
+	InstanceName       string
+	Engine             string
+	EngineVersion      string
+	InstanceType       string
+	InstanceStorage    int
+	InstanceChargeType string
+	VSwitchID          string
+	SecurityIPs        []string
+	Tags               map[string]string
+}
+
+// Resource wraps the instance into a Kusion resource named name.
+func (r *RDSInstance) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if r.Engine == "" || r.EngineVersion == "" || r.InstanceType == "" || r.InstanceStorage == 0 {
+		return v1.Resource{}, fmt.Errorf("engine, engine version, instance type and storage of rds instance %s must not be empty", name)
+	}
+	attrs := map[string]interface{}{
+		"engine":           r.Engine,
+		"engine_version":   r.EngineVersion,
+		"instance_type":    r.InstanceType,
+		"instance_storage": r.InstanceStorage,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"instance_name":        r.InstanceName,
+		"instance_charge_type": r.InstanceChargeType,
+		"vswitch_id":           r.VSwitchID,
+		"security_ips":         r.SecurityIPs,
+		"tags":                 r.Tags,
+	})
+	return provider.WrapResource(ResourceTypeDBInstance, name, attrs)
+}
+
+// OSSBucket is the config of an alicloud_oss_bucket resource.
+type OSSBucket struct {
+	Bucket       string
+	ACL          string
+	StorageClass string
+	ForceDestroy bool
+	Tags         map[string]string
+}
+
+// Resource wraps the bucket into a Kusion resource named name.
+func (b *OSSBucket) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if b.Bucket == "" {
+		return v1.Resource{}, fmt.Errorf("bucket of oss bucket %s must not be empty", name)
+	}
+	attrs := map[string]interface{}{
+		"bucket":        b.Bucket,
+		"force_destroy": b.ForceDestroy,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"acl":           b.ACL,
+		"storage_class": b.StorageClass,
+		"tags":          b.Tags,
+	})
+	return provider.WrapResource(ResourceTypeOSSBucket, name, attrs)
+}
+
+// SLBLoadBalancer is the config of an alicloud_slb_load_balancer resource.
+type SLBLoadBalancer struct {
+	LoadBalancerName string
+	LoadBalancerSpec string
+	AddressType      string
+	VSwitchID        string
+	Tags             map[string]string
+}
+
+// Resource wraps the load balancer into a Kusion resource named name.
+func (l *SLBLoadBalancer) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	attrs := map[string]interface{}{}
+	setAttrs(attrs, map[string]interface{}{
+		"load_balancer_name": l.LoadBalancerName,
+		"load_balancer_spec": l.LoadBalancerSpec,
+		"address_type":       l.AddressType,
+		"vswitch_id":         l.VSwitchID,
+		"tags":               l.Tags,
+	})
+	return provider.WrapResource(ResourceTypeSLBLoadBalancer, name, attrs)
+}
+
+// RedisInstance is the config of an alicloud_kvstore_instance resource of the Redis engine.
+type RedisInstance struct {
+	InstanceName  string
+	InstanceClass string
+	EngineVersion string
+	VSwitchID     string
+	Password      string
+	SecurityIPs   []string
+	Tags          map[string]string
+}
+
+// Resource wraps the instance into a Kusion resource named name.
+func (r *RedisInstance) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if r.InstanceClass == "" {
+		return v1.Resource{}, fmt.Errorf("instance class of redis instance %s must not be empty", name)
+	}
+	attrs := map[string]interface{}{
+		"instance_type":  "Redis",
+		"instance_class": r.InstanceClass,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"db_instance_name": r.InstanceName,
+		"engine_version":   r.EngineVersion,
+		"vswitch_id":       r.VSwitchID,
+		"password":         r.Password,
+		"tags":             r.Tags,
+	})
+	if len(r.SecurityIPs) > 0 {
+		attrs["security_ips"] = strings.Join(r.SecurityIPs, ",")
+	}
+	return provider.WrapResource(ResourceTypeKVStoreInstance, name, attrs)
+}
+
+// setAttrs sets the values that are not zero into attrs, so that the provider defaults are kept.
+func setAttrs(attrs map[string]interface{}, values map[string]interface{}) {
+	for k, v := range values {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			continue
+		}
+		if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.Len() == 0 {
+			continue
+		}
+		attrs[k] = v
+	}
+}
@@ -0,0 +1,88 @@
+// Package aws provides helpers for modules generating AWS resources with the Terraform AWS provider,
+// such as the provider and region resolution, tagging conventions, IAM policy documents and wrappers
+// of common resources.
+package aws
+
+import (
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+const (
+	// ProviderName is the key of the AWS provider in the Terraform runtime config of workspaces
+	ProviderName = "aws"
+	// DefaultProviderSource is the source of the AWS provider used if the runtime config has none
+	DefaultProviderSource = "hashicorp/aws"
+	// DefaultProviderVersion is the version of the AWS provider used if the runtime config has none
+	DefaultProviderVersion = "5.0.1"
+)
+
+// Environment variables of the region, read if the runtime config does not set one.
+const (
+	RegionEnv        = "AWS_REGION"
+	DefaultRegionEnv = "AWS_DEFAULT_REGION"
+)
+
+// Tag keys set by Tags, which are the well-known labels of the module package, so that tags and
+// labels select the same resources.
+const (
+	TagManagedBy = module.LabelManagedBy
+	TagProject   = module.LabelProject
+	TagStack     = module.LabelStack
+	TagApp       = module.LabelApp
+)
+
+// Provider returns the AWS provider config of the request. The provider config in the runtime config
+// of the workspace is used if set, otherwise the default provider. An error is returned if the region
+// can not be resolved, see Region.
+func Provider(req *module.GeneratorRequest) (*module.TFProviderConfig, error) {
+	c, err := module.TFProviderFromRuntimeConfig(req.RuntimeConfig, ProviderName, &module.TFProviderConfig{
+		Source:  DefaultProviderSource,
+		Version: DefaultProviderVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.Region == "" {
+		c.Region = regionFromEnv()
+	}
+	if c.Region == "" {
+		return nil, module.NewError(module.ErrCodeInvalidConfig, "region of aws provider is not set").
+			WithHint(fmt.Sprintf("set region of the aws provider in the workspace runtime config or the %s environment variable", RegionEnv))
+	}
+	return c, nil
+}
+
+// Region returns the AWS region of the request, which is the region of the AWS provider in the runtime
+// config, or the AWS_REGION and AWS_DEFAULT_REGION environment variables in turn.
+func Region(req *module.GeneratorRequest) (string, error) {
+	c, err := Provider(req)
+	if err != nil {
+		return "", err
+	}
+	return c.Region, nil
+}
+
+func regionFromEnv() string {
+	if region := os.Getenv(RegionEnv); region != "" {
+		return region
+	}
+	return os.Getenv(DefaultRegionEnv)
+}
+
+// Tags returns the tags of resources generated for the request, tagged with the project, stack and
+// app. The extra tags are added and take precedence.
+func Tags(req *module.GeneratorRequest, extra map[string]string) map[string]string {
+	tags := map[string]string{
+		TagManagedBy: module.ManagedByKusion,
+		TagProject:   req.Project,
+		TagStack:     req.Stack,
+		TagApp:       req.App,
+	}
+	for k, v := range extra {
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PolicyVersion is the version of IAM policy documents.
+const PolicyVersion = "2012-10-17"
+
+// Effects of IAM policy statements.
+const (
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+)
+
+// PolicyDocument is an IAM policy document, marshaled as the JSON expected by the policy attributes
+// of IAM resources.
+type PolicyDocument struct {
+	Version   string       `json:"Version"`
+	Statement []*Statement `json:"Statement"`
+}
+
+// Statement is a statement of an IAM policy document.
+type Statement struct {
+	Sid       string                            `json:"Sid,omitempty"`
+	Effect    string                            `json:"Effect"`
+	Principal map[string][]string               `json:"Principal,omitempty"`
+	Action    []string                          `json:"Action"`
+	Resource  []string                          `json:"Resource,omitempty"`
+	Condition map[string]map[string]interface{} `json:"Condition,omitempty"`
+}
+
+// NewPolicyDocument returns an empty policy document.
+func NewPolicyDocument() *PolicyDocument {
+	return &PolicyDocument{Version: PolicyVersion}
+}
+
+// Allow adds a statement allowing actions on resources and returns it for further settings.
+func (d *PolicyDocument) Allow(actions []string, resources ...string) *Statement {
+	return d.add(EffectAllow, actions, resources)
+}
+
+// Deny adds a statement denying actions on resources and returns it for further settings.
+func (d *PolicyDocument) Deny(actions []string, resources ...string) *Statement {
+	return d.add(EffectDeny, actions, resources)
+}
+
+func (d *PolicyDocument) add(effect string, actions, resources []string) *Statement {
+	s := &Statement{Effect: effect, Action: actions, Resource: resources}
+	d.Statement = append(d.Statement, s)
+	return s
+}
+
+// Validate checks whether every statement has a valid effect and at least one action.
+func (d *PolicyDocument) Validate() error {
+	for i, s := range d.Statement {
+		if s.Effect != EffectAllow && s.Effect != EffectDeny {
+			return fmt.Errorf("invalid effect %q of statement %d", s.Effect, i)
+		}
+		if len(s.Action) == 0 {
+			return fmt.Errorf("statement %d has no action", i)
+		}
+	}
+	return nil
+}
+
+// JSON returns the policy document as a JSON string.
+func (d *PolicyDocument) JSON() (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("marshal policy document failed. %w", err)
+	}
+	return string(out), nil
+}
+
+// WithSid sets the statement ID.
+func (s *Statement) WithSid(sid string) *Statement {
+	s.Sid = sid
+	return s
+}
+
+// WithPrincipal adds principals of kind, e.g. AWS or Service.
+func (s *Statement) WithPrincipal(kind string, principals ...string) *Statement {
+	if s.Principal == nil {
+		s.Principal = map[string][]string{}
+	}
+	s.Principal[kind] = append(s.Principal[kind], principals...)
+	return s
+}
+
+// WithCondition adds a condition, e.g. WithCondition("StringEquals", "aws:SourceVpc", "vpc-123").
+func (s *Statement) WithCondition(operator, key string, value interface{}) *Statement {
+	if s.Condition == nil {
+		s.Condition = map[string]map[string]interface{}{}
+	}
+	if s.Condition[operator] == nil {
+		s.Condition[operator] = map[string]interface{}{}
+	}
+	s.Condition[operator][key] = value
+	return s
+}
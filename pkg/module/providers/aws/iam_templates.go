@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Terraform resource types of the IAM wrappers.
+const (
+	ResourceTypeIAMRole                 = "aws_iam_role"
+	ResourceTypeIAMPolicy               = "aws_iam_policy"
+	ResourceTypeIAMRolePolicyAttachment = "aws_iam_role_policy_attachment"
+)
+
+// S3ReadOnlyPolicy allows reading the objects of the bucket.
+func S3ReadOnlyPolicy(bucketARN string) *PolicyDocument {
+	d := NewPolicyDocument()
+	d.Allow([]string{"s3:ListBucket"}, bucketARN)
+	d.Allow([]string{"s3:GetObject"}, bucketARN+"/*")
+	return d
+}
+
+// S3ReadWritePolicy allows reading, writing and deleting the objects of the bucket, but not managing the bucket.
+func S3ReadWritePolicy(bucketARN string) *PolicyDocument {
+	d := NewPolicyDocument()
+	d.Allow([]string{"s3:ListBucket"}, bucketARN)
+	d.Allow([]string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject"}, bucketARN+"/*")
+	return d
+}
+
+// SQSConsumerPolicy allows receiving and deleting the messages of the queue.
+func SQSConsumerPolicy(queueARN string) *PolicyDocument {
+	d := NewPolicyDocument()
+	d.Allow([]string{"sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility", "sqs:GetQueueAttributes"}, queueARN)
+	return d
+}
+
+// SQSProducerPolicy allows sending messages to the queue.
+func SQSProducerPolicy(queueARN string) *PolicyDocument {
+	d := NewPolicyDocument()
+	d.Allow([]string{"sqs:SendMessage", "sqs:GetQueueAttributes"}, queueARN)
+	return d
+}
+
+// RDSConnectPolicy allows connecting as the database user with IAM authentication. The dbResourceARN
+// is in the form of arn:aws:rds-db:<region>:<account>:dbuser:<db-resource-id>, without the user.
+func RDSConnectPolicy(dbResourceARN, user string) *PolicyDocument {
+	d := NewPolicyDocument()
+	d.Allow([]string{"rds-db:connect"}, strings.TrimSuffix(dbResourceARN, "/")+"/"+user)
+	return d
+}
+
+// IRSATrustPolicy allows the Kubernetes ServiceAccount to assume the role through the OIDC provider of
+// an EKS cluster. The issuer is the OIDC issuer URL without the https:// scheme.
+func IRSATrustPolicy(oidcProviderARN, issuer, namespace, serviceAccount string) *PolicyDocument {
+	issuer = strings.TrimPrefix(issuer, "https://")
+	d := NewPolicyDocument()
+	d.Allow([]string{"sts:AssumeRoleWithWebIdentity"}).
+		WithPrincipal("Federated", oidcProviderARN).
+		WithCondition("StringEquals", issuer+":sub", fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)).
+		WithCondition("StringEquals", issuer+":aud", "sts.amazonaws.com")
+	return d
+}
+
+// IAMRole is the config of an aws_iam_role resource.
+type IAMRole struct {
+	Name             string
+	AssumeRolePolicy *PolicyDocument
+	Tags             map[string]string
+}
+
+// Resource wraps the role into a Kusion resource named name.
+func (r *IAMRole) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if r.AssumeRolePolicy == nil {
+		return v1.Resource{}, fmt.Errorf("assume role policy of iam role %s must not be empty", name)
+	}
+	policy, err := r.AssumeRolePolicy.JSON()
+	if err != nil {
+		return v1.Resource{}, err
+	}
+	attrs := map[string]interface{}{"assume_role_policy": policy}
+	setAttrs(attrs, map[string]interface{}{
+		"name": r.Name,
+		"tags": r.Tags,
+	})
+	return provider.WrapResource(ResourceTypeIAMRole, name, attrs)
+}
+
+// IAMPolicy is the config of an aws_iam_policy resource.
+type IAMPolicy struct {
+	Name        string
+	Description string
+	Policy      *PolicyDocument
+	Tags        map[string]string
+}
+
+// Resource wraps the policy into a Kusion resource named name.
+func (p *IAMPolicy) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if p.Policy == nil {
+		return v1.Resource{}, fmt.Errorf("policy document of iam policy %s must not be empty", name)
+	}
+	policy, err := p.Policy.JSON()
+	if err != nil {
+		return v1.Resource{}, err
+	}
+	attrs := map[string]interface{}{"policy": policy}
+	setAttrs(attrs, map[string]interface{}{
+		"name":        p.Name,
+		"description": p.Description,
+		"tags":        p.Tags,
+	})
+	return provider.WrapResource(ResourceTypeIAMPolicy, name, attrs)
+}
+
+// GrantRole returns the role assumable by trust, the policy and the attachment of the policy to the role,
+// all named name, which is how modules grant the workload access to the resources they create.
+func GrantRole(provider *module.TFProviderConfig, name string, trust, policy *PolicyDocument, tags map[string]string) ([]v1.Resource, error) {
+	role, err := (&IAMRole{AssumeRolePolicy: trust, Tags: tags}).Resource(provider, name)
+	if err != nil {
+		return nil, err
+	}
+	pol, err := (&IAMPolicy{Policy: policy, Tags: tags}).Resource(provider, name)
+	if err != nil {
+		return nil, err
+	}
+	attachment, err := provider.WrapResource(ResourceTypeIAMRolePolicyAttachment, name, map[string]interface{}{
+		"role":       module.RefAttr(role.ID, "name"),
+		"policy_arn": module.RefAttr(pol.ID, "arn"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	module.DependsOn(&attachment, role.ID, pol.ID)
+	return []v1.Resource{role, pol, attachment}, nil
+}
@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"fmt"
+	"reflect"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Terraform resource types of the wrappers.
+const (
+	ResourceTypeDBInstance         = "aws_db_instance"
+	ResourceTypeS3Bucket           = "aws_s3_bucket"
+	ResourceTypeElastiCacheCluster = "aws_elasticache_cluster"
+	ResourceTypeSQSQueue           = "aws_sqs_queue"
+)
+
+// RDSInstance is the config of an aws_db_instance resource.
+type RDSInstance struct {
+	Identifier         string
+	Engine             string
+	EngineVersion      string
+	InstanceClass      string
+	AllocatedStorage   int
+	DBName             string
+	Username           string
+	Password           string
+	PubliclyAccessible bool
+	SkipFinalSnapshot  bool
+	SubnetGroupName    string
+	SecurityGroupIDs   []string
+	Tags               map[string]string
+}
+
+// Resource wraps the instance into a Kusion resource named name.
+func (r *RDSInstance) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if r.Engine == "" || r.InstanceClass == "" {
+		return v1.Resource{}, fmt.Errorf("engine and instance class of rds instance %s must not be empty", name)
+	}
+	attrs := map[string]interface{}{
+		"engine":              r.Engine,
+		"instance_class":      r.InstanceClass,
+		"publicly_accessible": r.PubliclyAccessible,
+		"skip_final_snapshot": r.SkipFinalSnapshot,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"identifier":             r.Identifier,
+		"engine_version":         r.EngineVersion,
+		"allocated_storage":      r.AllocatedStorage,
+		"db_name":                r.DBName,
+		"username":               r.Username,
+		"password":               r.Password,
+		"db_subnet_group_name":   r.SubnetGroupName,
+		"vpc_security_group_ids": r.SecurityGroupIDs,
+		"tags":                   r.Tags,
+	})
+	return provider.WrapResource(ResourceTypeDBInstance, name, attrs)
+}
+
+// S3Bucket is the config of an aws_s3_bucket resource.
+type S3Bucket struct {
+	Bucket       string
+	ForceDestroy bool
+	Tags         map[string]string
+}
+
+// Resource wraps the bucket into a Kusion resource named name.
+func (b *S3Bucket) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	attrs := map[string]interface{}{
+		"force_destroy": b.ForceDestroy,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"bucket": b.Bucket,
+		"tags":   b.Tags,
+	})
+	return provider.WrapResource(ResourceTypeS3Bucket, name, attrs)
+}
+
+// ElastiCacheCluster is the config of an aws_elasticache_cluster resource.
+type ElastiCacheCluster struct {
+	ClusterID          string
+	Engine             string
+	EngineVersion      string
+	NodeType           string
+	NumCacheNodes      int
+	Port               int
+	ParameterGroupName string
+	SubnetGroupName    string
+	SecurityGroupIDs   []string
+	Tags               map[string]string
+}
+
+// Resource wraps the cluster into a Kusion resource named name.
+func (c *ElastiCacheCluster) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	if c.ClusterID == "" || c.Engine == "" || c.NodeType == "" {
+		return v1.Resource{}, fmt.Errorf("cluster id, engine and node type of elasticache cluster %s must not be empty", name)
+	}
+	numNodes := c.NumCacheNodes
+	if numNodes == 0 {
+		numNodes = 1
+	}
+	attrs := map[string]interface{}{
+		"cluster_id":      c.ClusterID,
+		"engine":          c.Engine,
+		"node_type":       c.NodeType,
+		"num_cache_nodes": numNodes,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"engine_version":       c.EngineVersion,
+		"port":                 c.Port,
+		"parameter_group_name": c.ParameterGroupName,
+		"subnet_group_name":    c.SubnetGroupName,
+		"security_group_ids":   c.SecurityGroupIDs,
+		"tags":                 c.Tags,
+	})
+	return provider.WrapResource(ResourceTypeElastiCacheCluster, name, attrs)
+}
+
+// SQSQueue is the config of an aws_sqs_queue resource.
+type SQSQueue struct {
+	Name                     string
+	FifoQueue                bool
+	DelaySeconds             int
+	VisibilityTimeoutSeconds int
+	MessageRetentionSeconds  int
+	Policy                   *PolicyDocument
+	Tags                     map[string]string
+}
+
+// Resource wraps the queue into a Kusion resource named name.
+func (q *SQSQueue) Resource(provider *module.TFProviderConfig, name string) (v1.Resource, error) {
+	attrs := map[string]interface{}{
+		"fifo_queue": q.FifoQueue,
+	}
+	setAttrs(attrs, map[string]interface{}{
+		"name":                       q.Name,
+		"delay_seconds":              q.DelaySeconds,
+		"visibility_timeout_seconds": q.VisibilityTimeoutSeconds,
+		"message_retention_seconds":  q.MessageRetentionSeconds,
+		"tags":                       q.Tags,
+	})
+	if q.Policy != nil {
+		policy, err := q.Policy.JSON()
+		if err != nil {
+			return v1.Resource{}, err
+		}
+		attrs["policy"] = policy
+	}
+	return provider.WrapResource(ResourceTypeSQSQueue, name, attrs)
+}
+
+// setAttrs sets the values that are not zero into attrs, so that the provider defaults are kept.
+func setAttrs(attrs map[string]interface{}, values map[string]interface{}) {
+	for k, v := range values {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			continue
+		}
+		if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.Len() == 0 {
+			continue
+		}
+		attrs[k] = v
+	}
+}
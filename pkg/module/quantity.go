@@ -0,0 +1,103 @@
+package module
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ParseQuantity parses the Kubernetes quantity at the dot separated key path of cfg, such as
+// ParseQuantity(req.DevModuleConfig, "resources.memory", "512Mi"). The default is parsed if the key
+// is not set. Numbers are accepted as plain quantities, e.g. cpu: 2. Unparsable values fail with
+// ErrCodeInvalidConfig hinting the likely intended quantity, e.g. "2Gi" for "2GB".
+func ParseQuantity(cfg map[string]any, key, def string) (resource.Quantity, error) {
+	v, ok := lookupConfig(cfg, key)
+	if !ok || v == nil || v == "" {
+		v = def
+	}
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = strings.TrimSpace(x)
+	case int, int32, int64, uint, uint32, uint64, float32, float64:
+		s = fmt.Sprint(x)
+	default:
+		return resource.Quantity{}, NewError(ErrCodeInvalidConfig, "invalid quantity %v of %s, expected a string such as \"512Mi\"", v, key)
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		e := NewError(ErrCodeInvalidConfig, "invalid quantity %q of %s: %v", s, key, err)
+		if hint := quantityHint(s); hint != "" {
+			return resource.Quantity{}, e.WithHint(fmt.Sprintf("did you mean %q? quantities use the suffixes Ki, Mi, Gi, Ti or k, M, G, T", hint))
+		}
+		return resource.Quantity{}, e.WithHint("quantities are numbers with an optional suffix such as 512Mi, 2Gi or 500m")
+	}
+	return q, nil
+}
+
+// ParseDuration parses the duration at the dot separated key path of cfg, such as
+// ParseDuration(req.PlatformModuleConfig, "healthCheck.timeout", "30s"). The default is parsed if the
+// key is not set. Integers are accepted as seconds.
+func ParseDuration(cfg map[string]any, key, def string) (time.Duration, error) {
+	v, ok := lookupConfig(cfg, key)
+	if !ok || v == nil || v == "" {
+		v = def
+	}
+	switch x := v.(type) {
+	case int:
+		return time.Duration(x) * time.Second, nil
+	case int64:
+		return time.Duration(x) * time.Second, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if n, err := strconv.Atoi(s); err == nil {
+			return time.Duration(n) * time.Second, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, NewError(ErrCodeInvalidConfig, "invalid duration %q of %s: %v", s, key, err).
+				WithHint("durations are numbers with a unit such as 30s, 5m or 1h30m")
+		}
+		return d, nil
+	}
+	return 0, NewError(ErrCodeInvalidConfig, "invalid duration %v of %s, expected a string such as \"30s\"", v, key)
+}
+
+var unitTypo = regexp.MustCompile(`^([0-9.]+)\s*([a-zA-Z]+)$`)
+
+// quantityTypos are common unit mistakes and the suffixes likely intended.
+var quantityTypos = map[string]string{
+	"kb": "Ki", "kib": "Ki", "mb": "Mi", "mib": "Mi", "gb": "Gi", "gib": "Gi", "tb": "Ti", "tib": "Ti",
+	"ki": "Ki", "mi": "Mi", "gi": "Gi", "ti": "Ti", "g": "G", "t": "T",
+}
+
+// quantityHint returns the quantity likely intended by the unparsable s, or empty if unknown.
+func quantityHint(s string) string {
+	m := unitTypo.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	if suffix, ok := quantityTypos[strings.ToLower(m[2])]; ok {
+		return m[1] + suffix
+	}
+	return ""
+}
+
+// lookupConfig returns the value at the dot separated key path of cfg.
+func lookupConfig(cfg map[string]any, key string) (any, bool) {
+	var cur any = cfg
+	for _, k := range strings.Split(key, ".") {
+		m, ok := asStringMap(cur)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,81 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Readier is an optional interface of FrameworkModule performing startup work, such as loading
+// provider schemas or warming caches. If implemented, Generate fails with ErrCodeUnavailable until
+// Ready returns nil, and the engine can wait for the module with WaitReady.
+//
+// The gRPC health service of the plugin is registered by go-plugin and reports serving as soon as
+// the plugin starts, so readiness is served by the Ready RPC of the framework service instead.
+type Readier interface {
+	Ready(ctx context.Context) error
+}
+
+// Ready reports whether the wrapped module is ready to generate. Once ready, the module is not checked again.
+func (f *FrameworkModuleWrapper) Ready(ctx context.Context) error {
+	if err := f.enterSandbox(); err != nil {
+		return err
+	}
+	r, ok := f.Module.(Readier)
+	if !ok || f.ready.Load() {
+		return nil
+	}
+	if err := r.Ready(ctx); err != nil {
+		return asModuleError(err, ErrCodeUnavailable, "module is not ready")
+	}
+	f.ready.Store(true)
+	return nil
+}
+
+// Ready calls the Ready hooks of all registered modules implementing Readier, so that the plugin is
+// ready once every module is. Modules which are ready are not checked again.
+func (r *Registry) Ready(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.Names() {
+		r.mu.RLock()
+		m, ready := r.modules[name], r.ready[name]
+		r.mu.RUnlock()
+		readier, ok := m.(Readier)
+		if !ok || ready {
+			continue
+		}
+		if err := readier.Ready(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("module %s is not ready: %w", name, err))
+			continue
+		}
+		r.mu.Lock()
+		r.ready[name] = true
+		r.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FrameworkModuleWrapper) readyRPC(ctx context.Context, _ []byte) ([]byte, error) {
+	return nil, f.Ready(ctx)
+}
+
+// WaitReady calls the Ready RPC of the module plugin served on conn every interval until the module
+// is ready or ctx is done.
+func WaitReady(ctx context.Context, conn grpc.ClientConnInterface, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := invokeFramework(ctx, conn, "Ready", nil)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for module ready failed: %w, last error: %v", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// RecordDirEnv is the environment variable enabling the debug mode of module plugins, in which every
+// incoming request is dumped into the directory as a Recording. Recordings may contain secrets in the
+// configs, share them with care.
+const RecordDirEnv = "KUSION_MODULE_RECORD"
+
+// RecordingSuffix is the file suffix of recordings.
+const RecordingSuffix = ".recording.json"
+
+// Recording is an incoming Generate request dumped in the debug mode, which can be replayed into a
+// local build of the module with Replay or the kusion-module replay command.
+type Recording struct {
+	// Time is the time the request was received
+	Time time.Time `json:"time"`
+	// Module is the name of the module plugin that received the request
+	Module string `json:"module,omitempty"`
+	// Metadata is the gRPC request metadata, such as the operation and the requested module
+	Metadata map[string][]string `json:"metadata,omitempty"`
+	// Request is the proto request
+	Request *proto.GeneratorRequest `json:"request"`
+}
+
+var (
+	recordSeq      atomic.Uint64
+	recordWarnOnce sync.Once
+)
+
+// record dumps req into the directory of RecordDirEnv if set. Failures are logged and never fail the request.
+func (f *FrameworkModuleWrapper) record(ctx context.Context, req *proto.GeneratorRequest) {
+	dir := os.Getenv(RecordDirEnv)
+	if dir == "" {
+		return
+	}
+	recordWarnOnce.Do(func() {
+		log.Warnf("recording module requests into %s, recordings may contain secrets", dir)
+	})
+	r := &Recording{Time: time.Now(), Module: f.Name, Request: req}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		r.Metadata = md
+	}
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		log.Errorf("marshal recording failed: %v", err)
+		return
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		log.Errorf("create recording dir failed: %v", err)
+		return
+	}
+	name := fmt.Sprintf("%d-%d%s", r.Time.UnixNano(), recordSeq.Add(1), RecordingSuffix)
+	if err = os.WriteFile(filepath.Join(dir, name), out, 0o600); err != nil {
+		log.Errorf("write recording failed: %v", err)
+	}
+}
+
+// LoadRecording reads a recording file.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read recording %s failed. %w", path, err)
+	}
+	r := &Recording{}
+	if err = json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("unmarshal recording %s failed. %w", path, err)
+	}
+	if r.Request == nil {
+		return nil, fmt.Errorf("recording %s has no request", path)
+	}
+	return r, nil
+}
+
+// Replay sends the recorded request with its metadata to the module plugin served on conn.
+func (r *Recording) Replay(ctx context.Context, conn grpc.ClientConnInterface, opts ...grpc.CallOption) (*proto.GeneratorResponse, error) {
+	md := metadata.MD{}
+	for k, v := range r.Metadata {
+		// the transport headers are set by the client itself
+		if k == ":authority" || k == "content-type" || k == "user-agent" || k == "grpc-accept-encoding" {
+			continue
+		}
+		md[k] = v
+	}
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Join(mdFromOutgoing(ctx), md))
+	return proto.NewModuleClient(conn).Generate(ctx, r.Request, opts...)
+}
+
+func mdFromOutgoing(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
@@ -0,0 +1,63 @@
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// CrashDirEnv is the environment variable setting the directory crash reports are written to, which
+// can also be set by WithCrashDir.
+const CrashDirEnv = "KUSION_MODULE_CRASH_DIR"
+
+// WithCrashDir writes a crash report with the stack trace into dir whenever the module panics.
+func WithCrashDir(dir string) ServeOption {
+	return func(o *serveOptions) {
+		o.crashDir = dir
+	}
+}
+
+// recoverPanic converts a panic of the module into an Error with the stack trace, so that the plugin
+// keeps serving and the engine receives the error instead of a broken connection. It must be deferred.
+func (f *FrameworkModuleWrapper) recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := string(debug.Stack())
+	name := f.Name
+	if name == "" {
+		name = "module"
+	}
+	e := NewError(ErrCodeInternal, "%s panicked: %v", name, r).
+		WithHint("this is a bug of the module, report it with the stack trace to the module maintainers")
+	e.Stack = stack
+	log.Errorf("%s\n%s", e.Message, stack)
+	f.writeCrashReport(e)
+	*err = e
+}
+
+// writeCrashReport writes the panic into the crash dir if configured.
+func (f *FrameworkModuleWrapper) writeCrashReport(e *Error) {
+	dir := f.CrashDir
+	if dir == "" {
+		dir = os.Getenv(CrashDirEnv)
+	}
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Errorf("create crash dir failed: %v", err)
+		return
+	}
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.log", now.Format("20060102T150405"), now.Nanosecond()))
+	report := fmt.Sprintf("time: %s\nmodule: %s\nerror: %s\n\n%s", now.Format(time.RFC3339), f.Name, e.Message, e.Stack)
+	if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+		log.Errorf("write crash report failed: %v", err)
+	}
+}
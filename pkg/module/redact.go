@@ -0,0 +1,208 @@
+package module
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/hashicorp/go-hclog"
+	"gopkg.in/yaml.v2"
+	"kusionstack.io/kusion/pkg/log"
+
+	"kusionstack.io/kusion-module-framework/pkg/internal/yamlfield"
+)
+
+// Redacted replaces the redacted values in logs.
+const Redacted = "<redacted>"
+
+// DefaultSensitiveKeys match the config keys whose values are redacted from logs, case-insensitively.
+var DefaultSensitiveKeys = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)passw(or)?d`),
+	regexp.MustCompile(`(?i)secret`),
+	regexp.MustCompile(`(?i)token`),
+	regexp.MustCompile(`(?i)(api|access|private|secret)[_-]?key`),
+	regexp.MustCompile(`(?i)credential`),
+	regexp.MustCompile(`(?i)connection[_-]?string`),
+}
+
+// WithRedactKeys redacts the values of the config keys matching the regular expressions from the request
+// logs, in addition to DefaultSensitiveKeys. It panics if a pattern is invalid. The module configs are
+// logged before they are decoded into the config structs of the module, so key patterns are the only
+// protection of request logs, and `sensitive:"true"` tags of config structs do not apply to them.
+func WithRedactKeys(patterns ...string) ServeOption {
+	return func(o *serveOptions) {
+		for _, p := range patterns {
+			o.redactKeys = append(o.redactKeys, regexp.MustCompile(p))
+		}
+	}
+}
+
+// WithRequestLogLevel sets the level the decoded, redacted requests are logged at. Defaults to
+// hclog.Info, and hclog.Off disables the request logs.
+func WithRequestLogLevel(level hclog.Level) ServeOption {
+	return func(o *serveOptions) {
+		o.requestLogLevel = level
+	}
+}
+
+// Redact returns a copy of v safe to log, with the values of map keys and struct fields matching
+// DefaultSensitiveKeys and of struct fields tagged `sensitive:"true"` replaced by Redacted. Structs are
+// converted into maps keyed by their yaml names, e.g.
+//
+//	type Config struct {
+//		Username string `yaml:"username"`
+//		Password string `yaml:"password"`
+//		DSN      string `yaml:"dsn" sensitive:"true"`
+//	}
+//
+// Call Redact on the decoded config to log it with its tags, e.g. log.Infof("config: %v", module.Redact(cfg)).
+func Redact(v any) any {
+	return redactValue(reflect.ValueOf(v), DefaultSensitiveKeys)
+}
+
+// redactValue redacts v with the sensitive key patterns.
+func redactValue(v reflect.Value, keys []*regexp.Regexp) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface && v.CanInterface() {
+		if m, ok := v.Interface().(yaml.Marshaler); ok {
+			out, err := m.MarshalYAML()
+			if err != nil {
+				return Redacted
+			}
+			return redactValue(reflect.ValueOf(out), keys)
+		}
+		if ms, ok := v.Interface().(yaml.MapSlice); ok {
+			out := make(yaml.MapSlice, len(ms))
+			for i, item := range ms {
+				out[i].Key = item.Key
+				if sensitiveKey(fmt.Sprint(item.Key), keys) && item.Value != nil {
+					out[i].Value = Redacted
+				} else {
+					out[i].Value = redactValue(reflect.ValueOf(item.Value), keys)
+				}
+			}
+			return out
+		}
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), keys)
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if sensitiveKey(k, keys) && !iter.Value().IsZero() {
+				out[k] = Redacted
+			} else {
+				out[k] = redactValue(iter.Value(), keys)
+			}
+		}
+		return out
+	case reflect.Struct:
+		out := map[string]any{}
+		redactStruct(v, keys, out)
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("<%d bytes>", v.Len())
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i), keys)
+		}
+		return out
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// redactStruct adds the exported fields of the struct v to out, keyed by their yaml names.
+func redactStruct(v reflect.Value, keys []*regexp.Regexp, out map[string]any) {
+	_ = yamlfield.Walk(v.Type(), func(field yamlfield.Field) error {
+		fv, err := v.FieldByIndexErr(field.Index)
+		if err != nil {
+			// a field of a nil inlined struct pointer
+			return nil
+		}
+		if field.YAML.Inline {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Map {
+				for k, val := range redactValue(fv, keys).(map[string]any) {
+					out[k] = val
+				}
+			}
+			return nil
+		}
+		if field.YAML.OmitEmpty && fv.IsZero() {
+			return nil
+		}
+		if (field.Tag.Get("sensitive") == "true" || sensitiveKey(field.YAML.Name, keys)) && !fv.IsZero() {
+			out[field.YAML.Name] = Redacted
+			return nil
+		}
+		out[field.YAML.Name] = redactValue(fv, keys)
+		return nil
+	})
+}
+
+func sensitiveKey(key string, keys []*regexp.Regexp) bool {
+	for _, re := range keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// logRequest logs the redacted request at level.
+func logRequest(req *GeneratorRequest, level hclog.Level, keys []*regexp.Regexp) {
+	if level == hclog.Off {
+		return
+	}
+	out, err := requestLog(req, keys)
+	if err != nil {
+		log.Errorf("marshal new generator request failed: %v", err)
+		return
+	}
+	switch level {
+	case hclog.Trace, hclog.Debug:
+		log.Debugf("new generator request:%s", out)
+	case hclog.Warn:
+		log.Warnf("new generator request:%s", out)
+	case hclog.Error:
+		log.Errorf("new generator request:%s", out)
+	default:
+		log.Infof("new generator request:%s", out)
+	}
+}
+
+// requestLog returns the redacted request logged by logRequest. The configs are maps, so only the
+// key patterns apply to them.
+func requestLog(req *GeneratorRequest, keys []*regexp.Regexp) (string, error) {
+	out, err := yaml.Marshal(redactValue(reflect.ValueOf(req), keys))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// sensitiveKeys returns the sensitive key patterns of the wrapper.
+func (f *FrameworkModuleWrapper) sensitiveKeys() []*regexp.Regexp {
+	if len(f.RedactKeys) == 0 {
+		return DefaultSensitiveKeys
+	}
+	return append(append([]*regexp.Regexp{}, DefaultSensitiveKeys...), f.RedactKeys...)
+}
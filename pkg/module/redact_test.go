@@ -0,0 +1,58 @@
+package module
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+func TestRequestLog(t *testing.T) {
+	req := &GeneratorRequest{
+		Project: "demo",
+		DevModuleConfig: v1.Accessory{
+			"database": map[string]any{
+				"user":     "admin",
+				"password": "hunter2",
+				"replicas": []any{map[string]any{"host": "db-0", "dbPassword": "s3cr3t"}},
+			},
+		},
+		PlatformModuleConfig: v1.GenericConfig{
+			"vault": map[string]any{"addr": "https://vault", "licenseId": "L-42"},
+		},
+	}
+	out, err := requestLog(req, append(append([]*regexp.Regexp{}, DefaultSensitiveKeys...), regexp.MustCompile(`(?i)license`)))
+	if err != nil {
+		t.Fatalf("requestLog() error = %v", err)
+	}
+	for _, secret := range []string{"hunter2", "s3cr3t", "L-42"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("requestLog() = %s, leaks %s", out, secret)
+		}
+	}
+	for _, want := range []string{"password: " + Redacted, "dbPassword: " + Redacted, "user: admin", "host: db-0", "project: demo"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("requestLog() = %s, want %s", out, want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	type config struct {
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		DSN      string `yaml:"dsn" sensitive:"true"`
+		Empty    string `yaml:"empty" sensitive:"true"`
+	}
+	got, ok := Redact(&config{Username: "admin", Password: "hunter2", DSN: "postgres://u:p@db"}).(map[string]any)
+	if !ok {
+		t.Fatalf("Redact() = %T, want a map", got)
+	}
+	want := map[string]any{"username": "admin", "password": Redacted, "dsn": Redacted, "empty": ""}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Redact()[%s] = %v, want %v", k, got[k], v)
+		}
+	}
+}
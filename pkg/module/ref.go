@@ -0,0 +1,83 @@
+package module
+
+import (
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// ImplicitRefPrefix is the prefix of the implicit references between resources, which the engine
+// replaces with the attribute of the referenced resource once it is applied.
+const ImplicitRefPrefix = "$kusion_path."
+
+// RefAttr returns the placeholder of the attribute at attrPath, e.g. address or status.loadBalancer,
+// of the resource resID. The placeholder must be a whole attribute value of the referencing resource.
+func RefAttr(resID, attrPath string) string {
+	return ImplicitRefPrefix + resID + "." + strings.TrimPrefix(attrPath, ".")
+}
+
+// Ref returns the placeholder of the attribute at attrPath of target, and declares that from depends on
+// target, so that target is applied first.
+func Ref(from, target *v1.Resource, attrPath string) string {
+	DependsOn(from, target.ID)
+	return RefAttr(target.ID, attrPath)
+}
+
+// AddRefDependencies declares the dependencies of the implicit references between the resources of the
+// response. References to resources not in the response, e.g. of other modules, are left to the engine.
+// The wrapper calls it on every response before marshaling.
+func (r *GeneratorResponse) AddRefDependencies() {
+	if len(r.Resources) < 2 {
+		return
+	}
+	// longer IDs first, so that an ID prefixed by another ID is matched as a whole
+	ids := make([]string, 0, len(r.Resources))
+	for _, res := range r.Resources {
+		ids = append(ids, res.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+	for i := range r.Resources {
+		res := &r.Resources[i]
+		walkStrings(res.Attributes, func(s string) {
+			ref, ok := strings.CutPrefix(s, ImplicitRefPrefix)
+			if !ok {
+				return
+			}
+			for _, id := range ids {
+				if strings.HasPrefix(ref, id+".") {
+					DependsOn(res, id)
+					return
+				}
+			}
+		})
+	}
+}
+
+// walkStrings calls fn with every string in the decoded value v.
+func walkStrings(v interface{}, fn func(string)) {
+	switch val := v.(type) {
+	case string:
+		fn(val)
+	case map[string]interface{}:
+		for _, item := range val {
+			walkStrings(item, fn)
+		}
+	case map[interface{}]interface{}:
+		for _, item := range val {
+			walkStrings(item, fn)
+		}
+	case []interface{}:
+		for _, item := range val {
+			walkStrings(item, fn)
+		}
+	case map[string]string:
+		for _, item := range val {
+			fn(item)
+		}
+	case []string:
+		for _, item := range val {
+			fn(item)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ModuleNameMetadataKey is the gRPC request header carrying the name of the requested module.
+const ModuleNameMetadataKey = "kusion-module-name"
+
+// Registry bundles several FrameworkModules into one plugin binary and routes requests by
+// the module name carried in the request. Registry itself is a FrameworkModule, forwarding the
+// optional interfaces to the routed module, or to all modules for Ready, Reload and Cleanup.
+type Registry struct {
+	mu      sync.RWMutex
+	modules map[string]FrameworkModule
+	// ready are the names of the modules whose Ready hook succeeded
+	ready map[string]bool
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{modules: map[string]FrameworkModule{}, ready: map[string]bool{}}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register registers m as name in the default registry served by ServeRegistered.
+// It panics if name is empty or already registered.
+func Register(name string, m FrameworkModule) {
+	if err := defaultRegistry.Register(name, m); err != nil {
+		panic(err)
+	}
+}
+
+// ServeRegistered serves all modules registered by Register in one plugin.
+func ServeRegistered(opts ...ServeOption) {
+	Serve(defaultRegistry, opts...)
+}
+
+// ContextWithModuleName returns a copy of ctx requesting the module of name, used by hosts of
+// multi-module plugins.
+func ContextWithModuleName(ctx context.Context, name string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ModuleNameMetadataKey, name)
+}
+
+// Register registers m as name.
+func (r *Registry) Register(name string, m FrameworkModule) error {
+	if name == "" || m == nil {
+		return fmt.Errorf("module name and module must not be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.modules[name]; ok {
+		return fmt.Errorf("module %s is already registered", name)
+	}
+	r.modules[name] = m
+	return nil
+}
+
+// Names returns the sorted names of the registered modules.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.modules))
+	for name := range r.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the module requested by req. If req names no module and only one module is
+// registered, that module is returned.
+func (r *Registry) Lookup(req *GeneratorRequest) (FrameworkModule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if req.Module == "" {
+		if len(r.modules) == 1 {
+			for _, m := range r.modules {
+				return m, nil
+			}
+		}
+		return nil, NewError(ErrCodeInvalidRequest, "module name is missing in the request").
+			WithHint(fmt.Sprintf("set the %s metadata to one of the registered modules", ModuleNameMetadataKey))
+	}
+	m, ok := r.modules[req.Module]
+	if !ok {
+		return nil, NewError(ErrCodeInvalidRequest, "module %s is not registered in this plugin", req.Module)
+	}
+	return m, nil
+}
+
+// Generate routes the request to the requested module.
+func (r *Registry) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	m, err := r.Lookup(req)
+	if err != nil {
+		return nil, err
+	}
+	return m.Generate(ctx, req)
+}
+
+// Validate routes the request to the requested module if it implements Validator.
+func (r *Registry) Validate(ctx context.Context, req *GeneratorRequest) error {
+	m, err := r.Lookup(req)
+	if err != nil {
+		return err
+	}
+	if v, ok := m.(Validator); ok {
+		return v.Validate(ctx, req)
+	}
+	return nil
+}
+
+// Cleanup calls the Cleanup hooks of all registered modules implementing Cleaner.
+func (r *Registry) Cleanup(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var errs []error
+	for name, m := range r.modules {
+		if c, ok := m.(Cleaner); ok {
+			if err := c.Cleanup(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("cleanup module %s failed: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
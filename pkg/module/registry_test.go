@@ -0,0 +1,72 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// hookModule implements the optional interfaces forwarded by Registry.
+type hookModule struct {
+	name     string
+	notReady error
+	readies  int
+	reloaded EnvironmentDefaults
+}
+
+func (m *hookModule) Generate(context.Context, *GeneratorRequest) (*GeneratorResponse, error) {
+	return &GeneratorResponse{}, nil
+}
+
+func (m *hookModule) Ready(context.Context) error {
+	m.readies++
+	return m.notReady
+}
+
+func (m *hookModule) Reload(_ context.Context, defaults EnvironmentDefaults) error {
+	m.reloaded = defaults
+	return nil
+}
+
+func (m *hookModule) EstimateCost(_ context.Context, resources []v1.Resource) ([]CostEstimate, error) {
+	return []CostEstimate{{ResourceID: m.name, MonthlyCost: float64(len(resources))}}, nil
+}
+
+func TestRegistryHooks(t *testing.T) {
+	db, cache := &hookModule{name: "db"}, &hookModule{name: "cache", notReady: errors.New("warming up")}
+	r := NewRegistry()
+	if err := r.Register("db", db); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("cache", cache); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Ready(context.Background()); err == nil {
+		t.Fatalf("Ready() succeeded while module cache is not ready")
+	}
+	cache.notReady = nil
+	if err := r.Ready(context.Background()); err != nil {
+		t.Fatalf("Ready() error = %v", err)
+	}
+	if db.readies != 1 || cache.readies != 2 {
+		t.Errorf("Ready hooks called %d and %d times, want ready modules not checked again", db.readies, cache.readies)
+	}
+
+	defaults := EnvironmentDefaults{"prod": {"replicas": 3}}
+	if err := r.Reload(context.Background(), defaults); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if db.reloaded == nil || cache.reloaded == nil {
+		t.Errorf("Reload() did not reload all modules")
+	}
+
+	w := &FrameworkModuleWrapper{Module: r}
+	resp := &GeneratorResponse{Resources: testResources(2)}
+	w.estimateCost(context.Background(), &GeneratorRequest{Module: "cache"}, resp)
+	if len(resp.Costs) != 1 || resp.Costs[0].ResourceID != "cache" || resp.Costs[0].MonthlyCost != 2 {
+		t.Errorf("Costs = %+v, want the estimates of module cache", resp.Costs)
+	}
+}
@@ -0,0 +1,148 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// DefaultReloadInterval is the interval watched files are checked for changes at.
+const DefaultReloadInterval = 10 * time.Second
+
+// Reloader is an optional interface of FrameworkModule. If implemented, Reload is called with the new
+// defaults whenever the defaults file of WithDefaultsFile changes, so that the module recomputes the state
+// derived from them. No Generate call runs during Reload, and the defaults are kept if it fails.
+type Reloader interface {
+	Reload(ctx context.Context, defaults EnvironmentDefaults) error
+}
+
+// WithDefaultsFile loads the EnvironmentDefaults from the YAML or JSON file at path, e.g. a mounted
+// ConfigMap, and reloads them when the content of the file changes. Reloads happen between Generate
+// calls, so that every call sees either the old or the new defaults, and are retried while calls that
+// outlived their deadline still run. It overrides WithEnvironmentDefaults.
+func WithDefaultsFile(path string, interval time.Duration) ServeOption {
+	return func(o *serveOptions) {
+		o.defaultsFile = path
+		o.reloadInterval = interval
+	}
+}
+
+// watchDefaults loads the defaults file into the wrapper and watches it, returning the function stopping
+// the watch. It does nothing if no defaults file is configured.
+func (o *serveOptions) watchDefaults(f *FrameworkModuleWrapper) (stop func(), err error) {
+	if o.defaultsFile == "" {
+		return func() {}, nil
+	}
+	stop, err = WatchFile(context.Background(), o.defaultsFile, o.reloadInterval, func(data []byte) error {
+		var defaults EnvironmentDefaults
+		if err := yaml.Unmarshal(data, &defaults); err != nil {
+			return fmt.Errorf("unmarshal defaults file failed. %w", err)
+		}
+		return f.reloadDefaults(context.Background(), defaults)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load defaults file %s failed. %w", o.defaultsFile, err)
+	}
+	return stop, nil
+}
+
+// reloadDefaults replaces the environment defaults of the wrapper once no Generate call is running,
+// calling the Reloader of the module first. Calls of the module that outlived their deadline are waited
+// for up to the module timeout, or DefaultReloadInterval without one, while new calls are held back, and
+// the reload fails if they still run then, so that a module that never returns can not stall every
+// later call. The watch of WithDefaultsFile retries failed reloads at the next check.
+func (f *FrameworkModuleWrapper) reloadDefaults(ctx context.Context, defaults EnvironmentDefaults) error {
+	f.reloadMu.Lock()
+	defer f.reloadMu.Unlock()
+	wait := f.Timeout
+	if wait <= 0 {
+		wait = DefaultReloadInterval
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	if err := f.running.wait(waitCtx); err != nil {
+		return fmt.Errorf("wait for the running Generate calls of the module failed. %w", err)
+	}
+	if err := f.enterSandbox(); err != nil {
+		return err
+	}
+	if r, ok := f.Module.(Reloader); ok {
+		if err := r.Reload(ctx, defaults); err != nil {
+			return fmt.Errorf("reload module failed. %w", err)
+		}
+	}
+	f.EnvironmentDefaults = defaults
+	return nil
+}
+
+// Reload calls the Reload hooks of all registered modules implementing Reloader. Every module is
+// reloaded even if others fail, and the errors are joined.
+func (r *Registry) Reload(ctx context.Context, defaults EnvironmentDefaults) error {
+	var errs []error
+	for _, name := range r.Names() {
+		r.mu.RLock()
+		m := r.modules[name]
+		r.mu.RUnlock()
+		if reloader, ok := m.(Reloader); ok {
+			if err := reloader.Reload(ctx, defaults); err != nil {
+				errs = append(errs, fmt.Errorf("reload module %s failed: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WatchFile calls onChange with the content of the file at path, and again whenever the content changes,
+// checking the file every interval, or DefaultReloadInterval if not positive. The first call happens
+// before WatchFile returns, and its error is returned. Errors of later reads and calls are logged and
+// retried at the next check. The files of ConfigMaps mounted as volumes, which are replaced by symlink
+// swaps, are supported. Call stop or cancel ctx to stop watching.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func(data []byte) error) (stop func(), err error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = onChange(data); err != nil {
+		return nil, err
+	}
+	last := sha256.Sum256(data)
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Errorf("read watched file %s failed: %v", path, err)
+				continue
+			}
+			sum := sha256.Sum256(data)
+			if bytes.Equal(sum[:], last[:]) {
+				continue
+			}
+			if err = onChange(data); err != nil {
+				log.Errorf("reload watched file %s failed: %v", path, err)
+				continue
+			}
+			last = sum
+			log.Infof("reloaded watched file %s", path)
+		}
+	}()
+	return cancel, nil
+}
@@ -0,0 +1,310 @@
+package module
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// RemoteAuthTokenEnv is the environment variable of the bearer token of remote modules, read when the
+// module is served without WithAuthToken.
+const RemoteAuthTokenEnv = "KUSION_MODULE_AUTH_TOKEN"
+
+// WithAuthToken requires the clients of a module served by ServeRemote to send token as the bearer
+// token of the authorization metadata. The health service is not authenticated.
+func WithAuthToken(token string) ServeOption {
+	return func(o *serveOptions) {
+		o.authToken = token
+	}
+}
+
+// WithInsecureRemote allows ServeRemote to serve the module without authenticating its clients, e.g. behind
+// a proxy or service mesh authenticating them. Any client reaching the address can call the module then.
+func WithInsecureRemote() ServeOption {
+	return func(o *serveOptions) {
+		o.insecureRemote = true
+	}
+}
+
+// ServeRemote serves the FrameworkModule as a long-lived gRPC service on addr instead of a plugin
+// subprocess of the engine, so that a centrally hosted module serves many Kusion clients, which resolve
+// it with a RemoteResolver. The module and framework services are served with the gRPC health service,
+// and ServeRemote blocks until the listener fails or the process receives SIGINT or SIGTERM, after which
+// the in-flight calls are finished.
+//
+// The module must authenticate its clients with WithAuthToken or mutual TLS, see WithTLS, and ServeRemote
+// fails otherwise unless WithInsecureRemote is given. Bearer tokens are only accepted over TLS, or in
+// plain text on loopback addresses, so that they are never sent in the clear over the network.
+func ServeRemote(m FrameworkModule, addr string, opts ...ServeOption) error {
+	o := newServeOptions(opts)
+	if o.authToken == "" {
+		o.authToken = os.Getenv(RemoteAuthTokenEnv)
+	}
+	if err := o.checkRemoteSecurity(addr); err != nil {
+		return err
+	}
+	wrapper := o.wrapper(m)
+	stopReload, err := o.watchDefaults(wrapper)
+	if err != nil {
+		return err
+	}
+	defer stopReload()
+	defer func() {
+		if err := wrapper.Cleanup(context.Background()); err != nil {
+			log.Errorf("cleanup module failed: %v", err)
+		}
+	}()
+	defer o.startTelemetry()()
+	defer o.startHTTP(wrapper)()
+
+	var serverOpts []grpc.ServerOption
+	if provider := o.tlsProvider(); provider != nil {
+		var tlsConfig *tls.Config
+		tlsConfig, err = provider()
+		if err != nil {
+			return fmt.Errorf("load module TLS config failed. %w", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if o.authToken != "" {
+		o.unaryInterceptors = append([]grpc.UnaryServerInterceptor{authUnaryInterceptor(o.authToken)}, o.unaryInterceptors...)
+		o.streamInterceptors = append([]grpc.StreamServerInterceptor{authStreamInterceptor(o.authToken)}, o.streamInterceptors...)
+	}
+	s := o.grpcServer()(serverOpts)
+	RegisterServices(s, wrapper)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s failed. %w", addr, err)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		if _, ok := <-signals; ok {
+			healthServer.Shutdown()
+			s.GracefulStop()
+		}
+	}()
+
+	log.Infof("module %s serving on %s", o.name, lis.Addr())
+	if err = s.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("serve module failed. %w", err)
+	}
+	return nil
+}
+
+// checkRemoteSecurity checks that the clients of a module served on addr are authenticated, and that
+// bearer tokens are not sent in plain text over the network.
+func (o *serveOptions) checkRemoteSecurity(addr string) error {
+	tlsConfig := o.tls
+	if tlsConfig == nil {
+		tlsConfig = TLSConfigFromEnv()
+	}
+	mutualTLS := tlsConfig != nil && tlsConfig.CAFile != ""
+	switch {
+	case o.authToken == "" && !mutualTLS && !o.insecureRemote:
+		return fmt.Errorf("module served on %s does not authenticate its clients, set WithAuthToken, %s or the CA file "+
+			"of WithTLS, or allow it with WithInsecureRemote", addr, RemoteAuthTokenEnv)
+	case o.authToken != "" && tlsConfig == nil && !isLoopback(addr):
+		return fmt.Errorf("module served on %s accepts the auth token in plain text, serve it over TLS with WithTLS "+
+			"or on a loopback address", addr)
+	}
+	return nil
+}
+
+// isLoopback reports whether the host of addr is a loopback address. An empty host listens on all interfaces.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func authUnaryInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authenticate(ctx, info.FullMethod, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authenticate(ss.Context(), info.FullMethod, token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authenticate checks the bearer token in the metadata of calls to method.
+func authenticate(ctx context.Context, method, token string) error {
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if got, ok := strings.CutPrefix(v, "Bearer "); ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid module auth token")
+}
+
+// RemoteResolver resolves modules served by ServeRemote at registered addresses. Connections are pooled
+// per address and shared by all calls, and Generate calls failing with transient errors, e.g. while
+// the service restarts, are retried with the Retry policy.
+type RemoteResolver struct {
+	// TLS is the client TLS configuration of the modules, which are called in plain text if nil
+	TLS *TLSConfig
+	// Token is the bearer token sent to the modules, see WithAuthToken
+	Token string
+	// Retry is the retry policy of Generate calls, DefaultRetryPolicy is used for zero fields
+	Retry RetryPolicy
+	// Compression enables gzip compression of the requests and responses of the modules
+	Compression bool
+
+	mu        sync.Mutex
+	addresses map[string]string
+	conns     map[string]*grpc.ClientConn
+}
+
+// NewRemoteResolver returns a RemoteResolver of the modules at addresses, a map of module names to
+// host:port addresses.
+func NewRemoteResolver(addresses map[string]string) *RemoteResolver {
+	r := &RemoteResolver{}
+	for name, addr := range addresses {
+		r.Register(name, addr)
+	}
+	return r
+}
+
+// Register registers the address of the module name, replacing the previous one.
+func (r *RemoteResolver) Register(name, addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.addresses == nil {
+		r.addresses = map[string]string{}
+	}
+	r.addresses[name] = addr
+}
+
+// Resolve implements Resolver.
+func (r *RemoteResolver) Resolve(ctx context.Context, name string) (FrameworkModule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	addr, ok := r.addresses[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	conn, ok := r.conns[addr]
+	if !ok {
+		var err error
+		if conn, err = r.dial(ctx, addr); err != nil {
+			return nil, err
+		}
+		if r.conns == nil {
+			r.conns = map[string]*grpc.ClientConn{}
+		}
+		r.conns[addr] = conn
+	}
+	m := &pluginModule{conn: conn}
+	if r.Compression {
+		m.opts = append(m.opts, CompressedCall())
+	}
+	return &retryModule{pluginModule: m, policy: r.Retry}, nil
+}
+
+func (r *RemoteResolver) dial(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if r.TLS != nil {
+		tlsConfig, err := r.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if r.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: r.Token, secure: r.TLS != nil}))
+	}
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect to module at %s failed. %w", addr, err)
+	}
+	return conn, nil
+}
+
+// Close closes the pooled connections.
+func (r *RemoteResolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for addr, conn := range r.conns {
+		errs = append(errs, conn.Close())
+		delete(r.conns, addr)
+	}
+	return errors.Join(errs...)
+}
+
+// Cleanup implements Cleaner by closing the pooled connections.
+func (r *RemoteResolver) Cleanup(_ context.Context) error {
+	return r.Close()
+}
+
+// retryModule retries the Generate calls of a remote module failing with transient errors, which are the
+// gRPC errors classified by ClassifyError and the module errors of unavailable or timed out modules.
+type retryModule struct {
+	*pluginModule
+	policy RetryPolicy
+}
+
+func (m *retryModule) Generate(ctx context.Context, req *GeneratorRequest) (resp *GeneratorResponse, err error) {
+	err = Retry(ctx, m.policy, func(ctx context.Context) error {
+		resp, err = m.pluginModule.Generate(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// tokenCredentials sends the bearer token of remote modules.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,34 @@
+package module
+
+import "testing"
+
+func TestCheckRemoteSecurity(t *testing.T) {
+	for _, env := range []string{RemoteAuthTokenEnv, TLSCertFileEnv, TLSKeyFileEnv, TLSCAFileEnv} {
+		t.Setenv(env, "")
+	}
+	serverTLS := WithTLS(TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"})
+	tests := []struct {
+		name    string
+		addr    string
+		opts    []ServeOption
+		wantErr bool
+	}{
+		{name: "no auth", addr: ":8080", wantErr: true},
+		{name: "no auth with tls", addr: ":8080", opts: []ServeOption{serverTLS}, wantErr: true},
+		{name: "insecure", addr: ":8080", opts: []ServeOption{WithInsecureRemote()}},
+		{name: "token in plain text", addr: ":8080", opts: []ServeOption{WithAuthToken("t")}, wantErr: true},
+		{name: "token in plain text with insecure", addr: "10.0.0.1:8080", opts: []ServeOption{WithAuthToken("t"), WithInsecureRemote()}, wantErr: true},
+		{name: "token on loopback", addr: "127.0.0.1:8080", opts: []ServeOption{WithAuthToken("t")}},
+		{name: "token on localhost", addr: "localhost:8080", opts: []ServeOption{WithAuthToken("t")}},
+		{name: "token over tls", addr: ":8080", opts: []ServeOption{WithAuthToken("t"), serverTLS}},
+		{name: "mutual tls", addr: ":8080", opts: []ServeOption{WithTLS(TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", CAFile: "ca.crt"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newServeOptions(tt.opts)
+			if err := o.checkRemoteSecurity(tt.addr); (err != nil) != tt.wantErr {
+				t.Errorf("checkRemoteSecurity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// KubernetesResourceIDFromGVK returns the unique ID of a Kubernetes resource based on its
+// GroupVersionKind, namespace and name. The namespace is empty for cluster-scoped resources.
+func KubernetesResourceIDFromGVK(gvk schema.GroupVersionKind, namespace, name string) string {
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return kubernetesResourceID(apiVersion, kind, namespace, name)
+}
+
+func kubernetesResourceID(apiVersion, kind, namespace, name string) string {
+	// resource id example: apps/v1:Deployment:nginx:nginx-deployment
+	id := apiVersion + ":" + kind + ":"
+	if namespace != "" {
+		id += namespace + ":"
+	}
+	return id + name
+}
+
+// TerraformResourceID returns the unique ID of a Terraform resource based on its provider source
+// in the form of [host/]namespace/name, resource type and resource name.
+func TerraformResourceID(provider, resourceType, name string) (string, error) {
+	c := &TFProviderConfig{Source: provider}
+	if _, _, _, err := c.parseSource(); err != nil {
+		return "", err
+	}
+	id := c.ResourceID(resourceType, name)
+	if _, err := ParseTerraformResourceID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// KubernetesResourceIDParts are the parts of a Kubernetes resource ID.
+type KubernetesResourceIDParts struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// ParseKubernetesResourceID parses an ID in the form of apiVersion:kind:[namespace:]name.
+func ParseKubernetesResourceID(id string) (*KubernetesResourceIDParts, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, fmt.Errorf("invalid kubernetes resource id %q, expected apiVersion:kind:[namespace:]name", id)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid kubernetes resource id %q, parts must not be empty", id)
+		}
+	}
+	gv, err := schema.ParseGroupVersion(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion of kubernetes resource id %q: %w", id, err)
+	}
+	result := &KubernetesResourceIDParts{GVK: gv.WithKind(parts[1]), Name: parts[len(parts)-1]}
+	if len(parts) == 4 {
+		result.Namespace = parts[2]
+	}
+	return result, nil
+}
+
+// TerraformResourceIDParts are the parts of a Terraform resource ID.
+type TerraformResourceIDParts struct {
+	ProviderNamespace string
+	ProviderName      string
+	ResourceType      string
+	Name              string
+}
+
+// ParseTerraformResourceID parses an ID in the form of providerNamespace:providerName:resourceType:name.
+func ParseTerraformResourceID(id string) (*TerraformResourceIDParts, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid terraform resource id %q, expected providerNamespace:providerName:resourceType:name", id)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid terraform resource id %q, parts must not be empty", id)
+		}
+	}
+	return &TerraformResourceIDParts{
+		ProviderNamespace: parts[0],
+		ProviderName:      parts[1],
+		ResourceType:      parts[2],
+		Name:              parts[3],
+	}, nil
+}
+
+// ValidateResourceID checks whether id is a well-formed ID of a resource of resourceType.
+func ValidateResourceID(resourceType v1.Type, id string) error {
+	var err error
+	switch resourceType {
+	case v1.Kubernetes:
+		_, err = ParseKubernetesResourceID(id)
+	case v1.Terraform:
+		_, err = ParseTerraformResourceID(id)
+	default:
+		if id == "" {
+			err = fmt.Errorf("resource id must not be empty")
+		}
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// validateResources checks every resource generated by the module has a well-formed and unique ID,
+// reporting all conflicts at once with the module name.
+func validateResources(module string, resources []v1.Resource) error {
+	var problems []string
+	seen := make(map[string]int, len(resources))
+	for i, res := range resources {
+		if err := ValidateResourceID(res.Type, res.ID); err != nil {
+			problems = append(problems, fmt.Sprintf("resource %d (%s): %v", i, res.Type, err))
+			continue
+		}
+		if first, ok := seen[res.ID]; ok {
+			problems = append(problems, fmt.Sprintf("resources %d and %d have the same id %s", first, i, res.ID))
+			continue
+		}
+		seen[res.ID] = i
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	subject := "the module"
+	if module != "" {
+		subject = "module " + module
+	}
+	return NewError(ErrCodeInternal, "%s generated invalid resources: %s", subject, strings.Join(problems, "; ")).
+		WithHint("make the names of generated resources unique, e.g. by including the app name")
+}
+
+// Append appends resources to the response. It fails without appending any resource if an ID is
+// empty or already in the response, which the engine would otherwise reject with a less clear error.
+func (r *GeneratorResponse) Append(resources ...v1.Resource) error {
+	ids := make(map[string]bool, len(r.Resources)+len(resources))
+	for _, res := range r.Resources {
+		ids[res.ID] = true
+	}
+	for _, res := range resources {
+		if res.ID == "" {
+			return fmt.Errorf("id of the %s resource must not be empty", res.Type)
+		}
+		if ids[res.ID] {
+			return fmt.Errorf("duplicated resource %s in the response", res.ID)
+		}
+		ids[res.ID] = true
+	}
+	r.Resources = append(r.Resources, resources...)
+	return nil
+}
+
+// AppendKube wraps the Kubernetes object, typed or unstructured, into a resource and appends it.
+// The apiVersion and kind of the object must be set.
+func (r *GeneratorResponse) AppendKube(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Errorf("access kubernetes object metadata failed. %w", err)
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Version == "" || gvk.Kind == "" {
+		return fmt.Errorf("apiVersion and kind of kubernetes object %s must be set", accessor.GetName())
+	}
+	res, err := WrapK8sResourceToKusionResource(KubernetesResourceIDFromGVK(gvk, accessor.GetNamespace(), accessor.GetName()), obj)
+	if err != nil {
+		return err
+	}
+	return r.Append(*res)
+}
+
+// AppendTF wraps the Terraform resource of provider into a resource and appends it.
+func (r *GeneratorResponse) AppendTF(provider *TFProviderConfig, resourceType, name string, attrs map[string]any) error {
+	res, err := provider.WrapResource(resourceType, name, attrs)
+	if err != nil {
+		return err
+	}
+	return r.Append(res)
+}
+
+// Merge appends the resources, patches, cost estimates and outputs of responses from sub-generators. It fails
+// without changing the response if resource IDs or output names collide.
+func (r *GeneratorResponse) Merge(responses ...*GeneratorResponse) error {
+	var resources []v1.Resource
+	for _, other := range responses {
+		if other != nil {
+			resources = append(resources, other.Resources...)
+		}
+	}
+	outputs := map[string]OutputValue{}
+	for name, o := range r.Outputs {
+		outputs[name] = o
+	}
+	for _, other := range responses {
+		if other == nil {
+			continue
+		}
+		for name, o := range other.Outputs {
+			if _, ok := outputs[name]; ok {
+				return fmt.Errorf("merge responses failed. duplicated output %s", name)
+			}
+			outputs[name] = o
+		}
+	}
+	if err := r.Append(resources...); err != nil {
+		return fmt.Errorf("merge responses failed. %w", err)
+	}
+	if len(outputs) > 0 {
+		r.Outputs = outputs
+	}
+	for _, other := range responses {
+		if other == nil || other.Patcher.IsEmpty() {
+			continue
+		}
+		if r.Patcher == nil {
+			r.Patcher = &Patcher{}
+		}
+		r.Patcher.Merge(other.Patcher)
+	}
+	for _, other := range responses {
+		if other != nil {
+			r.Costs = append(r.Costs, other.Costs...)
+		}
+	}
+	return nil
+}
+
+// Sort sorts the resources by ID, keeping the order of resources with the same ID. The wrapper
+// sorts responses this way before marshaling, so repeated runs produce byte-identical output.
+func (r *GeneratorResponse) Sort() {
+	sort.SliceStable(r.Resources, func(i, j int) bool {
+		return r.Resources[i].ID < r.Resources[j].ID
+	})
+}
+
+// SortFunc sorts the resources by less and makes the wrapper keep this order, for modules needing
+// a custom order. less must be deterministic to keep the output stable.
+func (r *GeneratorResponse) SortFunc(less func(a, b *v1.Resource) bool) {
+	sort.SliceStable(r.Resources, func(i, j int) bool {
+		return less(&r.Resources[i], &r.Resources[j])
+	})
+	r.PreserveOrder = true
+}
@@ -0,0 +1,201 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryClass is the classification of an error deciding whether Retry calls the function again.
+type RetryClass int
+
+const (
+	// RetryFatal errors are returned immediately
+	RetryFatal RetryClass = iota
+	// RetryTransient errors are retried with exponential backoff
+	RetryTransient
+	// RetryThrottled errors are rate limits, retried with a doubled backoff or after the delay asked by the API
+	RetryThrottled
+)
+
+// RetryPolicy configures Retry. Zero fields other than Jitter use the values of DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, including the first one
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between two calls
+	MaxDelay time.Duration
+	// Multiplier is the growth factor of the delay
+	Multiplier float64
+	// Jitter is the random fraction, between 0 and 1, removed from or added to every delay
+	Jitter float64
+	// Classify classifies the errors of the function, which is ClassifyError if nil
+	Classify func(error) RetryClass
+}
+
+// DefaultRetryPolicy is suited for calls to cloud APIs within a module generation.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  5,
+	InitialDelay: 200 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as fatal, so that Retry returns it without retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks err as transient, so that Retry retries it. Errors are fatal unless classified otherwise.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// ThrottledError is a rate limit error, optionally with the delay asked by the API, e.g. from a Retry-After header.
+type ThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string { return e.Err.Error() }
+func (e *ThrottledError) Unwrap() error { return e.Err }
+
+// Throttled marks err as a rate limit error retried after the delay, which is computed by the policy if zero.
+func Throttled(err error, retryAfter time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &ThrottledError{Err: err, RetryAfter: retryAfter}
+}
+
+// ClassifyError is the default classification of Retry, which only retries errors known to be
+// retryable. Errors marked by Throttled and gRPC ResourceExhausted errors are throttled. Errors marked
+// by Transient, module errors with ErrCodeUnavailable or ErrCodeTimeout, and gRPC Unavailable, Aborted
+// and DeadlineExceeded errors are transient. Any other error is fatal, including errors marked by
+// Permanent and context errors, so that bugs and invalid inputs are not retried.
+func ClassifyError(err error) RetryClass {
+	var permanent *permanentError
+	var throttled *ThrottledError
+	var transient *transientError
+	var moduleErr *Error
+	switch {
+	case errors.As(err, &permanent), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return RetryFatal
+	case errors.As(err, &throttled):
+		return RetryThrottled
+	case errors.As(err, &transient):
+		return RetryTransient
+	case errors.As(err, &moduleErr):
+		if moduleErr.Code == ErrCodeUnavailable || moduleErr.Code == ErrCodeTimeout {
+			return RetryTransient
+		}
+		return RetryFatal
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.ResourceExhausted:
+			return RetryThrottled
+		case codes.Unavailable, codes.Aborted, codes.DeadlineExceeded:
+			return RetryTransient
+		}
+	}
+	return RetryFatal
+}
+
+// Retry calls fn until it succeeds, returns a fatal error, the attempts of policy are exhausted, or the
+// next delay would exceed the deadline of ctx. The last error of fn is returned.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	policy = policy.withDefaults()
+	delay := policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		class := policy.Classify(err)
+		if class == RetryFatal {
+			var permanent *permanentError
+			if errors.As(err, &permanent) {
+				return permanent.err
+			}
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("retry failed after %d attempts. %w", attempt, err)
+		}
+
+		wait := policy.jitter(delay)
+		var throttled *ThrottledError
+		if class == RetryThrottled {
+			if errors.As(err, &throttled) && throttled.RetryAfter > 0 {
+				wait = throttled.RetryAfter
+			} else {
+				wait *= 2
+			}
+		}
+		wait = min(wait, policy.MaxDelay)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("retry stopped after %d attempts before the deadline of the request. %w", attempt, err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry canceled after %d attempts. %w", attempt, errors.Join(ctx.Err(), err))
+		case <-timer.C:
+		}
+		delay = min(time.Duration(float64(delay)*policy.Multiplier), policy.MaxDelay)
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultRetryPolicy.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = DefaultRetryPolicy.Jitter
+	}
+	if p.Classify == nil {
+		p.Classify = ClassifyError
+	}
+	return p
+}
+
+func (p RetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter == 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,63 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RetryClass
+	}{
+		{name: "unclassified", err: errors.New("nil pointer"), want: RetryFatal},
+		{name: "wrapped unclassified", err: fmt.Errorf("call api failed. %w", errors.New("bad request")), want: RetryFatal},
+		{name: "transient", err: fmt.Errorf("call api failed. %w", Transient(errors.New("connection reset"))), want: RetryTransient},
+		{name: "throttled", err: Throttled(errors.New("slow down"), time.Second), want: RetryThrottled},
+		{name: "permanent", err: Permanent(Transient(errors.New("gone"))), want: RetryFatal},
+		{name: "canceled", err: Transient(context.Canceled), want: RetryFatal},
+		{name: "unavailable module", err: NewError(ErrCodeUnavailable, "not ready"), want: RetryTransient},
+		{name: "invalid config", err: NewError(ErrCodeInvalidConfig, "replicas must be positive"), want: RetryFatal},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "connection refused"), want: RetryTransient},
+		{name: "grpc resource exhausted", err: status.Error(codes.ResourceExhausted, "quota"), want: RetryThrottled},
+		{name: "grpc invalid argument", err: status.Error(codes.InvalidArgument, "bad"), want: RetryFatal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond}
+	calls := 0
+	err := Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		return errors.New("unclassified")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Retry() of an unclassified error called fn %d times, error = %v, want 1 call", calls, err)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return Transient(errors.New("connection reset"))
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry() of transient errors called fn %d times, error = %v, want success after 3 calls", calls, err)
+	}
+}
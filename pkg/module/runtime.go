@@ -0,0 +1,92 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// KubeConfigEnv is the environment variable of the kubeconfig path, used if the runtime config sets none.
+const KubeConfigEnv = "KUBECONFIG"
+
+// Runtime is a read accessor of the runtime configs of the request, which is safe to use if the
+// workspace defines no runtime configs.
+type Runtime struct {
+	rc *v1.RuntimeConfigs
+}
+
+// Runtime returns the accessor of the runtime configs of the request.
+func (r *GeneratorRequest) Runtime() *Runtime {
+	return &Runtime{rc: r.RuntimeConfig}
+}
+
+// Kubernetes returns a copy of the Kubernetes runtime config, which is never nil. The kubeconfig path
+// defaults to the KUBECONFIG environment variable.
+func (rt *Runtime) Kubernetes() *v1.KubernetesConfig {
+	c := &v1.KubernetesConfig{}
+	if rt.rc != nil && rt.rc.Kubernetes != nil {
+		*c = *rt.rc.Kubernetes
+	}
+	if c.KubeConfig == "" {
+		c.KubeConfig = os.Getenv(KubeConfigEnv)
+	}
+	return c
+}
+
+// Terraform returns the Terraform runtime config, which is never nil. Providers configured as null
+// in the workspace are omitted.
+func (rt *Runtime) Terraform() v1.TerraformConfig {
+	tc := v1.TerraformConfig{}
+	if rt.rc == nil {
+		return tc
+	}
+	for name, pc := range rt.rc.Terraform {
+		if pc != nil {
+			tc[name] = pc
+		}
+	}
+	return tc
+}
+
+// TerraformProviders returns the sorted names of the configured Terraform providers.
+func (rt *Runtime) TerraformProviders() []string {
+	tc := rt.Terraform()
+	names := make([]string, 0, len(tc))
+	for name := range tc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TerraformProvider returns the config of the Terraform provider named name, or def if it is not
+// configured, see TFProviderFromRuntimeConfig.
+func (rt *Runtime) TerraformProvider(name string, def *TFProviderConfig) (*TFProviderConfig, error) {
+	return TFProviderFromRuntimeConfig(rt.rc, name, def)
+}
+
+// Validate checks whether the kubeconfig file exists if set, and whether the configured Terraform
+// providers have valid sources and versions.
+func (rt *Runtime) Validate() error {
+	var errs []error
+	if kubeConfig := rt.Kubernetes().KubeConfig; kubeConfig != "" {
+		if _, err := os.Stat(kubeConfig); err != nil {
+			errs = append(errs, fmt.Errorf("invalid kubeconfig of kubernetes runtime. %w", err))
+		}
+	}
+	tc := rt.Terraform()
+	for _, name := range rt.TerraformProviders() {
+		p := &TFProviderConfig{Source: tc[name].Source, Version: tc[name].Version}
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid terraform provider %s. %w", name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return NewError(ErrCodeInvalidConfig, "invalid runtime config: %v", err).
+			WithHint("check the runtimes of the workspace config")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+func runtimeOf(t *testing.T, runtimeConfig string) *Runtime {
+	t.Helper()
+	req := &proto.GeneratorRequest{Project: "p", Stack: "dev", App: "app"}
+	if runtimeConfig != "" {
+		req.RuntimeConfig = []byte(runtimeConfig)
+	}
+	r, err := NewGeneratorRequest(req)
+	if err != nil {
+		t.Fatalf("NewGeneratorRequest() error = %v", err)
+	}
+	return r.Runtime()
+}
+
+func TestRuntimeKubernetes(t *testing.T) {
+	t.Setenv(KubeConfigEnv, "/env/kubeconfig")
+	tests := []struct {
+		name          string
+		runtimeConfig string
+		want          string
+	}{
+		{name: "no runtime config", want: "/env/kubeconfig"},
+		{name: "null runtime config", runtimeConfig: "null", want: "/env/kubeconfig"},
+		{name: "terraform only", runtimeConfig: "terraform:\n  aws:\n    source: hashicorp/aws\n    version: 5.0.0\n", want: "/env/kubeconfig"},
+		{name: "kubeconfig set", runtimeConfig: "kubernetes:\n  kubeConfig: /etc/kube/config\n", want: "/etc/kube/config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runtimeOf(t, tt.runtimeConfig).Kubernetes().KubeConfig; got != tt.want {
+				t.Errorf("Kubernetes().KubeConfig = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeTerraform(t *testing.T) {
+	tests := []struct {
+		name          string
+		runtimeConfig string
+		want          []string
+	}{
+		{name: "no runtime config", want: []string{}},
+		{name: "kubernetes only", runtimeConfig: "kubernetes:\n  kubeConfig: /etc/kube/config\n", want: []string{}},
+		{
+			name:          "providers",
+			runtimeConfig: "terraform:\n  random:\n    source: hashicorp/random\n    version: 3.5.1\n  aws:\n    source: hashicorp/aws\n    version: 5.0.0\n    region: us-east-1\n",
+			want:          []string{"aws", "random"},
+		},
+		{name: "null provider", runtimeConfig: "terraform:\n  aws: null\n", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runtimeOf(t, tt.runtimeConfig).TerraformProviders(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TerraformProviders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeTerraformProvider(t *testing.T) {
+	rt := runtimeOf(t, "terraform:\n  aws:\n    source: hashicorp/aws\n    region: us-east-1\n    profile: dev\n")
+	def := &TFProviderConfig{Source: "hashicorp/aws", Version: "5.0.0", Region: "us-west-2"}
+
+	got, err := rt.TerraformProvider("aws", def)
+	if err != nil {
+		t.Fatalf("TerraformProvider() error = %v", err)
+	}
+	want := &TFProviderConfig{Source: "hashicorp/aws", Version: "5.0.0", Region: "us-east-1", Meta: map[string]any{"profile": "dev"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TerraformProvider() = %+v, want %+v", got, want)
+	}
+
+	if got, err = rt.TerraformProvider("random", def); err != nil || got.Source != def.Source {
+		t.Errorf("TerraformProvider() of an unconfigured provider = %+v, %v, want the default", got, err)
+	}
+	if _, err = rt.TerraformProvider("random", nil); err == nil {
+		t.Errorf("TerraformProvider() of an unconfigured provider without default succeeded")
+	}
+}
+
+func TestRuntimeValidate(t *testing.T) {
+	kubeConfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeConfig, []byte("apiVersion: v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KubeConfigEnv, "")
+	tests := []struct {
+		name          string
+		runtimeConfig string
+		wantErr       bool
+	}{
+		{name: "no runtime config"},
+		{name: "existing kubeconfig", runtimeConfig: "kubernetes:\n  kubeConfig: " + kubeConfig + "\n"},
+		{name: "missing kubeconfig", runtimeConfig: "kubernetes:\n  kubeConfig: /missing/config\n", wantErr: true},
+		{name: "valid provider", runtimeConfig: "terraform:\n  aws:\n    source: hashicorp/aws\n    version: 5.0.0\n"},
+		{name: "provider without version", runtimeConfig: "terraform:\n  aws:\n    source: hashicorp/aws\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runtimeOf(t, tt.runtimeConfig).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errorCode(err) != ErrCodeInvalidConfig {
+				t.Errorf("Validate() error code = %s, want %s", errorCode(err), ErrCodeInvalidConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// ErrSandboxUnsupported is returned when the restrictions of a Sandbox are not supported by the platform.
+var ErrSandboxUnsupported = errors.New("module sandbox is not supported")
+
+// Sandbox restricts the plugin process before the code of the module runs, useful for registries running
+// third-party modules. The restrictions are enforced with Landlock on Linux before any code of the module
+// runs, i.e. the first Ready, Validate, Generate, OnDelete, Reload or Cleanup call, once the plugin has set
+// up its connection to the engine, and cannot be lifted. They apply
+// to all threads of the process, which requires binaries built with CGO_ENABLED=0.
+type Sandbox struct {
+	// NoNetwork denies TCP connections and listeners, which requires Linux 6.7 or later. The connection
+	// to the engine is kept
+	NoNetwork bool
+	// ReadOnlyFS denies writing files outside of the private temp dir of the module, which os.TempDir
+	// returns, and WritablePaths
+	ReadOnlyFS bool
+	// ReadPaths restricts reading and executing files to the paths beneath them and the temp dir if not empty
+	ReadPaths []string
+	// WritablePaths are the paths beneath which files can be written with ReadOnlyFS
+	WritablePaths []string
+	// BestEffort runs the module with the restrictions supported by the platform and logs the others,
+	// instead of failing every call
+	BestEffort bool
+}
+
+// WithSandbox restricts the plugin process with s before the code of the module runs.
+func WithSandbox(s Sandbox) ServeOption {
+	return func(o *serveOptions) {
+		o.sandbox = &s
+	}
+}
+
+// enterSandbox applies the sandbox of the wrapper once, after creating the private temp dir of the module.
+// The error of applying it is returned by every call.
+func (f *FrameworkModuleWrapper) enterSandbox() error {
+	if f.Sandbox == nil {
+		return nil
+	}
+	f.sandboxOnce.Do(func() {
+		s := *f.Sandbox
+		if s.ReadOnlyFS || len(s.ReadPaths) > 0 {
+			dir, err := os.MkdirTemp("", "kusion-module-")
+			if err != nil {
+				f.sandboxErr = fmt.Errorf("create sandbox temp dir failed. %w", err)
+				return
+			}
+			if err = os.Setenv("TMPDIR", dir); err != nil {
+				f.sandboxErr = fmt.Errorf("set sandbox temp dir failed. %w", err)
+				return
+			}
+			f.sandboxTemp = dir
+			s.WritablePaths = append(append([]string{dir}, s.WritablePaths...), f.sandboxWritablePaths()...)
+			if len(s.ReadPaths) > 0 {
+				s.ReadPaths = append(s.ReadPaths, s.WritablePaths...)
+			}
+		}
+		err := applySandbox(s)
+		if err != nil && s.BestEffort && errors.Is(err, ErrSandboxUnsupported) {
+			log.Warnf("module is not sandboxed: %v", err)
+			err = nil
+		}
+		if err != nil {
+			f.sandboxErr = NewError(ErrCodeInternal, "apply module sandbox failed: %v", err).
+				WithHint("run the module on Linux 5.13 or later built with CGO_ENABLED=0, or enable the best effort sandbox")
+		}
+	})
+	return f.sandboxErr
+}
+
+// sandboxWritablePaths returns the dirs the framework writes to, the crash and recording dirs.
+func (f *FrameworkModuleWrapper) sandboxWritablePaths() []string {
+	crashDir := f.CrashDir
+	if crashDir == "" {
+		crashDir = os.Getenv(CrashDirEnv)
+	}
+	var paths []string
+	for _, dir := range []string{crashDir, os.Getenv(RecordDirEnv)} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err == nil {
+			paths = append(paths, dir)
+		}
+	}
+	return paths
+}
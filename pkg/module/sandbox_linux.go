@@ -0,0 +1,118 @@
+//go:build linux
+
+package module
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	landlockWriteFS = unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE | unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK | unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK | unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	landlockReadFS = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	// landlockFileFS are the rights applying to files, the others only apply to dirs
+	landlockFileFS = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	landlockNetTCP = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+)
+
+// applySandbox restricts all threads of the process with a Landlock ruleset and no_new_privs.
+func applySandbox(s Sandbox) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("%w: landlock is not available: %v", ErrSandboxUnsupported, errno)
+	}
+
+	attr := unix.LandlockRulesetAttr{}
+	var writeFS, readFS uint64
+	if s.ReadOnlyFS {
+		writeFS = landlockWriteFS
+		if abi >= 2 {
+			writeFS |= unix.LANDLOCK_ACCESS_FS_REFER
+		}
+		if abi >= 3 {
+			writeFS |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+		}
+	}
+	if len(s.ReadPaths) > 0 {
+		readFS = landlockReadFS
+	}
+	attr.Access_fs = writeFS | readFS
+	var unsupported error
+	if s.NoNetwork {
+		if abi >= 4 {
+			attr.Access_net = landlockNetTCP
+		} else {
+			unsupported = fmt.Errorf("%w: denying network access requires landlock ABI 4, got %d", ErrSandboxUnsupported, abi)
+			if !s.BestEffort {
+				return unsupported
+			}
+		}
+	}
+	if attr.Access_fs == 0 && attr.Access_net == 0 {
+		return unsupported
+	}
+
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("create landlock ruleset failed. %w", errno)
+	}
+	defer unix.Close(int(fd))
+	if writeFS != 0 {
+		for _, path := range s.WritablePaths {
+			if err := addPathRule(int(fd), path, writeFS|readFS); err != nil {
+				return err
+			}
+		}
+	}
+	if readFS != 0 {
+		for _, path := range s.ReadPaths {
+			if err := addPathRule(int(fd), path, readFS); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the restrictions must apply to every thread running goroutines, which is not supported with cgo
+	if _, _, errno = syscall.AllThreadsSyscall6(unix.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0, 0); errno == syscall.ENOTSUP {
+		return fmt.Errorf("%w in binaries built with cgo", ErrSandboxUnsupported)
+	} else if errno != 0 {
+		return fmt.Errorf("set no_new_privs failed. %w", errno)
+	}
+	if _, _, errno = syscall.AllThreadsSyscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("restrict the process with landlock failed. %w", errno)
+	}
+	return unsupported
+}
+
+// addPathRule allows access to the files beneath path, ignoring paths that do not exist.
+func addPathRule(rulesetFD int, path string, access uint64) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat sandbox path %s failed. %w", path, err)
+	}
+	if !info.IsDir() {
+		access &= landlockFileFS
+	}
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open sandbox path %s failed. %w", path, err)
+	}
+	defer unix.Close(fd)
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFD), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("add sandbox path %s failed. %w", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package module
+
+import "fmt"
+
+// applySandbox is not supported outside of Linux.
+func applySandbox(Sandbox) error {
+	return fmt.Errorf("%w on this platform", ErrSandboxUnsupported)
+}
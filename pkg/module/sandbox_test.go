@@ -0,0 +1,78 @@
+package module
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// sandboxTestModule records the calls of its hooks, which must only run in the sandbox.
+type sandboxTestModule struct {
+	calls []string
+}
+
+func (m *sandboxTestModule) Generate(context.Context, *GeneratorRequest) (*GeneratorResponse, error) {
+	m.calls = append(m.calls, "Generate")
+	return &GeneratorResponse{}, nil
+}
+
+func (m *sandboxTestModule) Ready(context.Context) error {
+	m.calls = append(m.calls, "Ready")
+	return nil
+}
+
+func (m *sandboxTestModule) OnDelete(context.Context, *GeneratorRequest) error {
+	m.calls = append(m.calls, "OnDelete")
+	return nil
+}
+
+func (m *sandboxTestModule) Reload(context.Context, EnvironmentDefaults) error {
+	m.calls = append(m.calls, "Reload")
+	return nil
+}
+
+func (m *sandboxTestModule) Cleanup(context.Context) error {
+	m.calls = append(m.calls, "Cleanup")
+	return nil
+}
+
+func TestModuleCodeRunsInSandbox(t *testing.T) {
+	ctx := context.Background()
+	entries := map[string]func(w *FrameworkModuleWrapper) error{
+		"Ready": func(w *FrameworkModuleWrapper) error {
+			_, err := w.readyRPC(ctx, nil)
+			return err
+		},
+		"Generate": func(w *FrameworkModuleWrapper) error {
+			_, err := w.Generate(ctx, &proto.GeneratorRequest{Project: "p", Stack: "dev", App: "app"})
+			return err
+		},
+		"OnDelete": func(w *FrameworkModuleWrapper) error {
+			return w.OnDelete(ctx, &GeneratorRequest{})
+		},
+		"Reload": func(w *FrameworkModuleWrapper) error {
+			return w.reloadDefaults(ctx, EnvironmentDefaults{})
+		},
+		"Cleanup": func(w *FrameworkModuleWrapper) error {
+			return w.Cleanup(ctx)
+		},
+	}
+	for name, call := range entries {
+		t.Run(name, func(t *testing.T) {
+			// the sandbox fails to create its temp dir, so no module code may run
+			t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+			m := &sandboxTestModule{}
+			w := &FrameworkModuleWrapper{Module: m, Name: "sandboxed", Logger: hclog.NewNullLogger(), Sandbox: &Sandbox{ReadOnlyFS: true}}
+			if err := call(w); err == nil || !strings.Contains(err.Error(), "sandbox") {
+				t.Errorf("%s() error = %v, want the sandbox error", name, err)
+			}
+			if len(m.calls) > 0 {
+				t.Errorf("module code %v ran outside of the sandbox", m.calls)
+			}
+		})
+	}
+}
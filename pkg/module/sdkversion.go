@@ -0,0 +1,87 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// SDK versions of the module protocol on top of the plugin handshake. The version is bumped whenever the
+// shape of requests changes, e.g. a field moves or a metadata header replaces a proto field.
+const (
+	// SDKVersion is the version of requests handled by this framework
+	SDKVersion = 2
+	// MinSDKVersion is the oldest engine version whose requests are still adapted to SDKVersion
+	MinSDKVersion = 1
+)
+
+// SDKVersionMetadataKey is the gRPC request header with the SDK version of the engine, and the response
+// header with the SDK version of the module. Engines not sending it are SDK version 1.
+const SDKVersionMetadataKey = "kusion-module-sdk-version"
+
+// RequestAdapter upgrades a request of an older SDK version in place to the next version.
+type RequestAdapter func(req *proto.GeneratorRequest) error
+
+var (
+	requestAdaptersMu sync.RWMutex
+	requestAdapters   = map[int]RequestAdapter{}
+)
+
+// RegisterRequestAdapter registers the adapter upgrading requests of SDK version from to from+1.
+// Versions without adapters are compatible with the next version as is.
+func RegisterRequestAdapter(from int, adapter RequestAdapter) {
+	requestAdaptersMu.Lock()
+	defer requestAdaptersMu.Unlock()
+	requestAdapters[from] = adapter
+}
+
+// ContextWithSDKVersion returns a copy of ctx announcing the SDK version of the host, used by hosts of modules.
+func ContextWithSDKVersion(ctx context.Context, version int) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, SDKVersionMetadataKey, strconv.Itoa(version))
+}
+
+// engineSDKVersion returns the SDK version announced by the engine.
+func engineSDKVersion(ctx context.Context) (int, error) {
+	v := incomingMetadata(ctx, SDKVersionMetadataKey)
+	if v == "" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid sdk version %q", v)
+	}
+	return version, nil
+}
+
+// adaptRequest upgrades req of the engine SDK version to SDKVersion and announces SDKVersion in the
+// response header. Requests of newer engines are passed as is, as engines downgrade requests to the
+// version announced by the module.
+func adaptRequest(ctx context.Context, req *proto.GeneratorRequest) error {
+	version, err := engineSDKVersion(ctx)
+	if err != nil {
+		return NewError(ErrCodeInvalidRequest, "%v", err)
+	}
+	if err = setHeader(ctx, SDKVersionMetadataKey, strconv.Itoa(SDKVersion)); err != nil {
+		return fmt.Errorf("send sdk version failed. %w", err)
+	}
+	if version < MinSDKVersion {
+		return NewError(ErrCodeInvalidRequest, "engine sdk version %d is older than the minimum version %d supported by the module", version, MinSDKVersion).
+			WithHint("upgrade kusion, or use an older version of the module")
+	}
+	requestAdaptersMu.RLock()
+	defer requestAdaptersMu.RUnlock()
+	for v := version; v < SDKVersion; v++ {
+		adapter, ok := requestAdapters[v]
+		if !ok {
+			continue
+		}
+		if err = adapter(req); err != nil {
+			return NewError(ErrCodeInvalidRequest, "adapt request of sdk version %d failed: %v", v, err)
+		}
+	}
+	return nil
+}
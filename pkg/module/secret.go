@@ -0,0 +1,270 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	secretRefScheme = "secret://"
+	// EnvSecretStoreProvider is the conventional provider name of EnvSecretStore
+	EnvSecretStoreProvider = "env"
+)
+
+// SecretStore resolves secret values from an external secret store, such as Vault,
+// AWS Secrets Manager or Alicloud KMS. The stores of providers are registered by RegisterSecretStore,
+// or configured by the secretStore of the workspace, see WorkspaceSecretStore.
+type SecretStore interface {
+	// GetSecret returns the value of key of the secret at path. Key is empty if the
+	// reference does not specify one, in which case the whole secret value is expected.
+	GetSecret(ctx context.Context, path, key string) (string, error)
+}
+
+var (
+	secretStoresMu sync.RWMutex
+	secretStores   = map[string]SecretStore{}
+)
+
+// RegisterSecretStore registers the secret store of provider, referenced as secret://<provider>/<path>[#key],
+// for all modules of the plugin. Registering a provider twice replaces the previous store. No store is
+// registered by default.
+func RegisterSecretStore(provider string, store SecretStore) {
+	secretStoresMu.Lock()
+	defer secretStoresMu.Unlock()
+	secretStores[provider] = store
+}
+
+// WithSecretStore registers the secret store of provider for the served module, taking precedence over the
+// stores registered by RegisterSecretStore, e.g.
+// WithSecretStore(EnvSecretStoreProvider, EnvSecretStore{Prefix: "APP_SECRET_"}).
+func WithSecretStore(provider string, store SecretStore) ServeOption {
+	return func(o *serveOptions) {
+		if o.secretStores == nil {
+			o.secretStores = map[string]SecretStore{}
+		}
+		o.secretStores[provider] = store
+	}
+}
+
+// WorkspaceSecretStore is the secretStore of the workspace config, configuring the store of the secret
+// references of the workspace. It is read if the module is served with WithWorkspaceAccess, and the
+// credentials of the store are read from the environment of the plugin.
+type WorkspaceSecretStore struct {
+	Provider SecretStoreProviders `yaml:"provider"`
+}
+
+// SecretStoreProviders is the provider of a WorkspaceSecretStore, of which one is set.
+type SecretStoreProviders struct {
+	Vault    *VaultSecretStoreConfig    `yaml:"vault,omitempty"`
+	AWS      *AWSSecretStoreConfig      `yaml:"aws,omitempty"`
+	Alicloud *AlicloudSecretStoreConfig `yaml:"alicloud,omitempty"`
+}
+
+// VaultSecretStoreConfig configures a VaultSecretStore, whose token is read from VAULT_TOKEN.
+type VaultSecretStoreConfig struct {
+	// Server is the address of the Vault server
+	Server string `yaml:"server"`
+	// Path is the mount path of the KV engine, the first element of the paths of references if empty
+	Path string `yaml:"path,omitempty"`
+	// Version is the version of the KV engine, only v2 is supported
+	Version string `yaml:"version,omitempty"`
+}
+
+// AWSSecretStoreConfig configures an AWSSecretStore, see NewAWSSecretStoreFromEnv.
+type AWSSecretStoreConfig struct {
+	Region string `yaml:"region"`
+}
+
+// AlicloudSecretStoreConfig configures an AlicloudSecretStore, see NewAlicloudSecretStoreFromEnv.
+type AlicloudSecretStoreConfig struct {
+	Region string `yaml:"region"`
+}
+
+// workspaceSecretStore returns the store of provider configured in the workspace of the request, or nil
+// if the workspace is not accessible or configures another provider.
+func (r *GeneratorRequest) workspaceSecretStore(provider string) (SecretStore, error) {
+	if !r.workspaceAccess || len(r.workspace) == 0 {
+		return nil, nil
+	}
+	var ws struct {
+		SecretStore *WorkspaceSecretStore `yaml:"secretStore"`
+	}
+	if err := yaml.Unmarshal(r.workspace, &ws); err != nil {
+		return nil, fmt.Errorf("unmarshal secret store of workspace failed. %w", err)
+	}
+	if ws.SecretStore == nil {
+		return nil, nil
+	}
+	p := ws.SecretStore.Provider
+	var (
+		store SecretStore
+		err   error
+	)
+	switch {
+	case provider == VaultSecretStoreProvider && p.Vault != nil:
+		if p.Vault.Version != "" && p.Vault.Version != "v2" {
+			return nil, NewError(ErrCodeInvalidConfig, "vault kv %s of the workspace secret store is not supported", p.Vault.Version).
+				WithHint("use the kv v2 secret engine")
+		}
+		token := os.Getenv(VaultTokenEnv)
+		if token == "" {
+			err = fmt.Errorf("%s must be set", VaultTokenEnv)
+		}
+		store = &VaultSecretStore{Address: p.Vault.Server, Token: token, Mount: p.Vault.Path}
+	case provider == AWSSecretStoreProvider && p.AWS != nil:
+		store, err = NewAWSSecretStoreFromEnv(p.AWS.Region)
+	case provider == AlicloudSecretStoreProvider && p.Alicloud != nil:
+		store, err = NewAlicloudSecretStoreFromEnv(p.Alicloud.Region)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewError(ErrCodeInvalidConfig, "invalid %s secret store of the workspace: %v", provider, err).
+			WithHint("set the credentials of the secret store in the environment of the module")
+	}
+	return store, nil
+}
+
+// SecretRef is a parsed reference to a secret in the form of ${secret://<provider>/<path>[#key]}.
+type SecretRef struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+// IsSecretRef reports whether s is a secret reference.
+func IsSecretRef(s string) bool {
+	return strings.HasPrefix(trimRefBraces(s), secretRefScheme)
+}
+
+// ParseSecretRef parses a secret reference in the form of ${secret://<provider>/<path>[#key]}.
+// The surrounding ${} is optional.
+func ParseSecretRef(ref string) (*SecretRef, error) {
+	s := trimRefBraces(ref)
+	if !strings.HasPrefix(s, secretRefScheme) {
+		return nil, fmt.Errorf("invalid secret reference %q, expected ${secret://<provider>/<path>[#key]}", ref)
+	}
+	s = strings.TrimPrefix(s, secretRefScheme)
+	provider, path, _ := strings.Cut(s, "/")
+	path, key, _ := strings.Cut(path, "#")
+	if provider == "" || path == "" {
+		return nil, fmt.Errorf("invalid secret reference %q, expected ${secret://<provider>/<path>[#key]}", ref)
+	}
+	return &SecretRef{Provider: provider, Path: path, Key: key}, nil
+}
+
+// ResolveSecret resolves the secret reference in the form of ${secret://<provider>/<path>[#key]} against the
+// secret stores of WithSecretStore and RegisterSecretStore in turn, or the secret store configured in the
+// workspace if the provider is not registered.
+func (r *GeneratorRequest) ResolveSecret(ref string) (string, error) {
+	return r.ResolveSecretContext(context.Background(), ref)
+}
+
+// ResolveSecretContext is like ResolveSecret but uses ctx for the calls to the secret store.
+func (r *GeneratorRequest) ResolveSecretContext(ctx context.Context, ref string) (string, error) {
+	sr, err := ParseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	store, ok := r.secretStores[sr.Provider]
+	if !ok {
+		secretStoresMu.RLock()
+		store, ok = secretStores[sr.Provider]
+		secretStoresMu.RUnlock()
+	}
+	if !ok {
+		if store, err = r.workspaceSecretStore(sr.Provider); err != nil {
+			return "", err
+		}
+		if store == nil {
+			return "", fmt.Errorf("secret store of provider %q is neither registered nor configured in the workspace", sr.Provider)
+		}
+	}
+	value, err := store.GetSecret(ctx, sr.Path, sr.Key)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %q failed. %w", ref, err)
+	}
+	return value, nil
+}
+
+// ResolveSecrets replaces all string values in config that are secret references with the resolved secrets,
+// walking through nested maps and lists.
+func (r *GeneratorRequest) ResolveSecrets(ctx context.Context, config map[string]any) error {
+	for k, v := range config {
+		resolved, err := r.resolveSecretsIn(ctx, v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		config[k] = resolved
+	}
+	return nil
+}
+
+func (r *GeneratorRequest) resolveSecretsIn(ctx context.Context, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if !IsSecretRef(val) {
+			return val, nil
+		}
+		return r.ResolveSecretContext(ctx, val)
+	case map[string]any:
+		if err := r.ResolveSecrets(ctx, val); err != nil {
+			return nil, err
+		}
+	case map[interface{}]interface{}:
+		for k, item := range val {
+			resolved, err := r.resolveSecretsIn(ctx, item)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", k, err)
+			}
+			val[k] = resolved
+		}
+	case []interface{}:
+		for i, item := range val {
+			resolved, err := r.resolveSecretsIn(ctx, item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			val[i] = resolved
+		}
+	}
+	return v, nil
+}
+
+func trimRefBraces(s string) string {
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+		return s[2 : len(s)-1]
+	}
+	return s
+}
+
+// EnvSecretStore resolves secrets from environment variables of the plugin process. The path is the name
+// of the environment variable, and the key must be empty. Only the variables starting with Prefix are
+// readable, so that references in configs can not read the credentials of the plugin, such as VAULT_TOKEN
+// or AWS_SECRET_ACCESS_KEY. The store is not registered by default, see WithSecretStore.
+type EnvSecretStore struct {
+	// Prefix is the required prefix of the names of the readable variables, e.g. APP_SECRET_. Nothing is
+	// readable if empty.
+	Prefix string
+}
+
+// GetSecret returns the value of the environment variable named path.
+func (s EnvSecretStore) GetSecret(_ context.Context, path, key string) (string, error) {
+	if key != "" {
+		return "", fmt.Errorf("env secret store does not support keys")
+	}
+	if s.Prefix == "" || !strings.HasPrefix(path, s.Prefix) {
+		return "", NewError(ErrCodeInvalidConfig, "environment variable %s is not readable as a secret", path).
+			WithHint(fmt.Sprintf("only environment variables prefixed with %q are readable", s.Prefix))
+	}
+	value, ok := os.LookupEnv(path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", path)
+	}
+	return value, nil
+}
@@ -0,0 +1,149 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AlicloudSecretStoreProvider is the conventional provider name of AlicloudSecretStore.
+const AlicloudSecretStoreProvider = "alicloud"
+
+// Environment variables of the credentials of AlicloudSecretStore, the same as the Terraform provider's.
+const (
+	AlicloudAccessKeyEnv     = "ALICLOUD_ACCESS_KEY"
+	AlicloudSecretKeyEnv     = "ALICLOUD_SECRET_KEY"
+	AlicloudSecurityTokenEnv = "ALICLOUD_SECURITY_TOKEN"
+)
+
+// AlicloudSecretStore resolves secrets from the secrets manager of Alicloud KMS. The path of a reference
+// is the name of the secret, e.g. secret://alicloud/prod-db#password. Without a key the secret data is
+// returned, otherwise the secret data must be a JSON object and the value of key is returned.
+type AlicloudSecretStore struct {
+	// Region is the region of KMS, e.g. cn-hangzhou
+	Region string
+	// AccessKey, SecretKey and SecurityToken are the credentials signing the requests
+	AccessKey     string
+	SecretKey     string
+	SecurityToken string
+	// Endpoint is the URL of KMS, https://kms.<region>.aliyuncs.com is used if empty
+	Endpoint string
+	// Client is the HTTP client used to call KMS, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+// NewAlicloudSecretStoreFromEnv returns an AlicloudSecretStore of region with the credentials in the
+// ALICLOUD_ACCESS_KEY, ALICLOUD_SECRET_KEY and ALICLOUD_SECURITY_TOKEN environment variables.
+func NewAlicloudSecretStoreFromEnv(region string) (*AlicloudSecretStore, error) {
+	s := &AlicloudSecretStore{
+		Region:        region,
+		AccessKey:     os.Getenv(AlicloudAccessKeyEnv),
+		SecretKey:     os.Getenv(AlicloudSecretKeyEnv),
+		SecurityToken: os.Getenv(AlicloudSecurityTokenEnv),
+	}
+	if s.Region == "" {
+		return nil, fmt.Errorf("region of alicloud secret store is empty")
+	}
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return nil, fmt.Errorf("%s and %s must be set", AlicloudAccessKeyEnv, AlicloudSecretKeyEnv)
+	}
+	return s, nil
+}
+
+// GetSecret reads the secret at path with the GetSecretValue API.
+func (s *AlicloudSecretStore) GetSecret(ctx context.Context, path, key string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate signature nonce failed. %w", err)
+	}
+	params := url.Values{
+		"Action":           {"GetSecretValue"},
+		"SecretName":       {path},
+		"Format":           {"JSON"},
+		"Version":          {"2016-01-20"},
+		"AccessKeyId":      {s.AccessKey},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureVersion": {"1.0"},
+		"SignatureNonce":   {hex.EncodeToString(nonce)},
+		"Timestamp":        {time.Now().UTC().Format("2006-01-02T15:04:05Z")},
+	}
+	if s.SecurityToken != "" {
+		params.Set("SecurityToken", s.SecurityToken)
+	}
+	params.Set("Signature", alicloudSignature(http.MethodGet, params, s.SecretKey))
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://kms." + s.Region + ".aliyuncs.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/?"+alicloudQuery(params), nil)
+	if err != nil {
+		return "", err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read alicloud secret %s failed. %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("read alicloud secret %s failed with status %s: %s", path, resp.Status, bytes.TrimSpace(data))
+	}
+
+	var out struct {
+		SecretData string `json:"SecretData"`
+	}
+	if err = json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("decode alicloud response failed. %w", err)
+	}
+	return secretValue(out.SecretData, path, key)
+}
+
+// alicloudSignature signs the params of an RPC API call with the signature version 1.0 of Alicloud.
+func alicloudSignature(method string, params url.Values, secret string) string {
+	stringToSign := method + "&" + alicloudEscape("/") + "&" + alicloudEscape(alicloudQuery(params))
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// alicloudQuery returns the canonicalized query of params, sorted by key and escaped by alicloudEscape.
+func alicloudQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, alicloudEscape(k)+"="+alicloudEscape(params.Get(k)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// alicloudEscape percent-encodes s as required by Alicloud signatures, which differs from
+// url.QueryEscape in the encoding of spaces, asterisks and tildes.
+func alicloudEscape(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
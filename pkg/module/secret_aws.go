@@ -0,0 +1,181 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// AWSSecretStoreProvider is the conventional provider name of AWSSecretStore.
+const AWSSecretStoreProvider = "aws"
+
+// Environment variables of the credentials of AWSSecretStore.
+const (
+	AWSAccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	AWSSecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY"
+	AWSSessionTokenEnv    = "AWS_SESSION_TOKEN"
+)
+
+// AWSSecretStore resolves secrets from AWS Secrets Manager. The path of a reference is the name or ARN of
+// the secret, e.g. secret://aws/prod/db#password. Without a key the secret string is returned, otherwise
+// the secret string must be a JSON object and the value of key is returned.
+type AWSSecretStore struct {
+	// Region is the region of Secrets Manager, e.g. us-east-1
+	Region string
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials signing the requests
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Endpoint is the URL of Secrets Manager, https://secretsmanager.<region>.amazonaws.com is used if empty
+	Endpoint string
+	// Client is the HTTP client used to call Secrets Manager, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+// NewAWSSecretStoreFromEnv returns an AWSSecretStore of region with the credentials in the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables. Shared config profiles are not read.
+func NewAWSSecretStoreFromEnv(region string) (*AWSSecretStore, error) {
+	s := &AWSSecretStore{
+		Region:          region,
+		AccessKeyID:     os.Getenv(AWSAccessKeyIDEnv),
+		SecretAccessKey: os.Getenv(AWSSecretAccessKeyEnv),
+		SessionToken:    os.Getenv(AWSSessionTokenEnv),
+	}
+	if s.Region == "" {
+		return nil, fmt.Errorf("region of aws secret store is empty")
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, fmt.Errorf("%s and %s must be set", AWSAccessKeyIDEnv, AWSSecretAccessKeyEnv)
+	}
+	return s, nil
+}
+
+// GetSecret reads the secret at path with the GetSecretValue API.
+func (s *AWSSecretStore) GetSecret(ctx context.Context, path, key string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", err
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + s.Region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, body, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read aws secret %s failed. %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("read aws secret %s failed with status %s: %s", path, resp.Status, bytes.TrimSpace(data))
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err = json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("decode aws response failed. %w", err)
+	}
+	return secretValue(out.SecretString, path, key)
+}
+
+// sign signs req with AWS Signature Version 4 for Secrets Manager.
+func (s *AWSSecretStore) sign(req *http.Request, body []byte, now time.Time) {
+	const service = "secretsmanager"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signedHeaders := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if s.SessionToken != "" {
+		signedHeaders = []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"}
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), strings.Join(signedHeaders, ";"), payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(s.SecretAccessKey, date, s.Region, service), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+// awsSigningKey derives the Signature Version 4 signing key of the date, region and service.
+func awsSigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// secretValue returns the whole secret if key is empty, otherwise the value of key in the secret, which
+// must be a JSON object.
+func secretValue(secret, path, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, can not read key %s", path, key)
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", key, path)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,207 @@
+package module
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    *SecretRef
+		wantErr bool
+	}{
+		{ref: "${secret://vault/secret/db#password}", want: &SecretRef{Provider: "vault", Path: "secret/db", Key: "password"}},
+		{ref: "secret://env/DB_PASSWORD", want: &SecretRef{Provider: "env", Path: "DB_PASSWORD"}},
+		{ref: "secret://aws", wantErr: true},
+		{ref: "vault/secret/db", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseSecretRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSecretRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSecretRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvSecretStore(t *testing.T) {
+	t.Setenv("APP_SECRET_DB", "s3cr3t")
+	t.Setenv(VaultTokenEnv, "token")
+	if _, err := (&GeneratorRequest{}).ResolveSecret("secret://env/APP_SECRET_DB"); err == nil {
+		t.Errorf("ResolveSecret() succeeded without an env secret store")
+	}
+
+	req := &GeneratorRequest{secretStores: map[string]SecretStore{EnvSecretStoreProvider: EnvSecretStore{Prefix: "APP_SECRET_"}}}
+	got, err := req.ResolveSecret("${secret://env/APP_SECRET_DB}")
+	if err != nil {
+		t.Fatalf("ResolveSecret() error = %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Errorf("ResolveSecret() = %q, want s3cr3t", got)
+	}
+	if _, err = req.ResolveSecret("secret://env/" + VaultTokenEnv); errorCode(err) != ErrCodeInvalidConfig {
+		t.Errorf("ResolveSecret() of an unprefixed variable error = %v, want %s", err, ErrCodeInvalidConfig)
+	}
+	if _, err = (EnvSecretStore{}).GetSecret(context.Background(), VaultTokenEnv, ""); err == nil {
+		t.Errorf("GetSecret() of a store without prefix succeeded")
+	}
+}
+
+// workspaceRequest returns a request made in a workspace with the secret store config.
+func workspaceRequest(secretStore string) *GeneratorRequest {
+	return &GeneratorRequest{workspaceAccess: true, workspace: []byte("secretStore:\n" + secretStore)}
+}
+
+func TestResolveSecretFromWorkspace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/db" || r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data": {"data": {"password": "s3cr3t"}}}`)
+	}))
+	defer server.Close()
+	t.Setenv(VaultTokenEnv, "token")
+
+	req := workspaceRequest("  provider:\n    vault:\n      server: " + server.URL + "\n      path: kv\n      version: v2\n")
+	got, err := req.ResolveSecret("${secret://vault/db#password}")
+	if err != nil {
+		t.Fatalf("ResolveSecret() error = %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Errorf("ResolveSecret() = %q, want s3cr3t", got)
+	}
+
+	if _, err = req.ResolveSecret("secret://aws/db"); err == nil {
+		t.Errorf("ResolveSecret() of a provider not configured in the workspace succeeded")
+	}
+	if _, err = (&GeneratorRequest{}).ResolveSecret("secret://vault/kv/db#password"); err == nil {
+		t.Errorf("ResolveSecret() without workspace access succeeded")
+	}
+
+	t.Setenv(VaultTokenEnv, "")
+	if _, err = req.ResolveSecret("secret://vault/db#password"); errorCode(err) != ErrCodeInvalidConfig {
+		t.Errorf("ResolveSecret() without vault token error = %v, want %s", err, ErrCodeInvalidConfig)
+	}
+}
+
+func TestAWSSecretStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		auth := r.Header.Get("Authorization")
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(auth, "/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" {
+			http.Error(w, `{"message": "invalid signature"}`, http.StatusForbidden)
+			return
+		}
+		if body.SecretId != "prod/db" {
+			http.Error(w, `{"message": "not found"}`, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"password": "s3cr3t", "port": 5432}`})
+	}))
+	defer server.Close()
+
+	t.Setenv(AWSAccessKeyIDEnv, "AKID")
+	t.Setenv(AWSSecretAccessKeyEnv, "secret")
+	t.Setenv(AWSSessionTokenEnv, "session")
+	req := workspaceRequest("  provider:\n    aws:\n      region: us-east-1\n")
+	store, err := req.workspaceSecretStore(AWSSecretStoreProvider)
+	if err != nil {
+		t.Fatalf("workspaceSecretStore() error = %v", err)
+	}
+	store.(*AWSSecretStore).Endpoint = server.URL
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "", want: `{"password": "s3cr3t", "port": 5432}`},
+		{key: "password", want: "s3cr3t"},
+		{key: "port", want: "5432"},
+		{key: "user", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := store.GetSecret(context.Background(), "prod/db", tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("GetSecret() of key %q error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("GetSecret() of key %q = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestAWSSigningKey checks the key derivation against the example of the AWS Signature Version 4 docs.
+func TestAWSSigningKey(t *testing.T) {
+	got := hex.EncodeToString(awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	if want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"; got != want {
+		t.Errorf("awsSigningKey() = %s, want %s", got, want)
+	}
+}
+
+func TestAlicloudSecretStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		signature := params.Get("Signature")
+		params.Del("Signature")
+		if signature != alicloudSignature(http.MethodGet, params, "secret") || params.Get("AccessKeyId") != "AKID" {
+			http.Error(w, `{"Code": "SignatureDoesNotMatch"}`, http.StatusBadRequest)
+			return
+		}
+		if params.Get("Action") != "GetSecretValue" || params.Get("SecretName") != "prod db" {
+			http.Error(w, `{"Code": "Forbidden.ResourceNotFound"}`, http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretData": `{"password": "s3cr3t"}`, "SecretDataType": "text"})
+	}))
+	defer server.Close()
+
+	t.Setenv(AlicloudAccessKeyEnv, "AKID")
+	t.Setenv(AlicloudSecretKeyEnv, "secret")
+	store, err := NewAlicloudSecretStoreFromEnv("cn-hangzhou")
+	if err != nil {
+		t.Fatalf("NewAlicloudSecretStoreFromEnv() error = %v", err)
+	}
+	store.Endpoint = server.URL
+	got, err := store.GetSecret(context.Background(), "prod db", "password")
+	if err != nil {
+		t.Fatalf("GetSecret() error = %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Errorf("GetSecret() = %q, want s3cr3t", got)
+	}
+	if _, err = store.GetSecret(context.Background(), "missing", ""); err == nil {
+		t.Errorf("GetSecret() of a missing secret succeeded")
+	}
+}
+
+func TestAlicloudEscape(t *testing.T) {
+	for in, want := range map[string]string{
+		"a b":      "a%20b",
+		"a*b":      "a%2Ab",
+		"a~b":      "a~b",
+		"a/b=c&d":  "a%2Fb%3Dc%26d",
+		"2024-01Z": "2024-01Z",
+	} {
+		if got := alicloudEscape(in); got != want {
+			t.Errorf("alicloudEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
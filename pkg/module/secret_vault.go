@@ -0,0 +1,91 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VaultSecretStoreProvider is the conventional provider name of VaultSecretStore.
+const VaultSecretStoreProvider = "vault"
+
+// Environment variables of the address and token of VaultSecretStore.
+const (
+	VaultAddrEnv  = "VAULT_ADDR"
+	VaultTokenEnv = "VAULT_TOKEN"
+)
+
+// VaultSecretStore resolves secrets from the KV version 2 secret engine of HashiCorp Vault.
+// The path of a reference is <mount>/<secret path>, e.g. secret://vault/secret/db#password, or
+// the secret path if Mount is set.
+type VaultSecretStore struct {
+	// Address is the address of the Vault server, e.g. https://vault.example.com:8200
+	Address string
+	// Token is the token used to authenticate with Vault
+	Token string
+	// Mount is the mount path of the KV engine, read from the paths of references if empty
+	Mount string
+	// Client is the HTTP client used to call Vault, http.DefaultClient is used if nil
+	Client *http.Client
+}
+
+// NewVaultSecretStoreFromEnv returns a VaultSecretStore configured by the VAULT_ADDR and VAULT_TOKEN
+// environment variables.
+func NewVaultSecretStoreFromEnv() (*VaultSecretStore, error) {
+	addr, token := os.Getenv(VaultAddrEnv), os.Getenv(VaultTokenEnv)
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("%s and %s must be set", VaultAddrEnv, VaultTokenEnv)
+	}
+	return &VaultSecretStore{Address: addr, Token: token}, nil
+}
+
+// GetSecret reads the KV secret at path and returns the value of key. The key must not be empty.
+func (s *VaultSecretStore) GetSecret(ctx context.Context, path, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("key of vault secret %s is empty", path)
+	}
+	mount, secretPath := s.Mount, path
+	if mount == "" {
+		var ok bool
+		if mount, secretPath, ok = strings.Cut(path, "/"); !ok || secretPath == "" {
+			return "", fmt.Errorf("invalid vault secret path %q, expected <mount>/<path>", path)
+		}
+	}
+	mount = strings.Trim(mount, "/")
+	url := strings.TrimSuffix(s.Address, "/") + "/v1/" + mount + "/data/" + secretPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("read vault secret %s failed with status %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode vault response failed. %w", err)
+	}
+	value, ok := body.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in vault secret %s", key, path)
+	}
+	return fmt.Sprint(value), nil
+}
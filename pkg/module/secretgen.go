@@ -0,0 +1,198 @@
+package module
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// ResourceExtensionSecretSalt is the extension key of the salt used by SecretGenerator, stored in
+// the generated resource so that the values stay stable when the secret key changes.
+const ResourceExtensionSecretSalt = "kusion.io/secret-salt"
+
+// Character sets of secret policies.
+const (
+	CharsetLower        = "abcdefghijklmnopqrstuvwxyz"
+	CharsetUpper        = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	CharsetDigits       = "0123456789"
+	CharsetSymbols      = "!#$%&*+-=?@^_"
+	CharsetAlphanumeric = CharsetLower + CharsetUpper + CharsetDigits
+)
+
+// DefaultSecretLength is the length of generated values if the policy does not set one.
+const DefaultSecretLength = 32
+
+// SecretPolicy describes the generated values of SecretGenerator.
+type SecretPolicy struct {
+	// Length is the number of characters, DefaultSecretLength is used if zero
+	Length int
+	// Charset is the characters to pick from, CharsetAlphanumeric is used if empty
+	Charset string
+}
+
+// PlatformConfigSecretKeyKey is the key of the platform module config setting the key the salts of
+// SecretGenerator are derived from, a string or a secret reference resolved by ResolveSecret. It overrides
+// the key of the module set by WithSecretKey, so that every workspace generates different values.
+const PlatformConfigSecretKeyKey = "secretKey"
+
+// WithSecretKey sets the key the salts of SecretGenerator are derived from in workspaces whose platform
+// config does not set PlatformConfigSecretKeyKey.
+func WithSecretKey(key []byte) ServeOption {
+	return func(o *serveOptions) {
+		o.secretKey = key
+	}
+}
+
+// SecretGenerator derives stable random values, such as passwords and tokens, from the project, stack
+// and app of the request and a salt. The salt is derived from the secret key of the workspace or the
+// module, so previews and applies of the same stack generate the same values and show no spurious diffs.
+type SecretGenerator struct {
+	seed string
+	key  []byte
+	salt []byte
+}
+
+// NewSecretGenerator returns a SecretGenerator for the resource with the given ID. The salt stored
+// in the resource in the prior state pins the values, otherwise the salt is derived from the secret key
+// and the resource. Call Store to save the salt in the generated resource.
+func NewSecretGenerator(req *GeneratorRequest, resourceID string) (*SecretGenerator, error) {
+	g := &SecretGenerator{seed: fmt.Sprintf("%s/%s/%s/%s", req.Project, req.Stack, req.App, resourceID)}
+	key, err := req.secretGeneratorKey()
+	if err != nil {
+		return nil, err
+	}
+	g.key = key
+	if prior := req.PriorResource(resourceID); prior != nil {
+		if s, ok := prior.Extensions[ResourceExtensionSecretSalt].(string); ok && s != "" {
+			salt, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("decode secret salt of resource %s failed. %w", resourceID, err)
+			}
+			g.salt = salt
+			return g, nil
+		}
+	}
+	if err = g.Rotate(""); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// secretGeneratorKey returns the secret key in the platform config, or the key of the module.
+func (r *GeneratorRequest) secretGeneratorKey() ([]byte, error) {
+	raw, ok := r.PlatformModuleConfig[PlatformConfigSecretKeyKey]
+	if !ok || raw == nil {
+		return r.secretKey, nil
+	}
+	key, ok := raw.(string)
+	if !ok {
+		return nil, NewError(ErrCodeInvalidConfig, "%s must be a string or a secret reference", PlatformConfigSecretKeyKey)
+	}
+	if IsSecretRef(key) {
+		resolved, err := r.ResolveSecret(key)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s failed. %w", PlatformConfigSecretKeyKey, err)
+		}
+		key = resolved
+	}
+	return []byte(key), nil
+}
+
+// Rotate replaces the salt with the one derived from the secret key and generation, which changes all
+// values. Store the salt afterwards to pin the new values.
+func (g *SecretGenerator) Rotate(generation string) error {
+	if len(g.key) == 0 {
+		return NewError(ErrCodeInvalidConfig, "no secret key to derive the secret salt of %s from", g.seed).
+			WithHint(fmt.Sprintf("set %s in the platform module config, or serve the module with WithSecretKey", PlatformConfigSecretKeyKey))
+	}
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte(ResourceExtensionSecretSalt))
+	mac.Write([]byte{0})
+	mac.Write([]byte(g.seed))
+	mac.Write([]byte{0})
+	mac.Write([]byte(generation))
+	g.salt = mac.Sum(nil)
+	return nil
+}
+
+// Salt returns the hex encoded salt of the generator.
+func (g *SecretGenerator) Salt() string {
+	return hex.EncodeToString(g.salt)
+}
+
+// Store saves the salt in the extensions of res, which must be the resource the generator is created for.
+func (g *SecretGenerator) Store(res *v1.Resource) {
+	if res.Extensions == nil {
+		res.Extensions = map[string]interface{}{}
+	}
+	res.Extensions[ResourceExtensionSecretSalt] = g.Salt()
+}
+
+// Password returns the value named name following policy. The same name always returns the same value.
+func (g *SecretGenerator) Password(name string, policy SecretPolicy) (string, error) {
+	length := policy.Length
+	if length == 0 {
+		length = DefaultSecretLength
+	}
+	charset := policy.Charset
+	if charset == "" {
+		charset = CharsetAlphanumeric
+	}
+	if length < 0 {
+		return "", fmt.Errorf("invalid secret length %d", length)
+	}
+	if len(charset) > 256 {
+		return "", fmt.Errorf("secret charset is longer than 256 characters")
+	}
+
+	// reject bytes beyond the largest multiple of the charset size to avoid modulo bias
+	limit := byte(256 - 256%len(charset))
+	out := make([]byte, 0, length)
+	stream := g.stream(name)
+	for len(out) < length {
+		b := stream()
+		if limit != 0 && b >= limit {
+			continue
+		}
+		out = append(out, charset[int(b)%len(charset)])
+	}
+	return string(out), nil
+}
+
+// Token returns the value named name as a hex string of n random bytes.
+func (g *SecretGenerator) Token(name string, n int) string {
+	out := make([]byte, n)
+	stream := g.stream(name)
+	for i := range out {
+		out[i] = stream()
+	}
+	return hex.EncodeToString(out)
+}
+
+// stream returns a function generating the deterministic byte stream of name by HMAC-SHA256 in counter mode.
+func (g *SecretGenerator) stream(name string) func() byte {
+	var (
+		counter uint64
+		block   []byte
+	)
+	return func() byte {
+		if len(block) == 0 {
+			mac := hmac.New(sha256.New, g.salt)
+			mac.Write([]byte(g.seed))
+			mac.Write([]byte{0})
+			mac.Write([]byte(name))
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], counter)
+			mac.Write(c[:])
+			block = mac.Sum(nil)
+			counter++
+		}
+		b := block[0]
+		block = block[1:]
+		return b
+	}
+}
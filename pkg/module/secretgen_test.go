@@ -0,0 +1,97 @@
+package module
+
+import (
+	"errors"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+func TestNewSecretGenerator(t *testing.T) {
+	const id = "v1:Secret:app:db"
+	newRequest := func(platform v1.GenericConfig, prior ...v1.Resource) *GeneratorRequest {
+		return &GeneratorRequest{
+			Project: "p", Stack: "dev", App: "app",
+			PlatformModuleConfig: platform,
+			PriorState:           prior,
+			secretKey:            []byte("module-key"),
+			secretStores:         map[string]SecretStore{EnvSecretStoreProvider: EnvSecretStore{Prefix: "SECRETGEN_"}},
+		}
+	}
+	password := func(t *testing.T, req *GeneratorRequest) (string, *SecretGenerator) {
+		t.Helper()
+		g, err := NewSecretGenerator(req, id)
+		if err != nil {
+			t.Fatalf("NewSecretGenerator() error = %v", err)
+		}
+		p, err := g.Password("password", SecretPolicy{})
+		if err != nil {
+			t.Fatalf("Password() error = %v", err)
+		}
+		return p, g
+	}
+
+	first, g := password(t, newRequest(nil))
+	if second, _ := password(t, newRequest(nil)); first != second {
+		t.Errorf("first generation without prior state = %q, second = %q, want the same values", first, second)
+	}
+	if len(first) != DefaultSecretLength {
+		t.Errorf("len(password) = %d, want %d", len(first), DefaultSecretLength)
+	}
+
+	workspace, _ := password(t, newRequest(v1.GenericConfig{PlatformConfigSecretKeyKey: "workspace-key"}))
+	if workspace == first {
+		t.Errorf("the secret key of the workspace does not override the key of the module")
+	}
+
+	t.Setenv("SECRETGEN_TEST_KEY", "workspace-key")
+	fromStore, _ := password(t, newRequest(v1.GenericConfig{PlatformConfigSecretKeyKey: "${secret://env/SECRETGEN_TEST_KEY}"}))
+	if fromStore != workspace {
+		t.Errorf("password with the secret key resolved from the store = %q, want %q", fromStore, workspace)
+	}
+
+	res := v1.Resource{ID: id}
+	g.Store(&res)
+	pinned, _ := password(t, newRequest(v1.GenericConfig{PlatformConfigSecretKeyKey: "rotated-key"}, res))
+	if pinned != first {
+		t.Errorf("password with the stored salt = %q, want %q", pinned, first)
+	}
+
+	if err := g.Rotate("2"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated, _ := g.Password("password", SecretPolicy{}); rotated == first {
+		t.Errorf("password after Rotate is unchanged")
+	}
+
+	_, err := NewSecretGenerator(&GeneratorRequest{Project: "p", Stack: "dev", App: "app"}, id)
+	var moduleErr *Error
+	if !errors.As(err, &moduleErr) || moduleErr.Code != ErrCodeInvalidConfig {
+		t.Errorf("NewSecretGenerator() without secret key error = %v, want %s", err, ErrCodeInvalidConfig)
+	}
+}
+
+func TestSecretGeneratorPassword(t *testing.T) {
+	g := &SecretGenerator{seed: "p/dev/app/id", salt: []byte("salt")}
+	tests := []struct {
+		name    string
+		policy  SecretPolicy
+		wantLen int
+		wantErr bool
+	}{
+		{name: "default policy", policy: SecretPolicy{}, wantLen: DefaultSecretLength},
+		{name: "digits", policy: SecretPolicy{Length: 6, Charset: CharsetDigits}, wantLen: 6},
+		{name: "negative length", policy: SecretPolicy{Length: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Password("name", tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Password() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("len(Password()) = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
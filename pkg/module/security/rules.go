@@ -0,0 +1,226 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// DefaultRules returns the built-in rules, covering the common misconfigurations of Kubernetes
+// workloads and AWS resources.
+func DefaultRules() []Rule {
+	return []Rule{
+		{ID: "K8S001", Severity: SeverityCritical, Check: forContainers(privileged)},
+		{ID: "K8S002", Severity: SeverityHigh, Check: forContainers(privilegeEscalation)},
+		{ID: "K8S003", Severity: SeverityHigh, Check: forContainers(dangerousCapabilities)},
+		{ID: "K8S004", Severity: SeverityHigh, Check: hostNamespaces},
+		{ID: "K8S005", Severity: SeverityMedium, Check: hostPathVolumes},
+		{ID: "K8S006", Severity: SeverityMedium, Check: forContainers(runAsRoot)},
+		{ID: "AWS001", Severity: SeverityCritical, Check: publicS3ACL},
+		{ID: "AWS002", Severity: SeverityHigh, Check: s3PublicAccessBlock},
+		{ID: "AWS003", Severity: SeverityHigh, Check: openAdminPorts},
+	}
+}
+
+// podSpec returns the pod spec of Kubernetes workload resources.
+func podSpec(res *v1.Resource) (map[string]any, bool) {
+	if res.Type != v1.Kubernetes {
+		return nil, false
+	}
+	var spec any
+	switch res.Attributes["kind"] {
+	case "Pod":
+		spec = get(res.Attributes, "spec")
+	case "CronJob":
+		spec = get(res.Attributes, "spec", "jobTemplate", "spec", "template", "spec")
+	default:
+		spec = get(res.Attributes, "spec", "template", "spec")
+	}
+	return asMap(spec)
+}
+
+// forContainers checks every container, init container and ephemeral container of workloads.
+func forContainers(check func(container map[string]any) string) func(*v1.Resource) []string {
+	return func(res *v1.Resource) []string {
+		spec, ok := podSpec(res)
+		if !ok {
+			return nil
+		}
+		var out []string
+		for _, key := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			for _, item := range asList(spec[key]) {
+				c, ok := asMap(item)
+				if !ok {
+					continue
+				}
+				if msg := check(c); msg != "" {
+					out = append(out, fmt.Sprintf("container %v %s", c["name"], msg))
+				}
+			}
+		}
+		return out
+	}
+}
+
+func privileged(c map[string]any) string {
+	if get(c, "securityContext", "privileged") == true {
+		return "is privileged"
+	}
+	return ""
+}
+
+func privilegeEscalation(c map[string]any) string {
+	if get(c, "securityContext", "allowPrivilegeEscalation") == true {
+		return "allows privilege escalation"
+	}
+	return ""
+}
+
+func dangerousCapabilities(c map[string]any) string {
+	var added []string
+	for _, capability := range asList(get(c, "securityContext", "capabilities", "add")) {
+		switch s := strings.ToUpper(fmt.Sprint(capability)); s {
+		case "ALL", "SYS_ADMIN", "NET_ADMIN", "SYS_PTRACE", "SYS_MODULE":
+			added = append(added, s)
+		}
+	}
+	if len(added) > 0 {
+		return "adds the capabilities " + strings.Join(added, ", ")
+	}
+	return ""
+}
+
+func runAsRoot(c map[string]any) string {
+	if uid, ok := get(c, "securityContext", "runAsUser").(int); ok && uid == 0 {
+		return "runs as root"
+	}
+	if uid, ok := get(c, "securityContext", "runAsUser").(int64); ok && uid == 0 {
+		return "runs as root"
+	}
+	return ""
+}
+
+func hostNamespaces(res *v1.Resource) []string {
+	spec, ok := podSpec(res)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, key := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		if spec[key] == true {
+			out = append(out, "pod uses "+key)
+		}
+	}
+	return out
+}
+
+func hostPathVolumes(res *v1.Resource) []string {
+	spec, ok := podSpec(res)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range asList(spec["volumes"]) {
+		if p := get(item, "hostPath", "path"); p != nil {
+			out = append(out, fmt.Sprintf("volume %v mounts the host path %v", get(item, "name"), p))
+		}
+	}
+	return out
+}
+
+func tfType(res *v1.Resource) string {
+	if res.Type != v1.Terraform {
+		return ""
+	}
+	t, _ := res.Extensions[module.ResourceExtensionTFResourceType].(string)
+	return t
+}
+
+func publicS3ACL(res *v1.Resource) []string {
+	switch tfType(res) {
+	case "aws_s3_bucket", "aws_s3_bucket_acl":
+	default:
+		return nil
+	}
+	switch acl := fmt.Sprint(res.Attributes["acl"]); acl {
+	case "public-read", "public-read-write", "authenticated-read":
+		return []string{fmt.Sprintf("bucket has the public ACL %s", acl)}
+	}
+	return nil
+}
+
+func s3PublicAccessBlock(res *v1.Resource) []string {
+	if tfType(res) != "aws_s3_bucket_public_access_block" {
+		return nil
+	}
+	var out []string
+	for _, key := range []string{"block_public_acls", "block_public_policy", "ignore_public_acls", "restrict_public_buckets"} {
+		if v, ok := res.Attributes[key]; !ok || v == false {
+			out = append(out, fmt.Sprintf("%s is not enabled", key))
+		}
+	}
+	return out
+}
+
+var adminPorts = []struct {
+	port int
+	name string
+}{{22, "SSH"}, {3389, "RDP"}}
+
+func openAdminPorts(res *v1.Resource) []string {
+	var rules []any
+	switch tfType(res) {
+	case "aws_security_group":
+		rules = asList(res.Attributes["ingress"])
+	case "aws_security_group_rule":
+		if res.Attributes["type"] != "ingress" {
+			return nil
+		}
+		rules = []any{res.Attributes}
+	case "aws_vpc_security_group_ingress_rule":
+		rules = []any{map[string]any{
+			"from_port":   res.Attributes["from_port"],
+			"to_port":     res.Attributes["to_port"],
+			"cidr_blocks": []any{res.Attributes["cidr_ipv4"]},
+		}}
+	default:
+		return nil
+	}
+	var out []string
+	for _, r := range rules {
+		if !openToWorld(get(r, "cidr_blocks")) && !openToWorld(get(r, "ipv6_cidr_blocks")) {
+			continue
+		}
+		from, to := toInt(get(r, "from_port")), toInt(get(r, "to_port"))
+		for _, p := range adminPorts {
+			if (from == 0 && to == 0) || (from <= p.port && p.port <= to) {
+				out = append(out, fmt.Sprintf("ingress allows %s (port %d) from the internet", p.name, p.port))
+			}
+		}
+	}
+	return out
+}
+
+func openToWorld(cidrs any) bool {
+	for _, c := range asList(cidrs) {
+		if s := fmt.Sprint(c); s == "0.0.0.0/0" || s == "::/0" {
+			return true
+		}
+	}
+	return false
+}
+
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return -1
+}
@@ -0,0 +1,205 @@
+// Package security scans the generated resources for insecure settings, such as privileged containers
+// or public S3 buckets, before they reach the engine:
+//
+//	module.Serve(&MyModule{}, module.WithResponseCheck(security.Check(security.NewScanner())))
+//
+// Findings at or above the FailOn severity of the scanner fail the generation, the others are logged and
+// annotated on the Kubernetes resources so that they show up in previews.
+package security
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// FindingsAnnotation is the annotation of Kubernetes resources listing the IDs of the rules they violate.
+const FindingsAnnotation = "security.kusion.io/findings"
+
+// Severity is the severity of a finding.
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	case SeverityCritical:
+		return "critical"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Finding is a violation of a rule by a resource.
+type Finding struct {
+	RuleID     string
+	Severity   Severity
+	ResourceID string
+	Message    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s/%s] %s: %s", f.Severity, f.RuleID, f.ResourceID, f.Message)
+}
+
+// Rule checks a resource and returns the messages of its violations.
+type Rule struct {
+	// ID identifies the rule in findings and annotations, e.g. K8S001
+	ID string
+	// Severity is the severity of the violations
+	Severity Severity
+	// Check returns the violations of the resource, or nil if the resource is not applicable
+	Check func(res *v1.Resource) []string
+}
+
+// Scanner scans resources with rules.
+type Scanner struct {
+	// Rules are the rules checked, DefaultRules if nil
+	Rules []Rule
+	// FailOn is the min severity of findings failing the generation, SeverityCritical if zero
+	FailOn Severity
+	// Skip are the IDs of the rules not checked, e.g. to accept hostPath volumes of node agents
+	Skip []string
+}
+
+// NewScanner returns a scanner of the DefaultRules failing on critical findings.
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+// Scan returns the findings of the resources sorted by resource ID and rule ID.
+func (s *Scanner) Scan(resources []v1.Resource) []Finding {
+	rules := s.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	var findings []Finding
+	for i := range resources {
+		for _, rule := range rules {
+			if containsString(s.Skip, rule.ID) {
+				continue
+			}
+			for _, msg := range rule.Check(&resources[i]) {
+				findings = append(findings, Finding{RuleID: rule.ID, Severity: rule.Severity, ResourceID: resources[i].ID, Message: msg})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].ResourceID != findings[j].ResourceID {
+			return findings[i].ResourceID < findings[j].ResourceID
+		}
+		return findings[i].RuleID < findings[j].RuleID
+	})
+	return findings
+}
+
+// Check returns a module.ResponseCheck scanning the generated resources. Findings below the FailOn
+// severity are logged and annotated on Kubernetes resources.
+func Check(s *Scanner) module.ResponseCheck {
+	return func(ctx context.Context, _ *module.GeneratorRequest, resp *module.GeneratorResponse) error {
+		failOn := s.FailOn
+		if failOn == 0 {
+			failOn = SeverityCritical
+		}
+		var rejected []string
+		annotations := map[string][]string{}
+		for _, f := range s.Scan(resp.Resources) {
+			if f.Severity >= failOn {
+				rejected = append(rejected, f.String())
+				continue
+			}
+			module.LoggerFrom(ctx).Warn("security finding", "rule", f.RuleID, "severity", f.Severity.String(), "resource", f.ResourceID, "message", f.Message)
+			annotations[f.ResourceID] = append(annotations[f.ResourceID], f.RuleID)
+		}
+		if len(rejected) > 0 {
+			return module.NewError(module.ErrCodeInvalidConfig, "%d security findings: %s", len(rejected), strings.Join(rejected, "; ")).
+				WithHint("fix the insecure settings, or ask platform engineers to skip the rules for this module")
+		}
+		for i := range resp.Resources {
+			if ids := annotations[resp.Resources[i].ID]; len(ids) > 0 && resp.Resources[i].Type == v1.Kubernetes {
+				annotate(&resp.Resources[i], strings.Join(dedup(ids), ","))
+			}
+		}
+		return nil
+	}
+}
+
+func annotate(res *v1.Resource, value string) {
+	meta, ok := asMap(res.Attributes["metadata"])
+	if !ok {
+		return
+	}
+	annotations, ok := asMap(meta["annotations"])
+	if !ok {
+		annotations = map[string]any{}
+	}
+	annotations[FindingsAnnotation] = value
+	meta["annotations"] = annotations
+	res.Attributes["metadata"] = meta
+}
+
+func dedup(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if !containsString(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// asMap converts the map types of resource attributes into a map with string keys. Maps with
+// interface keys decoded by yaml.v2 are copied.
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, m != nil
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func get(v any, keys ...string) any {
+	for _, k := range keys {
+		m, ok := asMap(v)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+func asList(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
@@ -0,0 +1,225 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// ResourceEncodingMetadataKey is the gRPC request header with which hosts ask for the wire encoding
+// of the generated resources, and the response header with which plugins confirm the encoding used.
+// The proto request has no field for it, so hosts not sending the header get YAML as before.
+const ResourceEncodingMetadataKey = "kusion-module-resource-encoding"
+
+// Built-in resource encodings. JSON is a subset of YAML, so JSON resources are still readable by hosts
+// decoding them as YAML.
+const (
+	EncodingYAML = "yaml"
+	EncodingJSON = "json"
+)
+
+// Serializer encodes and decodes the resources sent over the wire.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	serializersMu sync.RWMutex
+	serializers   = map[string]Serializer{
+		EncodingYAML: yamlSerializer{},
+		EncodingJSON: jsonSerializer{},
+	}
+)
+
+// RegisterSerializer registers s as the serializer of encoding, replacing the existing one.
+func RegisterSerializer(encoding string, s Serializer) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+	serializers[encoding] = s
+}
+
+// SerializerFor returns the serializer of encoding, or the YAML serializer if encoding is empty.
+func SerializerFor(encoding string) (Serializer, error) {
+	if encoding == "" {
+		encoding = EncodingYAML
+	}
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+	s, ok := serializers[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported resource encoding %q", encoding)
+	}
+	return s, nil
+}
+
+// ContextWithResourceEncoding returns a copy of ctx asking module plugins for resources in encoding,
+// used by hosts of modules.
+func ContextWithResourceEncoding(ctx context.Context, encoding string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ResourceEncodingMetadataKey, encoding)
+}
+
+// negotiateSerializer returns the serializer asked by the host and confirms it in the response header.
+func negotiateSerializer(ctx context.Context) (Serializer, error) {
+	encoding := incomingMetadata(ctx, ResourceEncodingMetadataKey)
+	s, err := SerializerFor(encoding)
+	if err != nil || encoding == "" {
+		return s, err
+	}
+	if err = setHeader(ctx, ResourceEncodingMetadataKey, encoding); err != nil {
+		return nil, NewError(ErrCodeInternal, "send resource encoding failed: %v", err)
+	}
+	return s, nil
+}
+
+// bufferPool pools the buffers resources are encoded into, which grow to the size of big responses.
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity above which buffers are dropped instead of pooled, so that a single
+// huge response does not pin its memory.
+const maxPooledBuffer = 64 << 20
+
+// marshalResources encodes the resources with s. The built-in serializers encode all resources into a
+// pooled buffer, which is copied into one allocation shared by the encoded resources, instead of growing
+// a buffer for every resource separately.
+func marshalResources(s Serializer, resources []v1.Resource) ([][]byte, error) {
+	out := make([][]byte, len(resources))
+	switch s.(type) {
+	case yamlSerializer, jsonSerializer:
+	default:
+		for i := range resources {
+			data, err := s.Marshal(resources[i])
+			if err != nil {
+				return nil, fmt.Errorf("marshal resource failed: %w. res:%v", err, resources[i])
+			}
+			out[i] = data
+		}
+		return out, nil
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+	ends := make([]int, len(resources))
+	var err error
+	switch s.(type) {
+	case yamlSerializer:
+		err = encodeYAML(buf, resources, ends)
+	case jsonSerializer:
+		err = encodeJSON(buf, resources, ends)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	start := 0
+	for i, end := range ends {
+		out[i] = data[start:end:end]
+		start = end
+	}
+	return out, nil
+}
+
+// encodeYAML encodes the resources as YAML documents into buf, recording the end offset of every
+// document in ends. Every document gets its own encoder, as the event queue of a yaml.v2 encoder keeps
+// growing over the documents it encodes.
+func encodeYAML(buf *bytes.Buffer, resources []v1.Resource, ends []int) error {
+	for i := range resources {
+		encoder := yaml.NewEncoder(buf)
+		if err := encoder.Encode(resources[i]); err != nil {
+			return fmt.Errorf("marshal resource failed: %w. res:%v", err, resources[i])
+		}
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("marshal resource failed: %w. res:%v", err, resources[i])
+		}
+		ends[i] = buf.Len()
+	}
+	return nil
+}
+
+// encodeJSON encodes the resources as JSON into buf, recording the end offset of every resource in ends.
+func encodeJSON(buf *bytes.Buffer, resources []v1.Resource, ends []int) error {
+	encoder := json.NewEncoder(buf)
+	for i := range resources {
+		if err := encoder.Encode(resources[i]); err != nil {
+			var typeErr *json.UnsupportedTypeError
+			if !errors.As(err, &typeErr) {
+				return fmt.Errorf("marshal resource failed: %w. res:%v", err, resources[i])
+			}
+			data, err := jsonSerializer{}.Marshal(resources[i])
+			if err != nil {
+				return fmt.Errorf("marshal resource failed: %w. res:%v", err, resources[i])
+			}
+			buf.Write(data)
+		} else {
+			// the encoder terminates every value with a newline, which json.Marshal does not
+			buf.Truncate(buf.Len() - 1)
+		}
+		ends[i] = buf.Len()
+	}
+	return nil
+}
+
+type yamlSerializer struct{}
+
+func (yamlSerializer) Marshal(v interface{}) ([]byte, error) { return yaml.Marshal(v) }
+
+func (yamlSerializer) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v interface{}) ([]byte, error) {
+	out, err := json.Marshal(v)
+	var typeErr *json.UnsupportedTypeError
+	if errors.As(err, &typeErr) {
+		// attributes decoded by yaml.v2 contain maps with interface keys, which JSON does not support
+		data, yamlErr := yaml.Marshal(v)
+		if yamlErr != nil {
+			return nil, err
+		}
+		var generic interface{}
+		if yamlErr = yaml.Unmarshal(data, &generic); yamlErr != nil {
+			return nil, err
+		}
+		return json.Marshal(normalizeKeys(generic))
+	}
+	return out, err
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// normalizeKeys converts the maps with interface keys in v into maps with string keys.
+func normalizeKeys(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = normalizeKeys(item)
+		}
+		return m
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeKeys(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeKeys(item)
+		}
+		return val
+	}
+	return v
+}
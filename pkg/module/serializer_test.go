@@ -0,0 +1,97 @@
+package module
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// marshalEach encodes every resource on its own, like marshalResources of custom serializers.
+func marshalEach(s Serializer, resources []v1.Resource) ([][]byte, error) {
+	out := make([][]byte, len(resources))
+	for i := range resources {
+		data, err := s.Marshal(resources[i])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+func TestMarshalResources(t *testing.T) {
+	resources := testResources(1000)
+	for _, encoding := range []string{EncodingYAML, EncodingJSON} {
+		t.Run(encoding, func(t *testing.T) {
+			s, err := SerializerFor(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := marshalResources(s, resources)
+			if err != nil {
+				t.Fatalf("marshalResources() error = %v", err)
+			}
+			want, err := marshalEach(s, resources)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range want {
+				if !bytes.Equal(bytes.TrimSpace(got[i]), bytes.TrimSpace(want[i])) {
+					t.Fatalf("resource %d = %s, want %s", i, got[i], want[i])
+				}
+			}
+			// the encoded resources must not share capacity, so appending to one can not overwrite the next
+			if cap(got[0]) != len(got[0]) {
+				t.Errorf("cap of an encoded resource = %d, want its len %d", cap(got[0]), len(got[0]))
+			}
+		})
+	}
+}
+
+func TestMarshalResourcesAllocs(t *testing.T) {
+	resources := testResources(1000)
+	for _, encoding := range []string{EncodingYAML, EncodingJSON} {
+		t.Run(encoding, func(t *testing.T) {
+			s, err := SerializerFor(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pooled := testing.AllocsPerRun(3, func() { _, _ = marshalResources(s, resources) })
+			each := testing.AllocsPerRun(3, func() { _, _ = marshalEach(s, resources) })
+			t.Logf("%d resources: %.0f allocations pooled, %.0f allocations encoding each", len(resources), pooled, each)
+			if pooled >= each {
+				t.Errorf("marshalResources() allocates %.0f times, want less than %.0f of encoding every resource", pooled, each)
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalResources(b *testing.B) {
+	for _, n := range []int{1000, 5000} {
+		resources := testResources(n)
+		for _, encoding := range []string{EncodingYAML, EncodingJSON} {
+			s, err := SerializerFor(encoding)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, bc := range []struct {
+				name    string
+				marshal func(Serializer, []v1.Resource) ([][]byte, error)
+			}{
+				{name: "pooled", marshal: marshalResources},
+				{name: "each", marshal: marshalEach},
+			} {
+				b.Run(fmt.Sprintf("resources=%d/%s/%s", n, encoding, bc.name), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := bc.marshal(s, resources); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// ServeOption customizes how a module is served.
+type ServeOption func(*serveOptions)
+
+type serveOptions struct {
+	pluginOptions
+	logger   hclog.Logger
+	name     string
+	info     ModuleInfo
+	maxMsg   int
+	resolver Resolver
+	strict   bool
+	mutators []ResourceMutator
+	checks   []ResponseCheck
+	timeout  time.Duration
+	crashDir string
+
+	metricsAddr string
+	envDefaults EnvironmentDefaults
+
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
+	tls                *TLSConfig
+	authToken          string
+	insecureRemote     bool
+	defaultsFile       string
+	reloadInterval     time.Duration
+	limits             Limits
+	lazyWorkload       bool
+	redactKeys         []*regexp.Regexp
+	requestLogLevel    hclog.Level
+	httpAddr           string
+	devConfig          any
+	sandbox            *Sandbox
+	chaos              *Chaos
+	chaosFromEnv       bool
+	secretKey          []byte
+	secretStores       map[string]SecretStore
+}
+
+// WithMetricsAddr starts an HTTP listener on addr serving Prometheus metrics on /metrics, such as
+// Generate counts, durations, errors and cache hit rates. Metrics are not served if addr is empty.
+func WithMetricsAddr(addr string) ServeOption {
+	return func(o *serveOptions) {
+		o.metricsAddr = addr
+	}
+}
+
+// WithModuleName sets the module name used to tag logs. Defaults to the name of the executable.
+func WithModuleName(name string) ServeOption {
+	return func(o *serveOptions) {
+		o.name = name
+	}
+}
+
+// WithLogger sets the logger used by the plugin server and as the root logger of module logs.
+func WithLogger(logger hclog.Logger) ServeOption {
+	return func(o *serveOptions) {
+		o.logger = logger
+	}
+}
+
+// WithMaxMessageSize sets the max size of responses of Generate, which should match the max
+// receive message size of the engine. Defaults to DefaultMaxMessageSize.
+func WithMaxMessageSize(size int) ServeOption {
+	return func(o *serveOptions) {
+		o.maxMsg = size
+	}
+}
+
+// Serve serves the FrameworkModule as a Kusion module plugin over gRPC and blocks until
+// the plugin is shut down by the host. The health service is registered by go-plugin itself.
+// With the LocalFlag argument, a single request is served from stdin instead, and modules built for
+// wasip1 serve a single request envelope of WASMModule.
+//
+// A typical main function of a module is:
+//
+//	func main() {
+//		module.Serve(&MyModule{})
+//	}
+func Serve(m FrameworkModule, opts ...ServeOption) {
+	o := newServeOptions(opts)
+	wrapper := o.wrapper(m)
+	stopReload, err := o.watchDefaults(wrapper)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve module failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer stopReload()
+	o.serve(wrapper)
+}
+
+func newServeOptions(opts []ServeOption) *serveOptions {
+	o := &serveOptions{name: filepath.Base(os.Args[0])}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// wrapper returns the FrameworkModuleWrapper serving m with the options.
+func (o *serveOptions) wrapper(m FrameworkModule) *FrameworkModuleWrapper {
+	return &FrameworkModuleWrapper{
+		Module:              m,
+		Name:                o.name,
+		Logger:              o.logger,
+		ModuleInfo:          o.info,
+		MaxMessageSize:      o.maxMsg,
+		Resolver:            o.resolver,
+		StrictDecoding:      o.strict,
+		Mutators:            o.mutators,
+		Checks:              o.checks,
+		EnvironmentDefaults: o.envDefaults,
+		LazyWorkload:        o.lazyWorkload,
+		Sandbox:             o.sandbox,
+		RedactKeys:          o.redactKeys,
+		RequestLogLevel:     o.requestLogLevel,
+		Limits:              o.limits,
+		Chaos:               o.chaosConfig(),
+		SecretKey:           o.secretKey,
+		SecretStores:        o.secretStores,
+		Timeout:             o.timeout,
+		CrashDir:            o.crashDir,
+	}
+}
+
+// startTelemetry starts the metrics server and the tracing of the module, and returns the function
+// stopping them.
+func (o *serveOptions) startTelemetry() (stop func()) {
+	var stops []func()
+	if o.metricsAddr != "" {
+		stopMetrics := startMetricsServer(o.metricsAddr)
+		stops = append(stops, func() {
+			if err := stopMetrics(context.Background()); err != nil {
+				log.Errorf("stop metrics server failed: %v", err)
+			}
+		})
+	}
+
+	shutdownTracing, err := setupTracing(context.Background(), o.name)
+	if err != nil {
+		log.Errorf("setup tracing failed, spans are not exported: %v", err)
+	} else {
+		stops = append(stops, func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Errorf("shutdown tracing failed: %v", err)
+			}
+		})
+	}
+	return func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+}
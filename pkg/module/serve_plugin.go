@@ -0,0 +1,93 @@
+//go:build !wasip1
+
+package module
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"kusionstack.io/kusion/pkg/log"
+	"kusionstack.io/kusion/pkg/modules"
+)
+
+// HandshakeConfig is a common handshake that is shared by plugin and host.
+var HandshakeConfig = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "MODULE_PLUGIN",
+	MagicCookieValue: "ON",
+}
+
+// pluginOptions are the options of modules served as go-plugin plugins.
+type pluginOptions struct {
+	handshake *plugin.HandshakeConfig
+}
+
+// WithHandshakeConfig overrides the default HandshakeConfig.
+func WithHandshakeConfig(handshake plugin.HandshakeConfig) ServeOption {
+	return func(o *serveOptions) {
+		o.handshake = &handshake
+	}
+}
+
+func protocolVersion() uint {
+	return HandshakeConfig.ProtocolVersion
+}
+
+// serve serves the wrapper as a go-plugin plugin, or a single request in the local mode.
+func (o *serveOptions) serve(wrapper *FrameworkModuleWrapper) {
+	if localMode(os.Args[1:]) {
+		err := serveLocal(context.Background(), wrapper, os.Stdin, os.Stdout)
+		if cleanupErr := wrapper.Cleanup(context.Background()); cleanupErr != nil {
+			log.Errorf("cleanup module failed: %v", cleanupErr)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "generate failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	defer func() {
+		if err := wrapper.Cleanup(context.Background()); err != nil {
+			log.Errorf("cleanup module failed: %v", err)
+		}
+	}()
+	defer o.startTelemetry()()
+	defer o.startHTTP(wrapper)()
+
+	handshake := HandshakeConfig
+	if o.handshake != nil {
+		handshake = *o.handshake
+	}
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: handshake,
+		Plugins: map[string]plugin.Plugin{
+			modules.PluginKey: newGRPCPlugin(wrapper),
+		},
+		Logger:      o.logger,
+		TLSProvider: o.tlsProvider(),
+
+		// A non-nil value here enables gRPC serving for this plugin...
+		GRPCServer: o.grpcServer(),
+	})
+}
+
+// grpcPlugin extends the Kusion module plugin with the framework service.
+type grpcPlugin struct {
+	modules.GRPCPlugin
+	wrapper *FrameworkModuleWrapper
+}
+
+func newGRPCPlugin(wrapper *FrameworkModuleWrapper) *grpcPlugin {
+	return &grpcPlugin{GRPCPlugin: modules.GRPCPlugin{Impl: wrapper}, wrapper: wrapper}
+}
+
+func (p *grpcPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	if err := p.GRPCPlugin.GRPCServer(broker, s); err != nil {
+		return err
+	}
+	s.RegisterService(&frameworkServiceDesc, p.wrapper)
+	return nil
+}
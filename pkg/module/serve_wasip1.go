@@ -0,0 +1,35 @@
+//go:build wasip1
+
+package module
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"kusionstack.io/kusion/pkg/log"
+)
+
+// pluginOptions are empty for wasip1, as the guest modules of WASMModule are not go-plugin plugins.
+type pluginOptions struct{}
+
+func protocolVersion() uint {
+	return 1
+}
+
+// newDefaultResolver resolves the registered modules only, as wasip1 guests cannot start plugins.
+func newDefaultResolver() Resolver {
+	return defaultRegistry
+}
+
+// serve serves the single request envelope of WASMModule read from stdin.
+func (o *serveOptions) serve(wrapper *FrameworkModuleWrapper) {
+	err := serveWASM(context.Background(), wrapper, os.Stdin, os.Stdout)
+	if cleanupErr := wrapper.Cleanup(context.Background()); cleanupErr != nil {
+		log.Errorf("cleanup module failed: %v", cleanupErr)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve module failed: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,50 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// PriorStateMetadataKey is the gRPC request header carrying the YAML encoded resources of the
+// stack in the state backend, sent by engines supporting the prior state of modules.
+const PriorStateMetadataKey = "kusion-module-prior-state-bin"
+
+// ContextWithPriorState returns a copy of ctx sending the resources in the state backend to module
+// plugins, used by hosts of modules.
+func ContextWithPriorState(ctx context.Context, resources []v1.Resource) (context.Context, error) {
+	out, err := yaml.Marshal(resources)
+	if err != nil {
+		return nil, fmt.Errorf("marshal prior state failed. %w", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, PriorStateMetadataKey, string(out)), nil
+}
+
+// decodePriorState decodes the prior state sent in the request metadata, which is empty if the
+// engine has not sent it.
+func decodePriorState(ctx context.Context) ([]v1.Resource, error) {
+	data := incomingMetadata(ctx, PriorStateMetadataKey)
+	if data == "" {
+		return nil, nil
+	}
+	var resources []v1.Resource
+	if err := yaml.Unmarshal([]byte(data), &resources); err != nil {
+		return nil, fmt.Errorf("unmarshal prior state failed. %w", err)
+	}
+	return resources, nil
+}
+
+// PriorResource returns the resource with the given ID in the prior state, or nil if the resource
+// has not been applied yet. Modules can use it to adopt existing resources or to keep immutable
+// fields like generated passwords stable.
+func (r *GeneratorRequest) PriorResource(id string) *v1.Resource {
+	for i := range r.PriorState {
+		if r.PriorState[i].ID == id {
+			return &r.PriorState[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+const (
+	// DefaultMaxMessageSize is the default max size of gRPC messages received by the engine.
+	DefaultMaxMessageSize = 4 * 1024 * 1024
+	// DefaultChunkSize is the size of the chunks of resources sent by GenerateStream.
+	DefaultChunkSize = 1024 * 1024
+)
+
+var generateStreamDesc = grpc.StreamDesc{
+	StreamName:    "GenerateStream",
+	Handler:       generateStreamHandler,
+	ServerStreams: true,
+}
+
+// generateStreamHandler serves GenerateStream, the streaming variant of Generate for responses larger
+// than the max gRPC message size. The request is a JSON encoded proto GeneratorRequest, and every marshaled
+// resource of the response is sent in chunks of at most DefaultChunkSize bytes followed by an empty chunk.
+func generateStreamHandler(srv any, stream grpc.ServerStream) error {
+	in := &wrapperspb.BytesValue{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	req := &proto.GeneratorRequest{}
+	if err := json.Unmarshal(in.GetValue(), req); err != nil {
+		return NewError(ErrCodeInvalidRequest, "unmarshal stream generator request failed: %v", err)
+	}
+	resp, err := srv.(*FrameworkModuleWrapper).generate(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for _, res := range resp.Resources {
+		for start := 0; start < len(res); start += DefaultChunkSize {
+			end := start + DefaultChunkSize
+			if end > len(res) {
+				end = len(res)
+			}
+			if err = stream.SendMsg(wrapperspb.Bytes(res[start:end])); err != nil {
+				return err
+			}
+		}
+		if err = stream.SendMsg(wrapperspb.Bytes(nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateStream calls the GenerateStream RPC of the module plugin served on conn and assembles
+// the chunks into a GeneratorResponse, used by hosts to receive responses exceeding the max gRPC
+// message size. The returned response header carries the patcher, outputs, costs and resource encoding
+// like the header of Generate.
+func GenerateStream(ctx context.Context, conn grpc.ClientConnInterface, req *proto.GeneratorRequest,
+	opts ...grpc.CallOption,
+) (*proto.GeneratorResponse, metadata.MD, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal generator request failed. %w", err)
+	}
+	stream, err := conn.NewStream(ctx, &generateStreamDesc, "/"+FrameworkServiceName+"/GenerateStream", opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = stream.SendMsg(wrapperspb.Bytes(data)); err != nil {
+		return nil, nil, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, nil, err
+	}
+
+	resp := &proto.GeneratorResponse{}
+	var header metadata.MD
+	var current []byte
+	for received := false; ; received = true {
+		chunk := &wrapperspb.BytesValue{}
+		recvErr := stream.RecvMsg(chunk)
+		if recvErr != nil && !errors.Is(recvErr, io.EOF) {
+			return nil, nil, recvErr
+		}
+		if !received {
+			// the header arrives with the first message, or with the status if no resource is sent
+			if header, err = stream.Header(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if recvErr != nil {
+			break
+		}
+		if len(chunk.GetValue()) == 0 {
+			resp.Resources = append(resp.Resources, current)
+			current = nil
+			continue
+		}
+		current = append(current, chunk.GetValue()...)
+	}
+	if current != nil {
+		return nil, nil, fmt.Errorf("generate stream ended in the middle of a resource")
+	}
+	return resp, header, nil
+}
+
+// checkMessageSize returns an error if the response exceeds the max message size of the engine.
+func (f *FrameworkModuleWrapper) checkMessageSize(resp *proto.GeneratorResponse) error {
+	limit := f.MaxMessageSize
+	if limit <= 0 {
+		limit = DefaultMaxMessageSize
+	}
+	size := 0
+	for _, res := range resp.Resources {
+		size += len(res)
+	}
+	if size > limit {
+		return NewError(ErrCodeInternal, "generated resources of %d bytes exceed the max message size of %d bytes", size, limit).
+			WithHint("reduce the generated resources or call GenerateStream from the engine")
+	}
+	return nil
+}
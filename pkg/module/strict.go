@@ -0,0 +1,200 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+	"kusionstack.io/kusion/pkg/modules/proto"
+
+	"kusionstack.io/kusion-module-framework/pkg/internal/yamlfield"
+)
+
+// WithStrictDecoding makes the wrapper reject requests with duplicated keys, and makes DecodeDevConfig
+// and DecodePlatformConfig report fields unknown to the module, so that config typos fail the generation
+// instead of being silently ignored.
+func WithStrictDecoding() ServeOption {
+	return func(o *serveOptions) {
+		o.strict = true
+	}
+}
+
+// DecodeError is a problem of a YAML document found by strict decoding.
+type DecodeError struct {
+	// Path is the full YAML path of the key, e.g. devModuleConfig.database.sizee
+	Path string
+	// Field is the Go field the key failed on, e.g. Config.Database, which is empty for duplicated keys
+	Field string
+	// Reason describes the problem
+	Reason string
+}
+
+func (e *DecodeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s in %s", e.Path, e.Reason, e.Field)
+}
+
+// DecodeErrors is all problems found by strict decoding.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// checkDuplicateKeys returns the keys defined more than once in the YAML document data.
+func checkDuplicateKeys(data []byte, root string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var errs DecodeErrors
+	walkDuplicateKeys(&doc, root, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func walkDuplicateKeys(n *yamlv3.Node, path string, errs *DecodeErrors) {
+	switch n.Kind {
+	case yamlv3.DocumentNode:
+		for _, c := range n.Content {
+			walkDuplicateKeys(c, path, errs)
+		}
+	case yamlv3.MappingNode:
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			keyPath := joinYAMLPath(path, key)
+			if seen[key] {
+				*errs = append(*errs, &DecodeError{Path: keyPath, Reason: fmt.Sprintf("duplicated key at line %d", n.Content[i].Line)})
+			}
+			seen[key] = true
+			walkDuplicateKeys(n.Content[i+1], keyPath, errs)
+		}
+	case yamlv3.SequenceNode:
+		for i, c := range n.Content {
+			walkDuplicateKeys(c, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// checkUnknownFields returns the keys of the decoded config value that do not exist in the type t.
+func checkUnknownFields(value interface{}, t reflect.Type, path, field string) DecodeErrors {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()) {
+		return nil
+	}
+	var errs DecodeErrors
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := asStringMap(value)
+		if !ok {
+			return nil
+		}
+		fields := map[string]reflect.StructField{}
+		inlineMap := collectYAMLFields(t, fields)
+		for _, key := range sortedKeys(m) {
+			keyPath := joinYAMLPath(path, key)
+			f, ok := fields[key]
+			if !ok && inlineMap {
+				continue
+			}
+			if !ok {
+				errs = append(errs, &DecodeError{Path: keyPath, Field: field, Reason: fmt.Sprintf("unknown field %q", key)})
+				continue
+			}
+			errs = append(errs, checkUnknownFields(m[key], f.Type, keyPath, field+"."+f.Name)...)
+		}
+	case reflect.Map:
+		m, ok := asStringMap(value)
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(m) {
+			errs = append(errs, checkUnknownFields(m[key], t.Elem(), joinYAMLPath(path, key), field+"["+key+"]")...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			errs = append(errs, checkUnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%s[%d]", field, i))...)
+		}
+	}
+	return errs
+}
+
+// collectYAMLFields collects the fields of struct t by their yaml keys, following the rules of yaml.v2.
+// It returns true if t has an inline map, which accepts any key.
+func collectYAMLFields(t reflect.Type, fields map[string]reflect.StructField) (inlineMap bool) {
+	_ = yamlfield.Walk(t, func(f yamlfield.Field) error {
+		switch {
+		case !f.YAML.Inline:
+			fields[f.YAML.Name] = f.StructField
+		case f.Type.Kind() == reflect.Map:
+			inlineMap = true
+		}
+		return nil
+	})
+	return inlineMap
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkRequestKeys checks the raw YAML documents of the proto request for duplicated keys.
+func checkRequestKeys(req *proto.GeneratorRequest) error {
+	var errs DecodeErrors
+	for _, doc := range []struct {
+		root string
+		data []byte
+	}{
+		{"workload", req.Workload},
+		{"devModuleConfig", req.DevModuleConfig},
+		{"platformModuleConfig", req.PlatformModuleConfig},
+		{"runtimeConfig", req.RuntimeConfig},
+	} {
+		err := checkDuplicateKeys(doc.data, doc.root)
+		var decodeErrs DecodeErrors
+		switch {
+		case errors.As(err, &decodeErrs):
+			errs = append(errs, decodeErrs...)
+		case err != nil:
+			return fmt.Errorf("parse %s failed. %w", doc.root, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func joinYAMLPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
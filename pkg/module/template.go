@@ -0,0 +1,488 @@
+package module
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MaxTemplateOutput is the max size of the output of a rendered template.
+const MaxTemplateOutput = 16 * 1024 * 1024
+
+// maxIncludeDepth limits the recursion of include, so that templates including themselves fail
+// instead of exhausting the stack.
+const maxIncludeDepth = 64
+
+var errTemplateOutputTooLarge = fmt.Errorf("template output exceeds %d bytes", MaxTemplateOutput)
+
+// TemplateFuncs returns the functions available to templates rendered by RenderTemplate and RenderDir.
+// They are a small hand-written subset of the sprig functions, and toYaml and fromYaml of Helm, with the
+// same names, arguments and results, except that:
+//   - functions failing on invalid input in sprig's must variants, i.e. regexMatch, b64dec, atoi, toYaml,
+//     fromYaml and fromJson, return the error instead of a zero value,
+//   - repeat and until fail on negative counts and beyond MaxTemplateOutput,
+//   - div and mod fail on division by zero instead of panicking,
+//   - keys returns the keys sorted.
+//
+// Functions not listed here are not available. Functions reading the environment, files, the clock or
+// random sources are left out, so that the output only depends on the data and modules stay reproducible.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// defaults
+		"default":  defaultValue,
+		"empty":    isEmpty,
+		"coalesce": coalesce,
+		"required": required,
+		"ternary":  func(vt, vf any, cond bool) any { return ternary(vt, vf, cond) },
+		// strings
+		"quote":      func(v ...any) string { return quoteAll(v, func(s any) string { return strconv.Quote(toString(s)) }) },
+		"squote":     func(v ...any) string { return quoteAll(v, func(s any) string { return fmt.Sprintf("'%v'", s) }) },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"repeat":     repeat,
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"toString":   toString,
+		// regular expressions
+		"regexMatch":      func(re, s string) (bool, error) { return regexp.MatchString(re, s) },
+		"regexReplaceAll": regexReplaceAll,
+		// encodings
+		"toYaml":    toYAML,
+		"fromYaml":  fromYAML,
+		"toJson":    toJSON,
+		"fromJson":  fromJSON,
+		"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":    b64dec,
+		"sha256sum": func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+		// collections
+		"list":   func(items ...any) []any { return items },
+		"dict":   dict,
+		"keys":   keys,
+		"hasKey": func(m map[string]any, key string) bool { _, ok := m[key]; return ok },
+		"get":    get,
+		"until":  until,
+		// arithmetic
+		"int":  toInt,
+		"add":  func(v ...any) int64 { return foldSum(0, v, func(x, y int64) int64 { return x + y }) },
+		"sub":  func(a, b any) int64 { return toInt(a) - toInt(b) },
+		"mul":  func(a any, v ...any) int64 { return foldSum(toInt(a), v, func(x, y int64) int64 { return x * y }) },
+		"div":  intDiv,
+		"mod":  intMod,
+		"max":  func(a any, rest ...any) int64 { return foldInt(a, rest, func(x, y int64) bool { return y > x }) },
+		"min":  func(a any, rest ...any) int64 { return foldInt(a, rest, func(x, y int64) bool { return y < x }) },
+		"atoi": strconv.Atoi,
+	}
+}
+
+// RenderTemplate renders the Go template tmpl with data, e.g. the generator request or the decoded
+// module config, and the TemplateFuncs. Referencing missing map keys fails the rendering, use get or
+// hasKey for optional keys, e.g. {{ get .config "replicas" | default 1 }}.
+func RenderTemplate(tmpl string, data any) (string, error) {
+	t, err := newTemplate("template").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse template failed. %w", err)
+	}
+	out, err := executeTemplate(t, "template", data)
+	if err != nil {
+		return "", fmt.Errorf("render template failed. %w", err)
+	}
+	return out, nil
+}
+
+// RenderDir renders every template under dir of fsys, e.g. templates embedded in the module binary,
+// and returns the outputs keyed by the paths relative to dir without the .tmpl suffix. All templates
+// are parsed together so that they can include each other by path. Files whose base names start with
+// an underscore only define partials and are not rendered.
+func RenderDir(fsys fs.FS, dir string, data any) (map[string]string, error) {
+	t := newTemplate(dir)
+	var names []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(p, dir+"/")
+		if _, err = t.New(name).Parse(string(content)); err != nil {
+			return fmt.Errorf("parse template %s failed. %w", p, err)
+		}
+		if !strings.HasPrefix(path.Base(name), "_") {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load templates from %s failed. %w", dir, err)
+	}
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		rendered, err := executeTemplate(t, name, data)
+		if err != nil {
+			return nil, fmt.Errorf("render template %s failed. %w", name, err)
+		}
+		out[strings.TrimSuffix(name, ".tmpl")] = rendered
+	}
+	return out, nil
+}
+
+// newTemplate returns a template with the TemplateFuncs and an include function executing the
+// templates associated with it, like include of Helm.
+func newTemplate(name string) *template.Template {
+	t := template.New(name).Option("missingkey=error")
+	funcs := TemplateFuncs()
+	depth := 0
+	funcs["include"] = func(name string, data any) (string, error) {
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("include %s exceeds the max depth %d", name, maxIncludeDepth)
+		}
+		depth++
+		defer func() { depth-- }()
+		var buf bytes.Buffer
+		if err := t.ExecuteTemplate(&limitedWriter{w: &buf, n: MaxTemplateOutput}, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return t.Funcs(funcs)
+}
+
+func executeTemplate(t *template.Template, name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&limitedWriter{w: &buf, n: MaxTemplateOutput}, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// limitedWriter fails writes beyond n bytes, bounding the output of runaway templates.
+type limitedWriter struct {
+	w *bytes.Buffer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.w.Len()+len(p) > l.n {
+		return 0, errTemplateOutputTooLarge
+	}
+	return l.w.Write(p)
+}
+
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return rv.IsZero()
+}
+
+// defaultValue returns def if v is missing or empty, so that {{ .x | default "y" }} works when piped.
+func defaultValue(def any, v ...any) any {
+	if len(v) == 0 || isEmpty(v[0]) {
+		return def
+	}
+	return v[0]
+}
+
+// quoteAll quotes the values other than nil and joins them with spaces.
+func quoteAll(values []any, quote func(any) string) string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			out = append(out, quote(v))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func ternary(vt, vf any, cond bool) any {
+	if cond {
+		return vt
+	}
+	return vf
+}
+
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func required(msg string, v any) (any, error) {
+	if v == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case error:
+		return s.Error()
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// title upper-cases the first letter of every word as the deprecated strings.Title does.
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		defer func() { prev = r }()
+		if isSeparator(prev) {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// isSeparator reports whether r separates words, the same way as strings.Title.
+func isSeparator(r rune) bool {
+	if r <= unicode.MaxASCII {
+		switch {
+		case '0' <= r && r <= '9', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', r == '_':
+			return false
+		}
+		return true
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return false
+	}
+	return unicode.IsSpace(r)
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// join joins the items of the list v other than nil, or v itself if it is not a list.
+func join(sep string, v any) string {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(v)
+	}
+	parts := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if item := rv.Index(i).Interface(); item != nil {
+			parts = append(parts, toString(item))
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func regexReplaceAll(re, s, repl string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return r.ReplaceAllString(s, repl), nil
+}
+
+func toYAML(v any) (string, error) {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func fromYAML(s string) (map[string]any, error) {
+	m := map[string]any{}
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func toJSON(v any) (string, error) {
+	out, err := jsonSerializer{}.Marshal(v)
+	return string(out), err
+}
+
+func fromJSON(s string) (any, error) {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func b64dec(s string) (string, error) {
+	out, err := base64.StdEncoding.DecodeString(s)
+	return string(out), err
+}
+
+// dict builds a map of pairs of keys and values, a last key without a value maps to "".
+func dict(pairs ...any) map[string]any {
+	m := make(map[string]any, (len(pairs)+1)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		var v any = ""
+		if i+1 < len(pairs) {
+			v = pairs[i+1]
+		}
+		m[toString(pairs[i])] = v
+	}
+	return m
+}
+
+func keys(dicts ...map[string]any) []string {
+	var out []string
+	for _, m := range dicts {
+		for k := range m {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// get returns the value of key in m, or "" if m has no key.
+func get(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return ""
+}
+
+func repeat(count int, s string) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("repeat count %d is negative", count)
+	}
+	if len(s) > 0 && count > MaxTemplateOutput/len(s) {
+		return "", errTemplateOutputTooLarge
+	}
+	return strings.Repeat(s, count), nil
+}
+
+func until(n int) ([]int, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("until count %d is negative", n)
+	}
+	if n > MaxTemplateOutput {
+		return nil, fmt.Errorf("until count %d exceeds %d", n, MaxTemplateOutput)
+	}
+	out := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+func toInt(v any) int64 {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float())
+	case reflect.String:
+		// strings are parsed as spf13/cast does for sprig, with base prefixes and zero decimals, e.g. 0x10 and 3.0
+		n, _ := strconv.ParseInt(trimZeroDecimal(rv.String()), 0, 64)
+		return n
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// trimZeroDecimal trims decimals consisting of zeros, e.g. 3.00 to 3.
+func trimZeroDecimal(s string) string {
+	foundZero := false
+	for i := len(s); i > 0; i-- {
+		switch s[i-1] {
+		case '.':
+			if foundZero {
+				return s[:i-1]
+			}
+			return s
+		case '0':
+			foundZero = true
+		default:
+			return s
+		}
+	}
+	return s
+}
+
+func intDiv(a, b any) (int64, error) {
+	if toInt(b) == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return toInt(a) / toInt(b), nil
+}
+
+func intMod(a, b any) (int64, error) {
+	if toInt(b) == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return toInt(a) % toInt(b), nil
+}
+
+func foldInt(first any, rest []any, better func(cur, next int64) bool) int64 {
+	cur := toInt(first)
+	for _, v := range rest {
+		if n := toInt(v); better(cur, n) {
+			cur = n
+		}
+	}
+	return cur
+}
+
+func foldSum(first int64, rest []any, op func(x, y int64) int64) int64 {
+	cur := first
+	for _, v := range rest {
+		cur = op(cur, toInt(v))
+	}
+	return cur
+}
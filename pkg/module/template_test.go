@@ -0,0 +1,212 @@
+package module
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := map[string]any{"name": "web", "config": map[string]any{"replicas": 3}}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{tmpl: `{{ .name | upper | quote }}`, want: `"WEB"`},
+		{tmpl: `{{ get .config "image" | default "nginx" }}`, want: "nginx"},
+		{tmpl: `{{ get .config "replicas" | default 1 }}`, want: "3"},
+		{tmpl: `{{ repeat 3 "ab" }}`, want: "ababab"},
+		{tmpl: `{{ range until 3 }}{{ . }}{{ end }}`, want: "012"},
+		{tmpl: `{{ dict "a" 1 | toJson }}`, want: `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := RenderTemplate(tt.tmpl, data)
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		tooLong bool
+	}{
+		{name: "negative repeat", tmpl: `{{ repeat -1 "a" }}`},
+		{name: "huge repeat", tmpl: `{{ repeat 1000000000 "abcdefgh" }}`, tooLong: true},
+		{name: "negative until", tmpl: `{{ range until -1 }}{{ end }}`},
+		{name: "huge until", tmpl: `{{ range until 1000000000 }}{{ end }}`},
+		{name: "output beyond the limit", tmpl: `{{ range until 17 }}{{ repeat 1048576 "a" }}{{ end }}`, tooLong: true},
+		{name: "recursive include", tmpl: `{{ define "loop" }}{{ include "loop" . }}{{ end }}{{ include "loop" . }}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RenderTemplate(tt.tmpl, nil)
+			if err == nil {
+				t.Fatalf("RenderTemplate() succeeded")
+			}
+			if tt.tooLong && !errors.Is(err, errTemplateOutputTooLarge) {
+				t.Errorf("RenderTemplate() error = %v, want %v", err, errTemplateOutputTooLarge)
+			}
+		})
+	}
+}
+
+func TestRenderDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/_helpers.tmpl":     {Data: []byte(`{{ define "labels" }}app: {{ .name }}{{ end }}`)},
+		"templates/service.yaml.tmpl": {Data: []byte(`labels: {{ include "labels" . | nindent 2 }}`)},
+	}
+	got, err := RenderDir(fsys, "templates", map[string]any{"name": "web"})
+	if err != nil {
+		t.Fatalf("RenderDir() error = %v", err)
+	}
+	if len(got) != 1 || strings.TrimSpace(got["service.yaml"]) != "labels: \n  app: web" {
+		t.Errorf("RenderDir() = %q", got)
+	}
+}
+
+// TestTemplateFuncsSprig compares the functions with the documented behaviour of sprig, and of Helm for
+// toYaml and fromYaml, including their edge cases.
+func TestTemplateFuncsSprig(t *testing.T) {
+	data := map[string]any{
+		"nil":    nil,
+		"zero":   0,
+		"struct": struct{ A int }{},
+		"list":   []any{1, nil, "b"},
+		"config": map[string]any{"b": 2, "a": 1},
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		// defaults
+		{tmpl: `{{ default "foo" .nil }}`, want: "foo"},
+		{tmpl: `{{ default "foo" .zero }}`, want: "foo"},
+		{tmpl: `{{ default "foo" false }}`, want: "foo"},
+		{tmpl: `{{ default "foo" "" }}`, want: "foo"},
+		{tmpl: `{{ default "foo" list }}`, want: "foo"},
+		{tmpl: `{{ default "foo" dict }}`, want: "foo"},
+		{tmpl: `{{ default "foo" "bar" }}`, want: "bar"},
+		{tmpl: `{{ default "foo" }}`, want: "foo"},
+		{tmpl: `{{ empty .struct }}`, want: "false"},
+		{tmpl: `{{ empty 0.0 }}`, want: "true"},
+		{tmpl: `{{ empty .nil }}`, want: "true"},
+		{tmpl: `{{ coalesce 0 "" "x" "y" }}`, want: "x"},
+		{tmpl: `{{ coalesce 0 "" }}`, want: "<no value>"},
+		{tmpl: `{{ required "missing" "x" }}`, want: "x"},
+		{tmpl: `{{ required "missing" 0 }}`, want: "0"},
+		{tmpl: `{{ ternary "yes" "no" true }}`, want: "yes"},
+		{tmpl: `{{ false | ternary "yes" "no" }}`, want: "no"},
+		// strings
+		{tmpl: `{{ quote "a\"b" }}`, want: `"a\"b"`},
+		{tmpl: `{{ quote "a" 1 .nil "c" }}`, want: `"a" "1" "c"`},
+		{tmpl: `{{ quote .nil }}`, want: ""},
+		{tmpl: `{{ squote "a" 1 .nil }}`, want: `'a' '1'`},
+		{tmpl: `{{ upper "hello" }}`, want: "HELLO"},
+		{tmpl: `{{ lower "HELLO" }}`, want: "hello"},
+		{tmpl: `{{ title "hello world" }}`, want: "Hello World"},
+		{tmpl: `{{ title "hello-world foo_bar x.y" }}`, want: "Hello-World Foo_bar X.Y"},
+		{tmpl: `{{ trim "  hello \n" }}`, want: "hello"},
+		{tmpl: `{{ trimPrefix "-" "-hello" }}`, want: "hello"},
+		{tmpl: `{{ trimSuffix "-" "hello-" }}`, want: "hello"},
+		{tmpl: `{{ hasPrefix "cat" "catch" }}`, want: "true"},
+		{tmpl: `{{ hasSuffix "cat" "catch" }}`, want: "false"},
+		{tmpl: `{{ contains "cat" "catch" }}`, want: "true"},
+		{tmpl: `{{ "I Am Henry VIII" | replace " " "-" }}`, want: "I-Am-Henry-VIII"},
+		{tmpl: `{{ repeat 3 "hello" }}`, want: "hellohellohello"},
+		{tmpl: `{{ indent 2 "a\nb" }}`, want: "  a\n  b"},
+		{tmpl: `{{ nindent 2 "a" }}`, want: "\n  a"},
+		{tmpl: `{{ splitList "$" "foo$bar$baz" | join "," }}`, want: "foo,bar,baz"},
+		{tmpl: `{{ join "," .list }}`, want: "1,b"},
+		{tmpl: `{{ join "," "a" }}`, want: "a"},
+		{tmpl: `{{ join "," .nil }}`, want: ""},
+		{tmpl: `{{ toString 1.5 }}`, want: "1.5"},
+		{tmpl: `{{ toString .nil }}`, want: "<nil>"},
+		// regular expressions
+		{tmpl: `{{ regexMatch "^[a-z]+@[a-z]+\\.com$" "test@acme.com" }}`, want: "true"},
+		{tmpl: `{{ regexReplaceAll "a(x*)b" "-ab-axxb-" "${1}W" }}`, want: "-W-xxW-"},
+		// encodings
+		{tmpl: `{{ toYaml .config }}`, want: "a: 1\nb: 2"},
+		{tmpl: `{{ (fromYaml "a: 1").a }}`, want: "1"},
+		{tmpl: `{{ toJson .config }}`, want: `{"a":1,"b":2}`},
+		{tmpl: `{{ toJson "<a>" }}`, want: `"\u003ca\u003e"`},
+		{tmpl: `{{ (fromJson "{\"a\":1}").a }}`, want: "1"},
+		{tmpl: `{{ index (fromJson "[1,2]") 1 }}`, want: "2"},
+		{tmpl: `{{ b64enc "hello" }}`, want: "aGVsbG8="},
+		{tmpl: `{{ b64dec "aGVsbG8=" }}`, want: "hello"},
+		{tmpl: `{{ sha256sum "Hello world!" }}`, want: "c0535e4be2b79ffd93291305436bf889314e4a3faec05ecffcbb7df31ad9e51a"},
+		// collections
+		{tmpl: `{{ list 1 "a" | len }}`, want: "2"},
+		{tmpl: `{{ (dict "a" 1 "b").b | quote }}`, want: `""`},
+		{tmpl: `{{ dict 1 2 | toJson }}`, want: `{"1":2}`},
+		{tmpl: `{{ keys .config | join "," }}`, want: "a,b"},
+		{tmpl: `{{ keys (dict "a" 1) (dict "b" 2) | join "," }}`, want: "a,b"},
+		{tmpl: `{{ hasKey .config "a" }}`, want: "true"},
+		{tmpl: `{{ get .config "a" }}`, want: "1"},
+		{tmpl: `{{ get .config "z" | quote }}`, want: `""`},
+		{tmpl: `{{ range until 3 }}{{ . }}{{ end }}`, want: "012"},
+		{tmpl: `{{ range until 0 }}{{ . }}{{ end }}`, want: ""},
+		// arithmetic
+		{tmpl: `{{ int "42" }}`, want: "42"},
+		{tmpl: `{{ int "0x10" }}`, want: "16"},
+		{tmpl: `{{ int "3.0" }}`, want: "3"},
+		{tmpl: `{{ int "3.5" }}`, want: "0"},
+		{tmpl: `{{ int 3.9 }}`, want: "3"},
+		{tmpl: `{{ int true }}`, want: "1"},
+		{tmpl: `{{ int "abc" }}`, want: "0"},
+		{tmpl: `{{ add 1 2 3 }}`, want: "6"},
+		{tmpl: `{{ add }}`, want: "0"},
+		{tmpl: `{{ add "1" 2.5 }}`, want: "3"},
+		{tmpl: `{{ sub 3 5 }}`, want: "-2"},
+		{tmpl: `{{ mul 2 3 4 }}`, want: "24"},
+		{tmpl: `{{ mul 2 }}`, want: "2"},
+		{tmpl: `{{ div 7 2 }}`, want: "3"},
+		{tmpl: `{{ mod 7 2 }}`, want: "1"},
+		{tmpl: `{{ max 1 5 3 }}`, want: "5"},
+		{tmpl: `{{ min 4 2 3 }}`, want: "2"},
+		{tmpl: `{{ atoi "12" }}`, want: "12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := RenderTemplate(tt.tmpl, data)
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTemplateFuncsErrors covers the documented differences to sprig, where invalid input fails the
+// rendering instead of returning a zero value or panicking.
+func TestTemplateFuncsErrors(t *testing.T) {
+	tests := []string{
+		`{{ required "missing" "" }}`,
+		`{{ required "missing" .nil }}`,
+		`{{ regexMatch "(" "a" }}`,
+		`{{ regexReplaceAll "(" "a" "b" }}`,
+		`{{ b64dec "%%" }}`,
+		`{{ fromYaml "a: [" }}`,
+		`{{ fromJson "{" }}`,
+		`{{ atoi " 12" }}`,
+		`{{ div 1 0 }}`,
+		`{{ mod 1 0 }}`,
+	}
+	for _, tmpl := range tests {
+		t.Run(tmpl, func(t *testing.T) {
+			if _, err := RenderTemplate(tmpl, map[string]any{"nil": nil}); err == nil {
+				t.Errorf("RenderTemplate() succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// Extension keys of Terraform resources expected by the Kusion Terraform runtime.
+const (
+	ResourceExtensionTFProvider     = "provider"
+	ResourceExtensionTFProviderMeta = "providerMeta"
+	ResourceExtensionTFResourceType = "resourceType"
+)
+
+// DefaultTFRegistry is the registry host used when the provider source does not contain one.
+const DefaultTFRegistry = "registry.terraform.io"
+
+// TFProviderConfig describes the Terraform provider that manages a resource.
+type TFProviderConfig struct {
+	// Source is the provider source address, e.g. hashicorp/aws or registry.terraform.io/hashicorp/aws
+	Source string `json:"source" yaml:"source"`
+	// Version is the provider version, e.g. 5.0.1
+	Version string `json:"version" yaml:"version"`
+	// Region is the region of the cloud provider, stored in the provider meta if not empty
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// Meta is the additional provider meta, such as credentials or endpoints
+	Meta map[string]any `json:"meta,omitempty" yaml:"meta,omitempty"`
+}
+
+// ParseTFProviderURL parses a provider URL in the form of [host/]namespace/name/version into a TFProviderConfig.
+func ParseTFProviderURL(url string) (*TFProviderConfig, error) {
+	idx := strings.LastIndex(url, "/")
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid terraform provider url %q, expected [host/]namespace/name/version", url)
+	}
+	c := &TFProviderConfig{Source: url[:idx], Version: url[idx+1:]}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks whether the provider source and version are valid.
+func (c *TFProviderConfig) Validate() error {
+	if c.Version == "" {
+		return fmt.Errorf("version of terraform provider %q is empty", c.Source)
+	}
+	if _, _, _, err := c.parseSource(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Namespace returns the namespace of the provider, e.g. hashicorp.
+func (c *TFProviderConfig) Namespace() string {
+	_, namespace, _, _ := c.parseSource()
+	return namespace
+}
+
+// Name returns the name of the provider, e.g. aws.
+func (c *TFProviderConfig) Name() string {
+	_, _, name, _ := c.parseSource()
+	return name
+}
+
+// URL returns the provider URL in the form of host/namespace/name/version.
+func (c *TFProviderConfig) URL() string {
+	host, namespace, name, _ := c.parseSource()
+	return strings.Join([]string{host, namespace, name, c.Version}, "/")
+}
+
+// ProviderMeta returns the provider meta with the region set.
+func (c *TFProviderConfig) ProviderMeta() map[string]any {
+	meta := make(map[string]any, len(c.Meta)+1)
+	for k, v := range c.Meta {
+		meta[k] = v
+	}
+	if c.Region != "" {
+		meta["region"] = c.Region
+	}
+	return meta
+}
+
+// Extensions returns the resource extensions of a Terraform resource of resourceType managed by this provider.
+func (c *TFProviderConfig) Extensions(resourceType string) map[string]any {
+	return map[string]any{
+		ResourceExtensionTFProvider:     c.URL(),
+		ResourceExtensionTFProviderMeta: c.ProviderMeta(),
+		ResourceExtensionTFResourceType: resourceType,
+	}
+}
+
+// ResourceID returns the unique ID of a Terraform resource managed by this provider.
+func (c *TFProviderConfig) ResourceID(resourceType, name string) string {
+	// resource id example: hashicorp:aws:aws_db_instance:mysql
+	return c.Namespace() + ":" + c.Name() + ":" + resourceType + ":" + name
+}
+
+// WrapResource wraps the attributes of a Terraform resource managed by this provider into a Kusion resource.
+func (c *TFProviderConfig) WrapResource(resourceType, name string, attrs map[string]any) (v1.Resource, error) {
+	if err := c.Validate(); err != nil {
+		return v1.Resource{}, err
+	}
+	if resourceType == "" || name == "" {
+		return v1.Resource{}, fmt.Errorf("resource type and name of terraform resource must not be empty")
+	}
+	return v1.Resource{
+		ID:         c.ResourceID(resourceType, name),
+		Type:       v1.Terraform,
+		Attributes: attrs,
+		DependsOn:  nil,
+		Extensions: c.Extensions(resourceType),
+	}, nil
+}
+
+// WrapTFResource wraps the attributes of a Terraform resource into a Kusion resource. The provider
+// is the provider URL in the form of [host/]namespace/name/version, e.g. registry.terraform.io/hashicorp/aws/5.0.1.
+func WrapTFResource(provider, resourceType, name string, attrs map[string]any) (v1.Resource, error) {
+	c, err := ParseTFProviderURL(provider)
+	if err != nil {
+		return v1.Resource{}, err
+	}
+	return c.WrapResource(resourceType, name, attrs)
+}
+
+func (c *TFProviderConfig) parseSource() (host, namespace, name string, err error) {
+	parts := strings.Split(c.Source, "/")
+	switch len(parts) {
+	case 2:
+		host, namespace, name = DefaultTFRegistry, parts[0], parts[1]
+	case 3:
+		host, namespace, name = parts[0], parts[1], parts[2]
+	default:
+		return "", "", "", fmt.Errorf("invalid terraform provider source %q, expected [host/]namespace/name", c.Source)
+	}
+	if host == "" || namespace == "" || name == "" {
+		return "", "", "", fmt.Errorf("invalid terraform provider source %q, expected [host/]namespace/name", c.Source)
+	}
+	return host, namespace, name, nil
+}
+
+// TFProviderFromRuntimeConfig returns the config of the Terraform provider named name, e.g. aws, in the
+// runtime configs of the workspace. The region in the provider config is moved to the Region field and
+// the other fields are kept as the provider meta. If the provider is not configured, def is returned.
+func TFProviderFromRuntimeConfig(rc *v1.RuntimeConfigs, name string, def *TFProviderConfig) (*TFProviderConfig, error) {
+	if rc == nil || rc.Terraform == nil || rc.Terraform[name] == nil {
+		if def == nil {
+			return nil, fmt.Errorf("terraform provider %q is not found in the runtime config", name)
+		}
+		c := *def
+		return &c, nil
+	}
+	pc := rc.Terraform[name]
+	c := &TFProviderConfig{Source: pc.Source, Version: pc.Version}
+	if def != nil {
+		if c.Source == "" {
+			c.Source = def.Source
+		}
+		if c.Version == "" {
+			c.Version = def.Version
+		}
+		c.Region = def.Region
+	}
+	if len(pc.GenericConfig) > 0 {
+		c.Meta = make(map[string]any, len(pc.GenericConfig))
+		for k, v := range pc.GenericConfig {
+			if region, ok := v.(string); ok && k == "region" {
+				c.Region = region
+				continue
+			}
+			c.Meta[k] = v
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,54 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// BenchmarkModule benchmarks m with the GeneratorRequest in the fixture file, e.g.
+// testdata/default.request.yaml. Every iteration runs the full wrapper round trip, so the request
+// decoding and resource marshaling are measured along with Generate. Besides the ns/op and allocations,
+// the average duration of each wrapper phase is reported as decode-ns/op, validate-ns/op, queue-ns/op,
+// generate-ns/op and marshal-ns/op, so latency regressions can be tracked with benchstat.
+func BenchmarkModule(b *testing.B, m module.FrameworkModule, fixture string) {
+	b.Helper()
+
+	protoReq, err := loadRequest(b, fixture).ToProto()
+	if err != nil {
+		b.Fatalf("convert fixture %s failed: %v", fixture, err)
+	}
+	var total module.GenerateTimings
+	wrapper := &module.FrameworkModuleWrapper{
+		Module: m,
+		Logger: hclog.NewNullLogger(),
+		OnTimings: func(t module.GenerateTimings) {
+			total.Decode += t.Decode
+			total.Validate += t.Validate
+			total.Queue += t.Queue
+			total.Generate += t.Generate
+			total.Marshal += t.Marshal
+		},
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = wrapper.Generate(ctx, protoReq); err != nil {
+			b.Fatalf("generate with fixture %s failed: %v", fixture, err)
+		}
+	}
+	b.StopTimer()
+
+	perOp := func(d time.Duration) float64 { return float64(d.Nanoseconds()) / float64(b.N) }
+	b.ReportMetric(perOp(total.Decode), "decode-ns/op")
+	b.ReportMetric(perOp(total.Validate), "validate-ns/op")
+	b.ReportMetric(perOp(total.Queue), "queue-ns/op")
+	b.ReportMetric(perOp(total.Generate), "generate-ns/op")
+	b.ReportMetric(perOp(total.Marshal), "marshal-ns/op")
+}
@@ -0,0 +1,45 @@
+package testutil_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+	"kusionstack.io/kusion-module-framework/pkg/module/testutil"
+)
+
+// configMapModule generates a ConfigMap per replica set in the dev module config.
+type configMapModule struct{}
+
+func (configMapModule) Generate(_ context.Context, req *module.GeneratorRequest) (*module.GeneratorResponse, error) {
+	var config struct {
+		Replicas int `yaml:"replicas"`
+	}
+	if err := req.DecodeDevConfig(&config); err != nil {
+		return nil, err
+	}
+	resources := make([]v1.Resource, 0, config.Replicas)
+	for i := 0; i < config.Replicas; i++ {
+		name := fmt.Sprintf("%s-%d", req.App, i)
+		resources = append(resources, v1.Resource{
+			ID:   "v1:ConfigMap:" + req.App + ":" + name,
+			Type: v1.Kubernetes,
+			Attributes: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": name, "namespace": req.App},
+				"data":       map[string]any{"stack": req.Stack},
+			},
+		})
+	}
+	return &module.GeneratorResponse{Resources: resources}, nil
+}
+
+// BenchmarkModule shows the benchmark of a module generated by kusion-module init, reporting the
+// phases of the wrapper along with ns/op and allocations.
+func BenchmarkModule(b *testing.B) {
+	testutil.BenchmarkModule(b, configMapModule{}, "testdata/default.request.yaml")
+}
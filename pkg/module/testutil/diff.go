@@ -0,0 +1,161 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// AssertResourceEqual fails the test with a unified diff if the resources differ. Like DiffResources,
+// scalars are compared by value regardless of their YAML typing.
+func AssertResourceEqual(tb testing.TB, want, got v1.Resource) {
+	tb.Helper()
+
+	if diff := DiffResources([]v1.Resource{want}, []v1.Resource{got}); diff != "" {
+		tb.Errorf("resource %s does not match\n%s", want.ID, diff)
+	}
+}
+
+// AssertResourcesEqual fails the test with a unified diff if the resource sets differ, see DiffResources.
+func AssertResourcesEqual(tb testing.TB, want, got []v1.Resource) {
+	tb.Helper()
+
+	if diff := DiffResources(want, got); diff != "" {
+		tb.Errorf("resources do not match\n%s", diff)
+	}
+}
+
+// DiffResources returns the unified diff of the YAML of the resource sets, or an empty string if they
+// are equal. The resources are compared regardless of their order, and scalars by value regardless of
+// their YAML typing, e.g. the ints decoded from YAML equal the float64 decoded from JSON, and 80 equals
+// "80", so that resources built from typed objects compare equal to the ones in fixtures.
+func DiffResources(want, got []v1.Resource) string {
+	return unifiedDiff(normalizeResources(want), normalizeResources(got))
+}
+
+// normalizeResources returns the YAML of the normalized resources sorted by ID.
+func normalizeResources(resources []v1.Resource) string {
+	sorted := append([]v1.Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	out := make([]any, 0, len(sorted))
+	for _, res := range sorted {
+		out = append(out, normalizeResource(res))
+	}
+	data, err := yaml.Marshal(out)
+	if err != nil {
+		return "marshal resources failed: " + err.Error()
+	}
+	return string(data)
+}
+
+// normalizeResource converts res into generic maps, slices and scalars through JSON, converting numbers and
+// numeric or boolean strings into int64, float64 and bool values.
+func normalizeResource(res v1.Resource) any {
+	res.Attributes, _ = stringKeys(res.Attributes).(map[string]any)
+	res.Extensions, _ = stringKeys(res.Extensions).(map[string]any)
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err.Error()
+	}
+	var out any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&out); err != nil {
+		return err.Error()
+	}
+	return normalizeScalars(out)
+}
+
+// stringKeys returns a copy of v with the maps with interface keys decoded by yaml.v2, which JSON cannot
+// encode, converted into maps with string keys.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return out
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = stringKeys(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = stringKeys(item)
+		}
+		return out
+	}
+	return v
+}
+
+func normalizeScalars(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalizeScalars(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeScalars(item)
+		}
+		return v
+	case json.Number:
+		return normalizeNumber(v.String(), v)
+	case string:
+		if v == "true" || v == "false" {
+			return v == "true"
+		}
+		return normalizeNumber(v, v)
+	}
+	return v
+}
+
+// normalizeNumber returns s as an int64 if it is an integer, as a float64 if it is a number, or
+// fallback otherwise.
+func normalizeNumber(s string, fallback any) any {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fallback
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+	return f
+}
+
+// unifiedDiff returns the unified diff of the lines of want and got, or an empty string if they are equal.
+func unifiedDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(want),
+		B:        difflib.SplitLines(got),
+		FromFile: "want",
+		ToFile:   "got",
+		Context:  3,
+	})
+	if err != nil {
+		return "diff failed: " + err.Error()
+	}
+	return diff
+}
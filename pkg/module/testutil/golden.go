@@ -0,0 +1,93 @@
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+const (
+	requestSuffix = ".request.yaml"
+	goldenSuffix  = ".golden.yaml"
+)
+
+var update = flag.Bool("update", false, "update the golden files of module tests")
+
+// RunGoldenTests runs a subtest for every <name>.request.yaml fixture in testdataDir. Each fixture is
+// decoded into a GeneratorRequest and passed to the Generate method of m, and the YAML encoded
+// GeneratorResponse is compared with <name>.golden.yaml. Run tests with -update to rewrite the golden files.
+func RunGoldenTests(t *testing.T, m module.FrameworkModule, testdataDir string) {
+	t.Helper()
+
+	fixtures, err := filepath.Glob(filepath.Join(testdataDir, "*"+requestSuffix))
+	if err != nil {
+		t.Fatalf("list fixtures in %s failed: %v", testdataDir, err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no %s fixtures found in %s", requestSuffix, testdataDir)
+	}
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+		name := strings.TrimSuffix(filepath.Base(fixture), requestSuffix)
+		t.Run(name, func(t *testing.T) {
+			got := generate(t, m, fixture)
+			goldenFile := filepath.Join(testdataDir, name+goldenSuffix)
+			if *update {
+				if err := os.WriteFile(goldenFile, got, 0o644); err != nil {
+					t.Fatalf("update golden file %s failed: %v", goldenFile, err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("read golden file %s failed: %v, run with -update to create it", goldenFile, err)
+			}
+			if !bytes.Equal(want, got) {
+				t.Errorf("output of %s does not match %s, run with -update to accept it\n%s",
+					fixture, goldenFile, unifiedDiff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+func generate(t *testing.T, m module.FrameworkModule, fixture string) []byte {
+	t.Helper()
+
+	req := loadRequest(t, fixture)
+	resp, err := m.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("generate with fixture %s failed: %v", fixture, err)
+	}
+	if resp == nil {
+		resp = &module.GeneratorResponse{}
+	}
+	out, err := yaml.Marshal(resp)
+	if err != nil {
+		t.Fatalf("marshal response failed: %v", err)
+	}
+	return out
+}
+
+// loadRequest decodes the GeneratorRequest in the fixture file.
+func loadRequest(tb testing.TB, fixture string) *module.GeneratorRequest {
+	tb.Helper()
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		tb.Fatalf("read fixture %s failed: %v", fixture, err)
+	}
+	req := &module.GeneratorRequest{}
+	if err = yaml.Unmarshal(data, req); err != nil {
+		tb.Fatalf("unmarshal fixture %s failed: %v", fixture, err)
+	}
+	return req
+}
@@ -0,0 +1,111 @@
+//go:build !wasip1
+
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v2"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload/container"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// DefaultEngineTimeout is the deadline PluginClient sets on calls without one, like the engine bounds
+// the generation of every module.
+const DefaultEngineTimeout = 30 * time.Second
+
+// PluginClient calls a module plugin binary over real gRPC with the same options as the engine,
+// for end-to-end tests of modules.
+type PluginClient struct {
+	// Conn is the connection to the plugin, on which the module and framework services can be called
+	Conn *grpc.ClientConn
+	// Timeout is the deadline of calls without one, DefaultEngineTimeout by default
+	Timeout time.Duration
+	// MaxMessageSize is the max size of responses received, module.DefaultMaxMessageSize by default
+	MaxMessageSize int
+}
+
+// BuildPlugin builds the module plugin in the Go package dir into a temporary binary and returns its path.
+func BuildPlugin(tb testing.TB, dir string) string {
+	tb.Helper()
+
+	out := filepath.Join(tb.TempDir(), "kusion-module-test")
+	cmd := exec.Command("go", "build", "-o", out, ".")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("build module plugin failed: %v\n%s", err, output)
+	}
+	return out
+}
+
+// StartPlugin launches the module plugin binary at binaryPath through the plugin handshake and stops
+// it when the test ends.
+func StartPlugin(tb testing.TB, binaryPath string) *PluginClient {
+	tb.Helper()
+
+	conn, kill, err := module.LaunchPlugin(binaryPath, nil)
+	if err != nil {
+		tb.Fatalf("launch module plugin failed: %v", err)
+	}
+	tb.Cleanup(kill)
+	return &PluginClient{Conn: conn, Timeout: DefaultEngineTimeout, MaxMessageSize: module.DefaultMaxMessageSize}
+}
+
+// Generate sends req to the plugin and decodes the response like the engine does.
+func (c *PluginClient) Generate(ctx context.Context, req *module.GeneratorRequest) (*module.GeneratorResponse, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := c.Timeout
+		if timeout <= 0 {
+			timeout = DefaultEngineTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	maxSize := c.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = module.DefaultMaxMessageSize
+	}
+	return module.NewClientModule(c.Conn, grpc.MaxCallRecvMsgSize(maxSize)).Generate(ctx, req)
+}
+
+// MustGenerate is like Generate but fails the test on errors.
+func (c *PluginClient) MustGenerate(tb testing.TB, req *module.GeneratorRequest) *module.GeneratorResponse {
+	tb.Helper()
+
+	resp, err := c.Generate(context.Background(), req)
+	if err != nil {
+		tb.Fatalf("generate over grpc failed: %v", err)
+	}
+	return resp
+}
+
+// Info returns the metadata of the plugin.
+func (c *PluginClient) Info(ctx context.Context) (*module.ModuleInfo, error) {
+	return module.GetModuleInfo(ctx, c.Conn)
+}
+
+// LargeWorkload returns a service workload with the containers, each of which has envs environment
+// variables of valueSize bytes, to test modules and the max message size with large payloads.
+func LargeWorkload(containers, envs, valueSize int) *workload.Workload {
+	w := DefaultServiceWorkload()
+	w.Service.Containers = make(map[string]container.Container, containers)
+	value := strings.Repeat("x", valueSize)
+	for i := 0; i < containers; i++ {
+		c := container.Container{Image: DefaultImage}
+		for j := 0; j < envs; j++ {
+			c.Env = append(c.Env, yaml.MapItem{Key: fmt.Sprintf("ENV_%d", j), Value: value})
+		}
+		w.Service.Containers[fmt.Sprintf("container-%d", i)] = c
+	}
+	return w
+}
@@ -0,0 +1,153 @@
+package testutil
+
+import (
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload/container"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Default values of the requests built by RequestBuilder.
+const (
+	DefaultProject = "test-project"
+	DefaultStack   = "dev"
+	DefaultApp     = "test-app"
+	DefaultImage   = "nginx:latest"
+)
+
+// RequestBuilder builds GeneratorRequests for module unit tests.
+type RequestBuilder struct {
+	req *module.GeneratorRequest
+}
+
+// NewRequestBuilder returns a RequestBuilder whose request has default project, stack and app names
+// and a service workload with a single container named "main".
+func NewRequestBuilder() *RequestBuilder {
+	b := &RequestBuilder{req: &module.GeneratorRequest{
+		Project: DefaultProject,
+		Stack:   DefaultStack,
+		App:     DefaultApp,
+	}}
+	return b.WithWorkloads(DefaultServiceWorkload())
+}
+
+// DefaultServiceWorkload returns a service workload with a single container named "main" listening on port 80.
+func DefaultServiceWorkload() *workload.Workload {
+	return &workload.Workload{
+		Header: workload.Header{Type: workload.TypeService},
+		Service: &workload.Service{
+			Base: workload.Base{
+				Containers: map[string]container.Container{
+					"main": {Image: DefaultImage},
+				},
+			},
+			Ports: []workload.Port{{Port: 80, Protocol: "TCP"}},
+		},
+	}
+}
+
+// WithProject sets the project name.
+func (b *RequestBuilder) WithProject(project string) *RequestBuilder {
+	b.req.Project = project
+	return b
+}
+
+// WithStack sets the stack name.
+func (b *RequestBuilder) WithStack(stack string) *RequestBuilder {
+	b.req.Stack = stack
+	return b
+}
+
+// WithApp sets the application name.
+func (b *RequestBuilder) WithApp(app string) *RequestBuilder {
+	b.req.App = app
+	return b
+}
+
+// WithWorkloadService sets a service workload.
+func (b *RequestBuilder) WithWorkloadService(service *workload.Service) *RequestBuilder {
+	return b.WithWorkloads(&workload.Workload{
+		Header:  workload.Header{Type: workload.TypeService},
+		Service: service,
+	})
+}
+
+// WithWorkloadJob sets a job workload.
+func (b *RequestBuilder) WithWorkloadJob(job *workload.Job) *RequestBuilder {
+	return b.WithWorkloads(&workload.Workload{
+		Header: workload.Header{Type: workload.TypeJob},
+		Job:    job,
+	})
+}
+
+// WithWorkloads sets the workloads, the first of which is the Workload of the request.
+// Calling it without workloads builds a request for standalone infra modules.
+func (b *RequestBuilder) WithWorkloads(workloads ...*workload.Workload) *RequestBuilder {
+	b.req.Workloads = workloads
+	b.req.Workload = nil
+	if len(workloads) > 0 {
+		b.req.Workload = workloads[0]
+	}
+	return b
+}
+
+// WithDevConfig sets the developer's inputs of the module.
+func (b *RequestBuilder) WithDevConfig(config map[string]any) *RequestBuilder {
+	b.req.DevModuleConfig = config
+	return b
+}
+
+// WithPlatformConfig sets the platform engineer's inputs of the module.
+func (b *RequestBuilder) WithPlatformConfig(config map[string]any) *RequestBuilder {
+	b.req.PlatformModuleConfig = config
+	return b
+}
+
+// WithFeatureGates sets the feature gates in the platform module config.
+func (b *RequestBuilder) WithFeatureGates(gates map[string]bool) *RequestBuilder {
+	if b.req.PlatformModuleConfig == nil {
+		b.req.PlatformModuleConfig = map[string]any{}
+	}
+	m := make(map[string]any, len(gates))
+	for name, enabled := range gates {
+		m[name] = enabled
+	}
+	b.req.PlatformModuleConfig[module.PlatformConfigFeatureGatesKey] = m
+	return b
+}
+
+// WithRuntimeConfig sets the runtime configurations.
+func (b *RequestBuilder) WithRuntimeConfig(config *v1.RuntimeConfigs) *RequestBuilder {
+	b.req.RuntimeConfig = config
+	return b
+}
+
+// WithOperation sets the engine operation.
+func (b *RequestBuilder) WithOperation(op module.Operation) *RequestBuilder {
+	b.req.Operation = op
+	return b
+}
+
+// WithPriorState sets the resources in the state backend.
+func (b *RequestBuilder) WithPriorState(resources ...v1.Resource) *RequestBuilder {
+	b.req.PriorState = resources
+	return b
+}
+
+// WithProjectMeta sets the labels and annotations of the project.
+func (b *RequestBuilder) WithProjectMeta(meta module.ObjectMeta) *RequestBuilder {
+	b.req.ProjectMeta = meta
+	return b
+}
+
+// WithStackMeta sets the labels and annotations of the stack.
+func (b *RequestBuilder) WithStackMeta(meta module.ObjectMeta) *RequestBuilder {
+	b.req.StackMeta = meta
+	return b
+}
+
+// Build returns the built GeneratorRequest.
+func (b *RequestBuilder) Build() *module.GeneratorRequest {
+	return b.req
+}
@@ -0,0 +1,209 @@
+package tfmodule
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Environment variables overriding the paths of the binaries used by Resolver.
+const (
+	TerraformBinaryEnv = "KUSION_TERRAFORM_BINARY"
+	HCL2JSONBinaryEnv  = "KUSION_HCL2JSON_BINARY"
+)
+
+// Ref references a Terraform module as a module block does.
+type Ref struct {
+	// Source is the source of the module, e.g. terraform-aws-modules/vpc/aws, a git URL or a local path
+	// starting with ./ or ../
+	Source string
+	// Version is the version constraint of registry sources
+	Version string
+	// Variables are the input variables of the module
+	Variables map[string]any
+}
+
+// Resolver resolves the sources of Terraform modules by downloading them with terraform get, including the
+// sources of their nested modules, and loads them. Downloaded modules are cached by source and version, so
+// pin exact versions to get reproducible expansions. Local sources are loaded again on every call.
+type Resolver struct {
+	// CacheDir is the directory downloaded modules are cached in, kusion/terraform-modules in the user
+	// cache directory if empty
+	CacheDir string
+	// Terraform is the path of the terraform binary, TerraformBinaryEnv or terraform in PATH is used if empty
+	Terraform string
+	// HCL2JSON is the path of the hcl2json binary converting the HCL files of modules to the JSON syntax,
+	// HCL2JSONBinaryEnv or hcl2json in PATH is used if empty
+	HCL2JSON string
+}
+
+// rootCall is the name of the module block of the root configuration in which modules are downloaded.
+const rootCall = "module"
+
+// manifest is the modules.json written by terraform get.
+type manifest struct {
+	Modules []struct {
+		Key string `json:"Key"`
+		Dir string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+// Expand resolves and loads the module of ref and expands it as Module.Expand does.
+func (r *Resolver) Expand(ctx context.Context, name string, ref Ref, providers ...*module.TFProviderConfig) (*Expansion, error) {
+	m, err := r.Load(ctx, ref.Source, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	return m.Expand(name, ref.Variables, providers...)
+}
+
+// Load downloads the module of source and version, unless it is cached, and loads it with its nested modules.
+func (r *Resolver) Load(ctx context.Context, source, version string) (*Module, error) {
+	dir, cleanup, err := r.download(ctx, source, version)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	data, err := os.ReadFile(filepath.Join(dir, ".terraform", "modules", "modules.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read modules of %s failed. %w", source, err)
+	}
+	mf := &manifest{}
+	if err = json.Unmarshal(data, mf); err != nil {
+		return nil, fmt.Errorf("decode modules of %s failed. %w", source, err)
+	}
+	dirs := map[string]string{}
+	for _, m := range mf.Modules {
+		dirs[m.Key] = m.Dir
+	}
+	return r.loadKey(ctx, dir, dirs, rootCall)
+}
+
+// loadKey loads the module of the manifest key, whose nested module blocks are the keys <key>.<name>.
+func (r *Resolver) loadKey(ctx context.Context, root string, dirs map[string]string, key string) (*Module, error) {
+	dir, ok := dirs[key]
+	if !ok {
+		return nil, fmt.Errorf("module %s is not downloaded", key)
+	}
+	if filepath.IsAbs(dir) {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		dir = rel
+	}
+	convert := func(file string) ([]byte, error) {
+		return r.convert(ctx, filepath.Join(root, filepath.FromSlash(file)))
+	}
+	return load(os.DirFS(root), filepath.ToSlash(filepath.Clean(dir)), convert, func(name string, _ *Call) (*Module, error) {
+		return r.loadKey(ctx, root, dirs, key+"."+name)
+	})
+}
+
+// download runs terraform get for a root configuration calling the module, returning the directory of the
+// root configuration and the function removing it if it is not cached.
+func (r *Resolver) download(ctx context.Context, source, version string) (dir string, cleanup func(), err error) {
+	call := map[string]any{"source": source}
+	if version != "" {
+		call["version"] = version
+	}
+	cacheDir, err := r.cacheDir()
+	if err != nil {
+		return "", nil, err
+	}
+	if isLocalSource(source) {
+		// local paths are relative to the root configuration, which is in the cache directory
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return "", nil, err
+		}
+		call["source"] = abs
+	} else {
+		sum := sha256.Sum256([]byte(source + "\x00" + version))
+		dir = filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+		if _, err = os.Stat(filepath.Join(dir, ".terraform", "modules", "modules.json")); err == nil {
+			return dir, func() {}, nil
+		}
+	}
+
+	if err = os.MkdirAll(cacheDir, 0o700); err != nil {
+		return "", nil, fmt.Errorf("create module cache failed. %w", err)
+	}
+	tmp, err := os.MkdirTemp(cacheDir, "download-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create module cache failed. %w", err)
+	}
+	remove := func() { _ = os.RemoveAll(tmp) }
+	config, err := json.Marshal(map[string]any{"module": map[string]any{rootCall: call}})
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+	if err = os.WriteFile(filepath.Join(tmp, "main.tf.json"), config, 0o600); err != nil {
+		remove()
+		return "", nil, err
+	}
+	cmd := exec.CommandContext(ctx, binary(r.Terraform, TerraformBinaryEnv, "terraform"), "get")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1", "TF_INPUT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		remove()
+		return "", nil, fmt.Errorf("download terraform module %s failed: %s. %w", source, strings.TrimSpace(stderr.String()), err)
+	}
+	if dir == "" {
+		return tmp, remove, nil
+	}
+	if err = os.Rename(tmp, dir); err != nil {
+		// another resolver cached the module first
+		remove()
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return "", nil, fmt.Errorf("cache terraform module %s failed. %w", source, errors.Join(err, statErr))
+		}
+	}
+	return dir, func() {}, nil
+}
+
+func (r *Resolver) cacheDir() (string, error) {
+	if r.CacheDir != "" {
+		return r.CacheDir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("find the module cache directory failed. %w", err)
+	}
+	return filepath.Join(dir, "kusion", "terraform-modules"), nil
+}
+
+// convert converts the HCL file to the JSON syntax with hcl2json.
+func (r *Resolver) convert(ctx context.Context, file string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, binary(r.HCL2JSON, HCL2JSONBinaryEnv, "hcl2json"), file)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("convert terraform file %s failed: %s. %w", file, strings.TrimSpace(stderr.String()), err)
+	}
+	return out, nil
+}
+
+func binary(path, env, name string) string {
+	if path != "" {
+		return path
+	}
+	if path = os.Getenv(env); path != "" {
+		return path
+	}
+	return name
+}
@@ -0,0 +1,79 @@
+package tfmodule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeScript writes an executable shell script to dir and returns its path.
+func writeScript(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte("#!/bin/sh\nset -e\n"+script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestResolver(t *testing.T) {
+	bin := t.TempDir()
+	registry := filepath.Join(t.TempDir(), "registry")
+	if err := os.MkdirAll(registry, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// the module is written in HCL, converted by the fake hcl2json to its output format of blocks as arrays
+	if err := os.WriteFile(filepath.Join(registry, "main.tf"), []byte(`resource "aws_s3_bucket" "this" {}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hclOutput := `{
+  "variable": {"name": [{"description": "bucket name"}], "acl": [{"default": "private"}]},
+  "locals": [{"tags": {"Name": "${var.name}"}}],
+  "resource": {"aws_s3_bucket": {"this": [{"bucket": "${var.name}", "acl": "${var.acl}", "tags": "${local.tags}"}]}},
+  "output": {"arn": [{"value": "${aws_s3_bucket.this.arn}"}]}
+}`
+	if err := os.WriteFile(filepath.Join(registry, "main.hcl2json"), []byte(hclOutput), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(bin, "runs")
+	terraform := writeScript(t, bin, "terraform", `
+echo "$PWD" >> `+runs+`
+grep -q '"source":"terraform-aws-modules/s3-bucket/aws"' main.tf.json
+grep -q '"version":"4.1.0"' main.tf.json
+mkdir -p .terraform/modules/module
+cp `+registry+`/* .terraform/modules/module/
+echo '{"Modules": [{"Key": "", "Dir": "."}, {"Key": "module", "Dir": ".terraform/modules/module"}]}' > .terraform/modules/modules.json
+`)
+	hcl2json := writeScript(t, bin, "hcl2json", `cat "${1%.tf}.hcl2json"`+"\n")
+
+	r := &Resolver{CacheDir: t.TempDir(), Terraform: terraform, HCL2JSON: hcl2json}
+	ref := Ref{Source: "terraform-aws-modules/s3-bucket/aws", Version: "4.1.0", Variables: map[string]any{"name": "logs"}}
+	for i := 0; i < 2; i++ {
+		expansion, err := r.Expand(context.Background(), "main", ref, awsProvider)
+		if err != nil {
+			t.Fatalf("Expand() error = %v", err)
+		}
+		if len(expansion.Resources) != 1 {
+			t.Fatalf("Expand() returned %d resources, want 1", len(expansion.Resources))
+		}
+		want := map[string]any{"bucket": "logs", "acl": "private", "tags": map[string]any{"Name": "logs"}}
+		if got := expansion.Resources[0].Attributes; !reflect.DeepEqual(got, want) {
+			t.Errorf("bucket attributes = %v, want %v", got, want)
+		}
+	}
+	data, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("terraform get ran %d times, want once as the module is cached", n)
+	}
+
+	failing := &Resolver{CacheDir: t.TempDir(), Terraform: writeScript(t, bin, "failing", "echo 'module not found' >&2\nexit 1\n")}
+	if _, err = failing.Load(context.Background(), "example/missing/aws", "1.0.0"); err == nil || !strings.Contains(err.Error(), "module not found") {
+		t.Errorf("Load() error = %v, want the error of terraform get", err)
+	}
+}
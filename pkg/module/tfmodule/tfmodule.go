@@ -0,0 +1,553 @@
+// Package tfmodule expands Terraform modules into the resources expected by the Kusion Terraform runtime,
+// which manages single resources rather than modules, so that modules can reuse the modules of the
+// Terraform registry. A module is referenced by its source, version and input variables, as in a module
+// block of Terraform:
+//
+//	r := &tfmodule.Resolver{}
+//	expansion, err := r.Expand(ctx, "main", tfmodule.Ref{
+//		Source:    "terraform-aws-modules/vpc/aws",
+//		Version:   "5.0.0",
+//		Variables: map[string]any{"cidr": "10.0.0.0/16"},
+//	}, awsProvider)
+//
+// Resolver downloads the sources with terraform get into a cache directory, so the terraform binary must
+// be installed where modules are resolved, and converts the HCL files with hcl2json. Modules vendored into
+// the module binary in the JSON syntax (*.tf.json), e.g. by go:embed, are loaded by Load without either.
+//
+// Expressions are limited to whole interpolations of var.<name>, local.<name>, module.<name>.<output> and
+// <type>.<name>.<attribute> references; references to resources are turned into implicit references with
+// dependencies, and nested module blocks are expanded into the resources of the parent. The count,
+// for_each and provider meta-arguments, data sources and function calls are not supported, and expanding
+// modules using them fails with an error naming the construct.
+package tfmodule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Module is a loaded Terraform module.
+type Module struct {
+	Variables map[string]Variable
+	Locals    map[string]any
+	Resources []Resource
+	Outputs   map[string]any
+	// Calls are the nested module blocks by name
+	Calls map[string]*Call
+}
+
+// Variable is an input variable of a Terraform module.
+type Variable struct {
+	Description string `json:"description,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	// Required is true if the variable has no default
+	Required bool `json:"-"`
+}
+
+// Resource is a resource block of a Terraform module.
+type Resource struct {
+	Type       string
+	Name       string
+	Attributes map[string]any
+	DependsOn  []string
+}
+
+// Call is a nested module block of a Terraform module.
+type Call struct {
+	Source  string
+	Version string
+	// Inputs are the arguments of the block other than source and version, evaluated in the parent module
+	Inputs map[string]any
+	// Module is the loaded module of Source
+	Module *Module
+}
+
+type fileSyntax struct {
+	Variable map[string]any            `json:"variable"`
+	Locals   any                       `json:"locals"`
+	Resource map[string]map[string]any `json:"resource"`
+	Output   map[string]any            `json:"output"`
+	Module   map[string]any            `json:"module"`
+	Data     map[string]any            `json:"data"`
+}
+
+var unsupportedMetaArguments = []string{"count", "for_each", "provider"}
+
+// Load loads the *.tf.json files in dir of fsys as a Terraform module. The sources of nested module blocks
+// must be local paths within fsys, use a Resolver for remote sources and HCL files.
+func Load(fsys fs.FS, dir string) (*Module, error) {
+	return load(fsys, dir, nil, func(call string, c *Call) (*Module, error) {
+		if !isLocalSource(c.Source) {
+			return nil, fmt.Errorf("source %s of module %s is not a local path, use a Resolver to download it", c.Source, call)
+		}
+		child := path.Clean(path.Join(dir, c.Source))
+		if !fs.ValidPath(child) {
+			return nil, fmt.Errorf("source %s of module %s is outside of the loaded directory", c.Source, call)
+		}
+		return Load(fsys, child)
+	})
+}
+
+// load loads the module in dir of fsys, converting the HCL files with convert if not nil and loading the
+// nested modules with loadCall.
+func load(fsys fs.FS, dir string, convert func(file string) ([]byte, error), loadCall func(name string, c *Call) (*Module, error)) (*Module, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read terraform module %s failed. %w", dir, err)
+	}
+	m := &Module{Variables: map[string]Variable{}, Locals: map[string]any{}, Outputs: map[string]any{}, Calls: map[string]*Call{}}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".tf") && !strings.HasSuffix(name, ".tf.json") {
+			continue
+		}
+		file := path.Join(dir, name)
+		var data []byte
+		switch {
+		case strings.HasSuffix(name, ".tf.json"):
+			data, err = fs.ReadFile(fsys, file)
+		case convert == nil:
+			return nil, fmt.Errorf("terraform file %s is not in the JSON syntax, use a Resolver to convert HCL files", file)
+		default:
+			data, err = convert(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = m.parse(data); err != nil {
+			return nil, fmt.Errorf("parse terraform file %s failed. %w", file, err)
+		}
+	}
+	if len(m.Resources) == 0 && len(m.Calls) == 0 {
+		return nil, fmt.Errorf("no resources or modules found in terraform module %s", dir)
+	}
+	sort.Slice(m.Resources, func(i, j int) bool {
+		return m.Resources[i].Type+"."+m.Resources[i].Name < m.Resources[j].Type+"."+m.Resources[j].Name
+	})
+	for name, c := range m.Calls {
+		if c.Module, err = loadCall(name, c); err != nil {
+			return nil, fmt.Errorf("load module %s of %s failed. %w", name, dir, err)
+		}
+	}
+	return m, nil
+}
+
+// isLocalSource reports whether a module source is a local path, see the module sources of Terraform.
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// body returns the body of a block, which is an object in the JSON syntax of Terraform, or an array of
+// objects in the output of hcl2json.
+func body(v any, block string) (map[string]any, error) {
+	switch x := v.(type) {
+	case map[string]any:
+		return x, nil
+	case []any:
+		out := map[string]any{}
+		for _, item := range x {
+			m, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s block", block)
+			}
+			for k, val := range m {
+				if _, ok := out[k]; ok {
+					return nil, fmt.Errorf("duplicated %s %s", block, k)
+				}
+				out[k] = val
+			}
+		}
+		return out, nil
+	case nil:
+		return map[string]any{}, nil
+	}
+	return nil, fmt.Errorf("invalid %s block", block)
+}
+
+func (m *Module) parse(data []byte) error {
+	f := &fileSyntax{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return err
+	}
+	if len(f.Data) > 0 {
+		return fmt.Errorf("data sources are not supported")
+	}
+	for name, raw := range f.Variable {
+		fields, err := body(raw, "variable "+name)
+		if err != nil {
+			return err
+		}
+		desc, _ := fields["description"].(string)
+		def, hasDefault := fields["default"]
+		m.Variables[name] = Variable{Description: desc, Default: def, Required: !hasDefault}
+	}
+	locals, err := body(f.Locals, "locals")
+	if err != nil {
+		return err
+	}
+	for name, v := range locals {
+		m.Locals[name] = v
+	}
+	for name, raw := range f.Output {
+		o, err := body(raw, "output "+name)
+		if err != nil {
+			return err
+		}
+		m.Outputs[name] = o["value"]
+	}
+	for name, raw := range f.Module {
+		attrs, err := body(raw, "module "+name)
+		if err != nil {
+			return err
+		}
+		for _, arg := range append(unsupportedMetaArguments, "providers", "depends_on") {
+			if _, ok := attrs[arg]; ok {
+				return fmt.Errorf("meta-argument %s of module %s is not supported", arg, name)
+			}
+		}
+		c := &Call{Inputs: map[string]any{}}
+		for k, v := range attrs {
+			switch k {
+			case "source":
+				c.Source, _ = v.(string)
+			case "version":
+				c.Version, _ = v.(string)
+			default:
+				c.Inputs[k] = v
+			}
+		}
+		if c.Source == "" {
+			return fmt.Errorf("source of module %s is not set", name)
+		}
+		m.Calls[name] = c
+	}
+	for typ, blocks := range f.Resource {
+		for name, raw := range blocks {
+			attrs, err := body(raw, "resource "+typ+"."+name)
+			if err != nil {
+				return err
+			}
+			for _, arg := range unsupportedMetaArguments {
+				if _, ok := attrs[arg]; ok {
+					return fmt.Errorf("meta-argument %s of resource %s.%s is not supported", arg, typ, name)
+				}
+			}
+			r := Resource{Type: typ, Name: name, Attributes: attrs}
+			if deps, ok := attrs["depends_on"].([]any); ok {
+				for _, dep := range deps {
+					r.DependsOn = append(r.DependsOn, strings.TrimSuffix(strings.TrimPrefix(fmt.Sprint(dep), "${"), "}"))
+				}
+			}
+			delete(attrs, "depends_on")
+			delete(attrs, "lifecycle")
+			m.Resources = append(m.Resources, r)
+		}
+	}
+	return nil
+}
+
+// Expansion is the result of expanding a Terraform module.
+type Expansion struct {
+	// Resources are the Kusion resources of the resource blocks of the module and its nested modules
+	Resources []v1.Resource
+	// Outputs are the outputs of the module, in which references to resources are implicit references
+	Outputs map[string]any
+}
+
+// Response returns the generator response of the expanded resources with the outputs of the module.
+func (e *Expansion) Response() *module.GeneratorResponse {
+	resp := &module.GeneratorResponse{Resources: e.Resources}
+	for name, v := range e.Outputs {
+		if resp.Outputs == nil {
+			resp.Outputs = map[string]module.OutputValue{}
+		}
+		resp.Outputs[name] = module.OutputValue{Value: v}
+	}
+	return resp
+}
+
+// Expand expands the module instance named name with the input variables into Kusion resources. The
+// instance name is prefixed to the resource names, so that a module can be expanded more than once, and
+// nested modules are expanded as the instances <name>_<module>. The provider of every resource is the one
+// whose name is the prefix of the resource type, e.g. aws for aws_s3_bucket.
+func (m *Module) Expand(name string, vars map[string]any, providers ...*module.TFProviderConfig) (*Expansion, error) {
+	out, err := m.expand(name, vars, providers)
+	if err != nil {
+		return nil, err
+	}
+	resp := out.Response()
+	resp.AddRefDependencies()
+	out.Resources = resp.Resources
+	return out, nil
+}
+
+func (m *Module) expand(name string, vars map[string]any, providers []*module.TFProviderConfig) (*Expansion, error) {
+	inputs := map[string]any{}
+	for v, variable := range m.Variables {
+		value, ok := vars[v]
+		switch {
+		case ok:
+			inputs[v] = value
+		case variable.Required:
+			return nil, fmt.Errorf("variable %s of terraform module %s is required", v, name)
+		default:
+			inputs[v] = variable.Default
+		}
+	}
+	for v := range vars {
+		if _, ok := m.Variables[v]; !ok {
+			return nil, fmt.Errorf("terraform module %s has no variable %s", name, v)
+		}
+	}
+
+	e := &evaluator{
+		def: m, name: name, vars: inputs, providers: providers, ids: map[string]string{},
+		locals: map[string]any{}, calls: map[string]*Expansion{}, evaluating: map[string]bool{},
+	}
+	for _, r := range m.Resources {
+		p := providerOf(r.Type, providers)
+		if p == nil {
+			return nil, fmt.Errorf("no provider configured for resource %s.%s", r.Type, r.Name)
+		}
+		e.ids[r.Type+"."+r.Name] = p.ResourceID(r.Type, resourceName(name, r.Name))
+	}
+
+	out := &Expansion{Outputs: map[string]any{}}
+	for _, r := range m.Resources {
+		attrs, err := e.eval(r.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("expand resource %s.%s failed. %w", r.Type, r.Name, err)
+		}
+		res, err := providerOf(r.Type, providers).WrapResource(r.Type, resourceName(name, r.Name), attrs.(map[string]any))
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range r.DependsOn {
+			id, ok := e.ids[dep]
+			if !ok {
+				return nil, fmt.Errorf("unknown dependency %s of resource %s.%s", dep, r.Type, r.Name)
+			}
+			res.DependsOn = append(res.DependsOn, id)
+		}
+		out.Resources = append(out.Resources, res)
+	}
+	calls := make([]string, 0, len(m.Calls))
+	for call := range m.Calls {
+		calls = append(calls, call)
+	}
+	sort.Strings(calls)
+	for _, call := range calls {
+		child, err := e.call(call)
+		if err != nil {
+			return nil, err
+		}
+		out.Resources = append(out.Resources, child.Resources...)
+	}
+	for o, value := range m.Outputs {
+		v, err := e.eval(value)
+		if err != nil {
+			return nil, fmt.Errorf("expand output %s failed. %w", o, err)
+		}
+		out.Outputs[o] = v
+	}
+	return out, nil
+}
+
+func resourceName(instance, name string) string {
+	if instance == "" {
+		return name
+	}
+	return instance + "_" + name
+}
+
+func providerOf(resourceType string, providers []*module.TFProviderConfig) *module.TFProviderConfig {
+	for _, p := range providers {
+		if strings.HasPrefix(resourceType, p.Name()+"_") {
+			return p
+		}
+	}
+	return nil
+}
+
+type evaluator struct {
+	def        *Module
+	name       string
+	vars       map[string]any
+	providers  []*module.TFProviderConfig
+	ids        map[string]string
+	locals     map[string]any
+	calls      map[string]*Expansion
+	evaluating map[string]bool
+}
+
+// eval replaces the interpolations in the strings of v.
+func (e *evaluator) eval(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return e.interpolate(x)
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, item := range x {
+			val, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = val
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(x))
+		for i, item := range x {
+			val, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = val
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// interpolate evaluates the ${...} interpolations of s. A string consisting of a single interpolation
+// evaluates to the value of the expression, otherwise the values are formatted into the string.
+func (e *evaluator) interpolate(s string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if strings.HasPrefix(s, "${") && strings.Index(s, "}") == len(s)-1 {
+		return e.expr(strings.TrimSpace(s[2 : len(s)-1]))
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated interpolation in %q", s)
+		}
+		v, err := e.expr(strings.TrimSpace(s[start+2 : start+end]))
+		if err != nil {
+			return nil, err
+		}
+		if str, ok := v.(string); ok && strings.HasPrefix(str, module.ImplicitRefPrefix) {
+			return nil, fmt.Errorf("references to resources must be the whole value, got %q", s)
+		}
+		b.WriteString(s[:start])
+		b.WriteString(fmt.Sprint(v))
+		s = s[start+end+1:]
+	}
+}
+
+func (e *evaluator) expr(expr string) (any, error) {
+	parts := strings.Split(expr, ".")
+	if len(parts) < 2 || strings.ContainsAny(expr, `()[]+-*/?:!=<> "`) {
+		return nil, fmt.Errorf("unsupported expression %q, only var, local, module and resource references are supported", expr)
+	}
+	switch parts[0] {
+	case "var":
+		v, ok := e.vars[parts[1]]
+		if !ok {
+			return nil, fmt.Errorf("undeclared variable %s", parts[1])
+		}
+		return lookup(v, parts[2:], expr)
+	case "local":
+		v, err := e.local(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return lookup(v, parts[2:], expr)
+	case "module":
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("unsupported expression %q, references to modules must select an output", expr)
+		}
+		child, err := e.call(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		v, ok := child.Outputs[parts[2]]
+		if !ok {
+			return nil, fmt.Errorf("module %s has no output %s", parts[1], parts[2])
+		}
+		return lookup(v, parts[3:], expr)
+	}
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("unsupported expression %q, references to resources must select an attribute", expr)
+	}
+	id, ok := e.ids[parts[0]+"."+parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("reference to undeclared resource %s.%s", parts[0], parts[1])
+	}
+	return module.RefAttr(id, strings.Join(parts[2:], ".")), nil
+}
+
+func (e *evaluator) local(name string) (any, error) {
+	if v, ok := e.locals[name]; ok {
+		return v, nil
+	}
+	raw, ok := e.def.Locals[name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared local %s", name)
+	}
+	if e.evaluating["local."+name] {
+		return nil, fmt.Errorf("cycle in local %s", name)
+	}
+	e.evaluating["local."+name] = true
+	defer delete(e.evaluating, "local."+name)
+	v, err := e.eval(raw)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate local %s failed. %w", name, err)
+	}
+	e.locals[name] = v
+	return v, nil
+}
+
+// call expands the nested module block name with its inputs evaluated in the parent module.
+func (e *evaluator) call(name string) (*Expansion, error) {
+	if out, ok := e.calls[name]; ok {
+		return out, nil
+	}
+	c, ok := e.def.Calls[name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared module %s", name)
+	}
+	if e.evaluating["module."+name] {
+		return nil, fmt.Errorf("cycle in module %s", name)
+	}
+	e.evaluating["module."+name] = true
+	defer delete(e.evaluating, "module."+name)
+	inputs, err := e.eval(c.Inputs)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate inputs of module %s failed. %w", name, err)
+	}
+	out, err := c.Module.expand(resourceName(e.name, name), inputs.(map[string]any), e.providers)
+	if err != nil {
+		return nil, fmt.Errorf("expand module %s failed. %w", name, err)
+	}
+	e.calls[name] = out
+	return out, nil
+}
+
+func lookup(v any, keys []string, expr string) (any, error) {
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot select %s in %s", k, expr)
+		}
+		if v, ok = m[k]; !ok {
+			return nil, fmt.Errorf("no attribute %s in %s", k, expr)
+		}
+	}
+	return v, nil
+}
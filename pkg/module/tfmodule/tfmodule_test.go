@@ -0,0 +1,147 @@
+package tfmodule
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+const vpcModule = `{
+  "variable": {
+    "cidr": {"description": "CIDR of the VPC"},
+    "name": {"default": "vpc"}
+  },
+  "locals": {"tags": {"Name": "${var.name}"}},
+  "resource": {
+    "aws_vpc": {"this": {"cidr_block": "${var.cidr}", "tags": "${local.tags}"}},
+    "aws_subnet": {"a": {"vpc_id": "${aws_vpc.this.id}", "cidr_block": "${var.cidr}", "depends_on": ["aws_vpc.this"]}}
+  },
+  "output": {"vpc_id": {"value": "${aws_vpc.this.id}"}}
+}`
+
+var awsProvider = &module.TFProviderConfig{Source: "hashicorp/aws", Version: "5.0.1", Region: "us-east-1"}
+
+func TestExpand(t *testing.T) {
+	m, err := Load(fstest.MapFS{"vpc/main.tf.json": {Data: []byte(vpcModule)}}, "vpc")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !m.Variables["cidr"].Required || m.Variables["name"].Required {
+		t.Errorf("Variables = %+v, want cidr required and name optional", m.Variables)
+	}
+
+	expansion, err := m.Expand("main", map[string]any{"cidr": "10.0.0.0/16"}, awsProvider)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	const vpcID = "hashicorp:aws:aws_vpc:main_this"
+	if len(expansion.Resources) != 2 {
+		t.Fatalf("Expand() returned %d resources, want 2", len(expansion.Resources))
+	}
+	subnet, vpc := expansion.Resources[0], expansion.Resources[1]
+	if vpc.ID != vpcID || subnet.ID != "hashicorp:aws:aws_subnet:main_a" {
+		t.Fatalf("resource IDs = %s, %s", subnet.ID, vpc.ID)
+	}
+	if want := map[string]any{"cidr_block": "10.0.0.0/16", "tags": map[string]any{"Name": "vpc"}}; !reflect.DeepEqual(vpc.Attributes, want) {
+		t.Errorf("vpc attributes = %v, want %v", vpc.Attributes, want)
+	}
+	if got := subnet.Attributes["vpc_id"]; got != module.RefAttr(vpcID, "id") {
+		t.Errorf("subnet vpc_id = %v, want the reference to the vpc", got)
+	}
+	if !reflect.DeepEqual(subnet.DependsOn, []string{vpcID}) {
+		t.Errorf("subnet DependsOn = %v, want [%s]", subnet.DependsOn, vpcID)
+	}
+	if got := expansion.Outputs["vpc_id"]; got != module.RefAttr(vpcID, "id") {
+		t.Errorf("output vpc_id = %v, want the reference to the vpc", got)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	m, err := Load(fstest.MapFS{"vpc/main.tf.json": {Data: []byte(vpcModule)}}, "vpc")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		vars      map[string]any
+		providers []*module.TFProviderConfig
+	}{
+		{name: "missing required variable", vars: map[string]any{}, providers: []*module.TFProviderConfig{awsProvider}},
+		{name: "unknown variable", vars: map[string]any{"cidr": "10.0.0.0/16", "zone": "a"}, providers: []*module.TFProviderConfig{awsProvider}},
+		{name: "no provider", vars: map[string]any{"cidr": "10.0.0.0/16"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Expand("main", tt.vars, tt.providers...); err == nil {
+				t.Errorf("Expand() succeeded")
+			}
+		})
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	for name, data := range map[string]string{
+		"remote module": `{"module": {"vpc": {"source": "terraform-aws-modules/vpc/aws", "version": "5.0.0"}}}`,
+		"data source":   `{"data": {"aws_ami": {"ubuntu": {}}}, "resource": {"aws_instance": {"a": {}}}}`,
+		"count":         `{"resource": {"aws_instance": {"a": {"count": 2}}}}`,
+		"hcl only":      ``,
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := fstest.MapFS{"module/main.tf.json": {Data: []byte(data)}}
+			if data == "" {
+				fsys = fstest.MapFS{"module/main.tf": {Data: []byte(`resource "aws_instance" "a" {}`)}}
+			}
+			if _, err := Load(fsys, "module"); err == nil {
+				t.Errorf("Load() succeeded")
+			}
+		})
+	}
+}
+
+func TestExpandNestedModule(t *testing.T) {
+	root := `{
+  "variable": {"cidr": {}},
+  "module": {"network": {"source": "./network", "cidr": "${var.cidr}"}},
+  "resource": {"aws_instance": {"web": {"subnet_id": "${module.network.subnet_id}"}}},
+  "output": {"vpc_id": {"value": "${module.network.vpc_id}"}}
+}`
+	network := `{
+  "variable": {"cidr": {}},
+  "resource": {
+    "aws_vpc": {"this": {"cidr_block": "${var.cidr}"}},
+    "aws_subnet": {"a": {"vpc_id": "${aws_vpc.this.id}"}}
+  },
+  "output": {"vpc_id": {"value": "${aws_vpc.this.id}"}, "subnet_id": {"value": "${aws_subnet.a.id}"}}
+}`
+	m, err := Load(fstest.MapFS{
+		"app/main.tf.json":         {Data: []byte(root)},
+		"app/network/main.tf.json": {Data: []byte(network)},
+	}, "app")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	expansion, err := m.Expand("main", map[string]any{"cidr": "10.0.0.0/16"}, awsProvider)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	ids := map[string]bool{}
+	for _, r := range expansion.Resources {
+		ids[r.ID] = true
+	}
+	const vpcID, subnetID = "hashicorp:aws:aws_vpc:main_network_this", "hashicorp:aws:aws_subnet:main_network_a"
+	if len(ids) != 3 || !ids[vpcID] || !ids[subnetID] || !ids["hashicorp:aws:aws_instance:main_web"] {
+		t.Fatalf("resource IDs = %v", ids)
+	}
+	web := expansion.Resources[0]
+	if got := web.Attributes["subnet_id"]; got != module.RefAttr(subnetID, "id") {
+		t.Errorf("web subnet_id = %v, want the reference to the subnet of the nested module", got)
+	}
+	if !reflect.DeepEqual(web.DependsOn, []string{subnetID}) {
+		t.Errorf("web DependsOn = %v, want [%s]", web.DependsOn, subnetID)
+	}
+	if got := expansion.Outputs["vpc_id"]; got != module.RefAttr(vpcID, "id") {
+		t.Errorf("output vpc_id = %v, want the reference to the vpc", got)
+	}
+}
@@ -0,0 +1,308 @@
+// Package tfschema validates Terraform resources built by modules against the schemas of their providers,
+// loaded from the output of `terraform providers schema -json`, so that wrong attribute names and types
+// fail the generation instead of the apply.
+package tfschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Schemas are the resource schemas of Terraform providers.
+type Schemas struct {
+	FormatVersion   string                     `json:"format_version"`
+	ProviderSchemas map[string]*ProviderSchema `json:"provider_schemas"`
+}
+
+// ProviderSchema is the schema of a provider, keyed by resource type.
+type ProviderSchema struct {
+	ResourceSchemas map[string]*ResourceSchema `json:"resource_schemas"`
+}
+
+// ResourceSchema is the schema of a resource type.
+type ResourceSchema struct {
+	Version int    `json:"version"`
+	Block   *Block `json:"block"`
+}
+
+// Block is a Terraform configuration block.
+type Block struct {
+	Attributes map[string]*Attribute `json:"attributes,omitempty"`
+	BlockTypes map[string]*BlockType `json:"block_types,omitempty"`
+}
+
+// Attribute is an attribute of a block. Type is the JSON encoding of the cty type, e.g. "string" or ["list","string"].
+type Attribute struct {
+	Type     json.RawMessage `json:"type,omitempty"`
+	Required bool            `json:"required,omitempty"`
+	Optional bool            `json:"optional,omitempty"`
+	Computed bool            `json:"computed,omitempty"`
+}
+
+// BlockType is a nested block of a block.
+type BlockType struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       *Block `json:"block"`
+	MinItems    int    `json:"min_items,omitempty"`
+	MaxItems    int    `json:"max_items,omitempty"`
+}
+
+// Load parses the output of `terraform providers schema -json`.
+func Load(data []byte) (*Schemas, error) {
+	s := &Schemas{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("unmarshal provider schemas failed. %w", err)
+	}
+	if len(s.ProviderSchemas) == 0 {
+		return nil, fmt.Errorf("no provider schemas found")
+	}
+	return s, nil
+}
+
+// LoadFS parses the provider schemas at path in fsys, e.g. a schema bundled with embed.FS.
+func LoadFS(fsys fs.FS, path string) (*Schemas, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("read provider schemas %s failed. %w", path, err)
+	}
+	return Load(data)
+}
+
+// Mutator returns a mutator validating every Terraform resource of a response, to be passed to
+// module.WithResponseMutator. Resources of providers without a loaded schema are not validated.
+func (s *Schemas) Mutator() module.ResourceMutator {
+	return func(res *v1.Resource) error {
+		if res.Type != v1.Terraform || s.providerOf(res) == nil {
+			return nil
+		}
+		return s.Validate(res)
+	}
+}
+
+// Validate checks the attributes of the Terraform resource against the schema of its provider and type.
+func (s *Schemas) Validate(res *v1.Resource) error {
+	provider := s.providerOf(res)
+	if provider == nil {
+		return fmt.Errorf("no schema of provider %v of resource %s", res.Extensions[module.ResourceExtensionTFProvider], res.ID)
+	}
+	resourceType, _ := res.Extensions[module.ResourceExtensionTFResourceType].(string)
+	schema, ok := provider.ResourceSchemas[resourceType]
+	if !ok || schema.Block == nil {
+		return fmt.Errorf("unknown resource type %q of resource %s", resourceType, res.ID)
+	}
+	var problems []string
+	schema.Block.validate("", res.Attributes, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("resource %s does not match the %s schema: %s", res.ID, resourceType, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// providerOf returns the schema of the provider of res, matched on the provider URL without its version.
+func (s *Schemas) providerOf(res *v1.Resource) *ProviderSchema {
+	url, _ := res.Extensions[module.ResourceExtensionTFProvider].(string)
+	parts := strings.Split(url, "/")
+	if len(parts) < 3 {
+		return nil
+	}
+	source := strings.Join(parts[:len(parts)-1], "/")
+	if p, ok := s.ProviderSchemas[source]; ok {
+		return p
+	}
+	// schemas of terraform versions before 0.13 are keyed by the provider name only
+	return s.ProviderSchemas[parts[len(parts)-2]]
+}
+
+func (b *Block) validate(path string, attrs map[string]interface{}, problems *[]string) {
+	for _, name := range sortedNames(b.Attributes) {
+		attr := b.Attributes[name]
+		if _, ok := attrs[name]; !ok && attr.Required {
+			*problems = append(*problems, fmt.Sprintf("missing required attribute %s", joinPath(path, name)))
+		}
+	}
+	for _, name := range sortedNames(b.BlockTypes) {
+		bt := b.BlockTypes[name]
+		if _, ok := attrs[name]; !ok && bt.MinItems > 0 {
+			*problems = append(*problems, fmt.Sprintf("missing required block %s", joinPath(path, name)))
+		}
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		value := attrs[name]
+		keyPath := joinPath(path, name)
+		if attr, ok := b.Attributes[name]; ok {
+			if attr.Computed && !attr.Optional && !attr.Required {
+				*problems = append(*problems, fmt.Sprintf("attribute %s is computed by the provider and cannot be set", keyPath))
+				continue
+			}
+			if !matchesType(attr.Type, value) {
+				*problems = append(*problems, fmt.Sprintf("attribute %s must be of type %s", keyPath, attr.Type))
+			}
+			continue
+		}
+		if bt, ok := b.BlockTypes[name]; ok {
+			bt.validate(keyPath, value, problems)
+			continue
+		}
+		*problems = append(*problems, fmt.Sprintf("unknown attribute %s", keyPath))
+	}
+}
+
+func (bt *BlockType) validate(path string, value interface{}, problems *[]string) {
+	if bt.Block == nil {
+		return
+	}
+	var items []interface{}
+	switch bt.NestingMode {
+	case "single", "group":
+		items = []interface{}{value}
+	case "map":
+		m, ok := asMap(value)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("block %s must be a map", path))
+			return
+		}
+		for _, item := range m {
+			items = append(items, item)
+		}
+	default:
+		list, ok := value.([]interface{})
+		if !ok {
+			// a single block is accepted for list blocks of at most one item
+			if _, isMap := asMap(value); isMap && bt.MaxItems == 1 {
+				list = []interface{}{value}
+			} else {
+				*problems = append(*problems, fmt.Sprintf("block %s must be a list", path))
+				return
+			}
+		}
+		if len(list) < bt.MinItems || (bt.MaxItems > 0 && len(list) > bt.MaxItems) {
+			*problems = append(*problems, fmt.Sprintf("block %s has %d items, expected between %d and %d", path, len(list), bt.MinItems, bt.MaxItems))
+		}
+		items = list
+	}
+	for i, item := range items {
+		m, ok := asMap(item)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("item %d of block %s must be an object", i, path))
+			continue
+		}
+		itemPath := path
+		if len(items) > 1 {
+			itemPath = fmt.Sprintf("%s[%d]", path, i)
+		}
+		bt.Block.validate(itemPath, m, problems)
+	}
+}
+
+// matchesType reports whether value can be of the cty type encoded in t. References resolved by the
+// engine, e.g. $kusion_path.<id>.arn, match every type.
+func matchesType(t json.RawMessage, value interface{}) bool {
+	if value == nil || len(t) == 0 {
+		return true
+	}
+	if s, ok := value.(string); ok && strings.HasPrefix(s, module.ImplicitRefPrefix) {
+		return true
+	}
+	var primitive string
+	if err := json.Unmarshal(t, &primitive); err == nil {
+		switch primitive {
+		case "string":
+			switch value.(type) {
+			case string, bool, int, int32, int64, float32, float64:
+				// terraform converts numbers and bools to strings
+				return true
+			}
+			return false
+		case "number":
+			switch value.(type) {
+			case int, int32, int64, uint, uint32, uint64, float32, float64:
+				return true
+			}
+			return false
+		case "bool":
+			_, ok := value.(bool)
+			return ok
+		}
+		return true
+	}
+	var complexType []json.RawMessage
+	if err := json.Unmarshal(t, &complexType); err != nil || len(complexType) != 2 {
+		return true
+	}
+	var kind string
+	_ = json.Unmarshal(complexType[0], &kind)
+	switch kind {
+	case "list", "set":
+		items, ok := value.([]interface{})
+		if !ok {
+			_, ok = value.([]string)
+			return ok
+		}
+		for _, item := range items {
+			if !matchesType(complexType[1], item) {
+				return false
+			}
+		}
+		return true
+	case "map":
+		if _, ok := value.(map[string]string); ok {
+			return true
+		}
+		m, ok := asMap(value)
+		if !ok {
+			return false
+		}
+		for _, item := range m {
+			if !matchesType(complexType[1], item) {
+				return false
+			}
+		}
+		return true
+	case "object":
+		_, ok := asMap(value)
+		return ok
+	}
+	return true
+}
+
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, item := range m {
+			out[fmt.Sprint(k)] = item
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,41 @@
+package module
+
+import "time"
+
+// GenerateTimings is the durations of the phases of a Generate call in the wrapper.
+type GenerateTimings struct {
+	// Decode is the duration of decoding the proto request
+	Decode time.Duration
+	// Validate is the duration of the Validate hook of the module
+	Validate time.Duration
+	// Queue is the duration of waiting for the Limits of the module
+	Queue time.Duration
+	// Generate is the duration of the Generate method of the module
+	Generate time.Duration
+	// Marshal is the duration of post-processing and marshaling the generated resources
+	Marshal time.Duration
+}
+
+// Total returns the sum of the durations of all phases.
+func (t GenerateTimings) Total() time.Duration {
+	return t.Decode + t.Validate + t.Queue + t.Generate + t.Marshal
+}
+
+// stopwatch returns a function returning the duration since its last call.
+func stopwatch() func() time.Duration {
+	last := time.Now()
+	return func() time.Duration {
+		now := time.Now()
+		d := now.Sub(last)
+		last = now
+		return d
+	}
+}
+
+// observeTimings records the phase durations in the metrics and passes them to the OnTimings hook.
+func (f *FrameworkModuleWrapper) observeTimings(t *GenerateTimings) {
+	observePhases(f.Name, t)
+	if f.OnTimings != nil {
+		f.OnTimings(*t)
+	}
+}
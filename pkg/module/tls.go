@@ -0,0 +1,126 @@
+package module
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Environment variables of the TLS files of modules, read when the module is served without WithTLS.
+const (
+	// TLSCertFileEnv is the environment variable of the PEM certificate file of the module
+	TLSCertFileEnv = "KUSION_MODULE_TLS_CERT_FILE"
+	// TLSKeyFileEnv is the environment variable of the PEM private key file of the module
+	TLSKeyFileEnv = "KUSION_MODULE_TLS_KEY_FILE"
+	// TLSCAFileEnv is the environment variable of the PEM CA bundle verifying the peers of the module
+	TLSCAFileEnv = "KUSION_MODULE_TLS_CA_FILE"
+)
+
+// TLSConfig is the TLS configuration of the traffic between engines and modules, e.g. modules served as
+// remote services. On the server side a CAFile enables mutual TLS by requiring client certificates signed
+// by the CA, on the client side it verifies the certificate of the module instead of the system roots.
+type TLSConfig struct {
+	// CertFile is the PEM certificate file, the server certificate of modules or the client certificate of engines
+	CertFile string
+	// KeyFile is the PEM private key file of CertFile
+	KeyFile string
+	// CAFile is the PEM CA bundle verifying the certificates of the peers
+	CAFile string
+	// ServerName overrides the name verified in the certificate of modules by clients
+	ServerName string
+}
+
+// TLSConfigFromEnv returns the TLS configuration in the TLSCertFileEnv, TLSKeyFileEnv and TLSCAFileEnv
+// environment variables, or nil if none is set.
+func TLSConfigFromEnv() *TLSConfig {
+	c := &TLSConfig{
+		CertFile: os.Getenv(TLSCertFileEnv),
+		KeyFile:  os.Getenv(TLSKeyFileEnv),
+		CAFile:   os.Getenv(TLSCAFileEnv),
+	}
+	if c.CertFile == "" && c.KeyFile == "" && c.CAFile == "" {
+		return nil
+	}
+	return c
+}
+
+// WithTLS serves the module over TLS with the certificate in c, requiring client certificates if c sets
+// a CAFile. Without this option the TLS configuration is read from the environment by TLSConfigFromEnv.
+func WithTLS(c TLSConfig) ServeOption {
+	return func(o *serveOptions) {
+		o.tls = &c
+	}
+}
+
+// ServerConfig returns the tls.Config of modules serving with the certificate in c.
+func (c *TLSConfig) ServerConfig() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("both the certificate and the key file of the module must be set")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load module certificate failed. %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.CAFile != "" {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ClientConfig returns the tls.Config of engines connecting to modules, presenting the certificate in c
+// if set.
+func (c *TLSConfig) ClientConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: c.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate failed. %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// tlsProvider returns the go-plugin TLS provider of the configured TLS, or nil to serve in plain text.
+func (o *serveOptions) tlsProvider() func() (*tls.Config, error) {
+	c := o.tls
+	if c == nil {
+		c = TLSConfigFromEnv()
+	}
+	if c == nil {
+		return nil
+	}
+	return c.ServerConfig
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file failed. %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in CA file %s", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,21 @@
+//go:build wasip1
+
+package module
+
+import "context"
+
+// Modules built for wasip1 export no spans, so that they do not link OpenTelemetry, and StartSpan is
+// not available.
+
+// noopSpan is the span of Generate calls of modules built for wasip1.
+type noopSpan struct{}
+
+func startGenerateSpan(ctx context.Context, _ *GeneratorRequest, _ string) (context.Context, noopSpan) {
+	return ctx, noopSpan{}
+}
+
+func endSpan(noopSpan, error) {}
+
+func setupTracing(context.Context, string) (func(context.Context) error, error) {
+	return func(context.Context) error { return nil }, nil
+}
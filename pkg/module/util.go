@@ -26,15 +26,9 @@ func WrapK8sResourceToKusionResource(id string, resource any) (*v1.Resource, err
 }
 
 // KubernetesResourceID returns the unique ID of a Kubernetes resource
-// based on its type and metadata.
+// based on its type and metadata. The apiVersion is used as is, even if it does not parse.
 func KubernetesResourceID(typeMeta metav1.TypeMeta, objectMeta metav1.ObjectMeta) string {
-	// resource id example: apps/v1:Deployment:nginx:nginx-deployment
-	id := typeMeta.APIVersion + ":" + typeMeta.Kind + ":"
-	if objectMeta.Namespace != "" {
-		id += objectMeta.Namespace + ":"
-	}
-	id += objectMeta.Name
-	return id
+	return kubernetesResourceID(typeMeta.APIVersion, typeMeta.Kind, objectMeta.Namespace, objectMeta.Name)
 }
 
 // UniqueAppName returns a unique name for a workload based on its project and app name.
@@ -45,7 +39,7 @@ func UniqueAppName(projectName, stackName, appName string) string {
 // UniqueAppLabels returns a map of labels that identify an app based on its project and name.
 func UniqueAppLabels(projectName, appName string) map[string]string {
 	return map[string]string{
-		"app.kubernetes.io/part-of": projectName,
-		"app.kubernetes.io/name":    appName,
+		LabelPartOf: projectName,
+		LabelName:   appName,
 	}
 }
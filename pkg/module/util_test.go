@@ -0,0 +1,32 @@
+package module
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKubernetesResourceID(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		kind       string
+		namespace  string
+		want       string
+	}{
+		{name: "core", apiVersion: "v1", kind: "Service", namespace: "app", want: "v1:Service:app:web"},
+		{name: "group", apiVersion: "apps/v1", kind: "Deployment", namespace: "app", want: "apps/v1:Deployment:app:web"},
+		{name: "cluster scoped", apiVersion: "rbac.authorization.k8s.io/v1", kind: "ClusterRole", want: "rbac.authorization.k8s.io/v1:ClusterRole:web"},
+		// apiVersions that do not parse are kept as is
+		{name: "invalid apiVersion", apiVersion: "a/b/c", kind: "Widget", namespace: "app", want: "a/b/c:Widget:app:web"},
+		{name: "empty apiVersion", kind: "Widget", namespace: "app", want: ":Widget:app:web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := KubernetesResourceID(metav1.TypeMeta{APIVersion: tt.apiVersion, Kind: tt.kind}, metav1.ObjectMeta{Namespace: tt.namespace, Name: "web"})
+			if got != tt.want {
+				t.Errorf("KubernetesResourceID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
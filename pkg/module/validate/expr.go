@@ -0,0 +1,1045 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Expr requires config to satisfy the boolean expression, written in a subset of CEL over the keys of
+// the config, such as
+//
+//	validate.Expr("replicas <= maxReplicas", "replicas must not exceed maxReplicas")
+//	validate.Expr("!has(backup.retention) || backup.retention >= 7", "")
+//
+// The whole config is the variable self and each of its keys is a variable too. The subset supports
+//
+//   - literals: 64-bit decimal ints, doubles such as 1.5 or 1.5e3, strings in single or double quotes
+//     with Go escapes, true, false, null and lists such as [1, 2]
+//   - field selection a.b, map indexing a["b"] and list indexing a[0]
+//   - the operators, from the lowest to the highest precedence: ?:, ||, &&, the relations
+//     == != < <= > >= in, + -, * / % and the unary ! -
+//   - the functions has(a.b), size, int, double and string
+//   - the string methods startsWith, endsWith, contains and matches, which uses the RE2 syntax
+//   - the macros all(x, p) and exists(x, p) over the items of lists and the keys of maps
+//
+// Unlike CEL, ints and doubles can be mixed in arithmetic and relations, && and || are evaluated from
+// left to right, so an error of the left operand is returned even if the right one decides the result,
+// and selecting a missing key is an error, so optional keys must be guarded with has. As in CEL, int
+// overflow and division by zero are errors. Map literals, uints, bytes, timestamps, durations and the
+// other macros are not supported. The violation includes msg, if not empty, and the failing expression.
+func Expr(expr, msg string) Rule {
+	return exprRule(expr, msg, nil)
+}
+
+// RequestExpr is like Expr but also binds the variable request with the project, stack, app and module
+// of req, so that rules can depend on the stack, such as
+//
+//	validate.RequestExpr(req, `request.stack != "prod" || backup == true`, "prod stacks must enable backup")
+func RequestExpr(req *module.GeneratorRequest, expr, msg string) Rule {
+	return exprRule(expr, msg, map[string]any{"request": map[string]any{
+		"project": req.Project,
+		"stack":   req.Stack,
+		"app":     req.App,
+		"module":  req.Module,
+	}})
+}
+
+func exprRule(expr, msg string, vars map[string]any) Rule {
+	program, compileErr := CompileExpr(expr)
+	return func(config map[string]any) *FieldError {
+		if compileErr != nil {
+			return &FieldError{Path: "self", Message: compileErr.Error()}
+		}
+		path := program.path()
+		ok, err := program.Eval(config, vars)
+		switch {
+		case err != nil:
+			return &FieldError{Path: path, Message: fmt.Sprintf("evaluate `%s` failed: %v", expr, err)}
+		case !ok && msg != "":
+			return &FieldError{Path: path, Message: fmt.Sprintf("%s (violates `%s`)", msg, expr)}
+		case !ok:
+			return &FieldError{Path: path, Message: fmt.Sprintf("violates `%s`", expr)}
+		}
+		return nil
+	}
+}
+
+// Program is a compiled expression.
+type Program struct {
+	expr string
+	root node
+}
+
+// CompileExpr parses the expression, see Expr for the supported syntax.
+func CompileExpr(expr string) (*Program, error) {
+	p := &parser{src: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, fmt.Errorf("invalid expression `%s`: %w", expr, err)
+	}
+	root, err := p.parseExpr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q at %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression `%s`: %w", expr, err)
+	}
+	return &Program{expr: expr, root: root}, nil
+}
+
+// Eval evaluates the program with the keys of config and vars as variables. The result must be a boolean.
+func (p *Program) Eval(config map[string]any, vars map[string]any) (bool, error) {
+	s := &scope{vars: map[string]any{"self": config}}
+	for k, v := range config {
+		s.vars[k] = v
+	}
+	for k, v := range vars {
+		s.vars[k] = v
+	}
+	v, err := p.root.eval(s)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must be a boolean, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+// path returns the first config path selected by the expression, used to report violations.
+func (p *Program) path() string {
+	var found string
+	walkNodes(p.root, func(n node) bool {
+		if path, ok := selectPath(n); ok && !strings.HasPrefix(path, "request") {
+			found = strings.TrimPrefix(path, "self.")
+			return false
+		}
+		return true
+	})
+	if found == "" || found == "self" {
+		return "self"
+	}
+	return found
+}
+
+// lexer
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokInt
+	tokFloat
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+	val  any
+}
+
+type parser struct {
+	src  string
+	toks []token
+	i    int
+}
+
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ",", "?", ":"}
+
+func (p *parser) tokenize() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			p.toks = append(p.toks, token{kind: tokIdent, text: src[i:j], pos: i})
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			isFloat := false
+			for j < len(src) {
+				if src[j] == '.' || src[j] == 'e' || src[j] == 'E' {
+					// a dot not followed by a digit selects a field, e.g. items[0].name
+					if j+1 >= len(src) || !unicode.IsDigit(rune(src[j+1])) {
+						break
+					}
+					isFloat = true
+				} else if !unicode.IsDigit(rune(src[j])) {
+					break
+				}
+				j++
+			}
+			text := src[i:j]
+			if isFloat {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return fmt.Errorf("invalid number %q at %d", text, i)
+				}
+				p.toks = append(p.toks, token{kind: tokFloat, text: text, pos: i, val: f})
+			} else {
+				n, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid number %q at %d", text, i)
+				}
+				p.toks = append(p.toks, token{kind: tokInt, text: text, pos: i, val: n})
+			}
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && rune(src[j]) != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return fmt.Errorf("unterminated string at %d", i)
+			}
+			raw := src[i+1 : j]
+			if c == '\'' {
+				raw = strings.ReplaceAll(strings.ReplaceAll(raw, `\'`, `'`), `"`, `\"`)
+			}
+			s, err := strconv.Unquote(`"` + raw + `"`)
+			if err != nil {
+				return fmt.Errorf("invalid string at %d", i)
+			}
+			p.toks = append(p.toks, token{kind: tokString, text: src[i : j+1], pos: i, val: s})
+			i = j + 1
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					p.toks = append(p.toks, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("unexpected %q at %d", c, i)
+			}
+		}
+	}
+	p.toks = append(p.toks, token{kind: tokEOF, text: "end of expression", pos: len(src)})
+	return nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); (t.kind == tokOp || t.kind == tokIdent) && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q at %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+// parser, following the precedence of CEL
+
+func (p *parser) parseExpr() (node, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{cond: cond, then: then, els: els}, nil
+}
+
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !containsOp(precedence[level], t) {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+func containsOp(ops []string, t token) bool {
+	if t.kind != tokOp && !(t.kind == tokIdent && t.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		x, err := p.parseUnary()
+		return &unaryNode{op: "!", x: x}, err
+	}
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		return &unaryNode{op: "-", x: x}, err
+	}
+	return p.parseMember()
+}
+
+func (p *parser) parseMember() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name at %d, got %q", t.pos, t.text)
+			}
+			if p.accept("(") {
+				args, err := p.parseArgs(")")
+				if err != nil {
+					return nil, err
+				}
+				x = &callNode{name: t.text, target: x, args: args}
+				continue
+			}
+			x = &selectNode{x: x, field: t.text}
+		case p.accept("["):
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexNode{x: x, index: index}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokInt, tokFloat, tokString:
+		return &literalNode{v: t.val}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{v: true}, nil
+		case "false":
+			return &literalNode{v: false}, nil
+		case "null":
+			return &literalNode{v: nil}, nil
+		}
+		if p.accept("(") {
+			args, err := p.parseArgs(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{name: t.text, args: args}, nil
+		}
+		return &identNode{name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.parseArgs("]")
+			return &listNode{items: items}, err
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) parseArgs(end string) ([]node, error) {
+	var args []node
+	if p.accept(end) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(end) {
+			return args, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// evaluation
+
+type scope struct {
+	vars   map[string]any
+	parent *scope
+}
+
+func (s *scope) lookup(name string) (any, bool) {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+type node interface {
+	eval(s *scope) (any, error)
+}
+
+type literalNode struct{ v any }
+
+func (n *literalNode) eval(*scope) (any, error) { return n.v, nil }
+
+type identNode struct{ name string }
+
+func (n *identNode) eval(s *scope) (any, error) {
+	v, ok := s.lookup(n.name)
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", n.name)
+	}
+	return normalize(v), nil
+}
+
+type selectNode struct {
+	x     node
+	field string
+}
+
+func (n *selectNode) eval(s *scope) (any, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	v, ok, err := field(x, n.field)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		path, _ := selectPath(n)
+		return nil, fmt.Errorf("no such key: %s", path)
+	}
+	return v, nil
+}
+
+type indexNode struct {
+	x, index node
+}
+
+func (n *indexNode) eval(s *scope) (any, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := x.([]any); ok {
+		i, ok := index.(int64)
+		if !ok {
+			return nil, fmt.Errorf("list index must be an int, got %s", typeName(index))
+		}
+		if i < 0 || i >= int64(len(list)) {
+			return nil, fmt.Errorf("index %d out of range of list of size %d", i, len(list))
+		}
+		return normalize(list[i]), nil
+	}
+	key, ok := index.(string)
+	if !ok {
+		return nil, fmt.Errorf("map key must be a string, got %s", typeName(index))
+	}
+	v, ok, err := field(x, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", key)
+	}
+	return v, nil
+}
+
+type listNode struct{ items []node }
+
+func (n *listNode) eval(s *scope) (any, error) {
+	out := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+type condNode struct {
+	cond, then, els node
+}
+
+func (n *condNode) eval(s *scope) (any, error) {
+	c, err := evalBool(n.cond, s)
+	if err != nil {
+		return nil, err
+	}
+	if c {
+		return n.then.eval(s)
+	}
+	return n.els.eval(s)
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(s *scope) (any, error) {
+	if n.op == "!" {
+		b, err := evalBool(n.x, s)
+		return !b, err
+	}
+	v, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case int64:
+		if x == math.MinInt64 {
+			return nil, errIntOverflow
+		}
+		return -x, nil
+	case float64:
+		return -x, nil
+	}
+	return nil, fmt.Errorf("cannot negate %s", typeName(v))
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(s *scope) (any, error) {
+	switch n.op {
+	case "||":
+		l, err := evalBool(n.left, s)
+		if err != nil || l {
+			return l, err
+		}
+		return evalBool(n.right, s)
+	case "&&":
+		l, err := evalBool(n.left, s)
+		if err != nil || !l {
+			return l, err
+		}
+		return evalBool(n.right, s)
+	}
+	l, err := n.left.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		return in(l, r)
+	case "<", "<=", ">", ">=":
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+	return arithmetic(n.op, l, r)
+}
+
+type callNode struct {
+	name   string
+	target node
+	args   []node
+}
+
+func (n *callNode) eval(s *scope) (any, error) {
+	if n.target == nil {
+		return n.evalFunc(s)
+	}
+	if n.name == "all" || n.name == "exists" {
+		return n.evalMacro(s)
+	}
+	target, err := n.target.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	str, ok := target.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a method of %s", n.name, typeName(target))
+	}
+	if len(n.args) != 1 {
+		return nil, fmt.Errorf("%s expects 1 argument", n.name)
+	}
+	arg, err := n.args[0].eval(s)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a string argument, got %s", n.name, typeName(arg))
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(str, a), nil
+	case "endsWith":
+		return strings.HasSuffix(str, a), nil
+	case "contains":
+		return strings.Contains(str, a), nil
+	case "matches":
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(str), nil
+	}
+	return nil, fmt.Errorf("unknown method %s", n.name)
+}
+
+func (n *callNode) evalFunc(s *scope) (any, error) {
+	if len(n.args) != 1 {
+		return nil, fmt.Errorf("%s expects 1 argument", n.name)
+	}
+	if n.name == "has" {
+		sel, ok := n.args[0].(*selectNode)
+		if !ok {
+			return nil, fmt.Errorf("has expects a field selection, e.g. has(a.b)")
+		}
+		x, err := sel.x.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		_, ok, err = field(x, sel.field)
+		return ok, err
+	}
+	v, err := n.args[0].eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch n.name {
+	case "size":
+		switch x := v.(type) {
+		case string:
+			return int64(len([]rune(x))), nil
+		case []any:
+			return int64(len(x)), nil
+		case map[string]any:
+			return int64(len(x)), nil
+		}
+		return nil, fmt.Errorf("size of %s is undefined", typeName(v))
+	case "int":
+		switch x := v.(type) {
+		case int64:
+			return x, nil
+		case float64:
+			// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+			if math.IsNaN(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+				return nil, errIntOverflow
+			}
+			return int64(x), nil
+		case string:
+			return strconv.ParseInt(x, 10, 64)
+		}
+	case "double":
+		if f, ok := toFloat(v); ok {
+			return f, nil
+		}
+		if x, ok := v.(string); ok {
+			return strconv.ParseFloat(x, 64)
+		}
+	case "string":
+		switch v.(type) {
+		case int64, float64, string, bool:
+			return fmt.Sprint(v), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown function %s", n.name)
+	}
+	return nil, fmt.Errorf("cannot convert %s with %s", typeName(v), n.name)
+}
+
+func (n *callNode) evalMacro(s *scope) (any, error) {
+	if len(n.args) != 2 {
+		return nil, fmt.Errorf("%s expects a variable and a predicate", n.name)
+	}
+	ident, ok := n.args[0].(*identNode)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a variable name as the first argument", n.name)
+	}
+	target, err := n.target.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	switch x := target.(type) {
+	case []any:
+		items = x
+	case map[string]any:
+		for k := range x {
+			items = append(items, k)
+		}
+	default:
+		return nil, fmt.Errorf("%s is undefined on %s", n.name, typeName(target))
+	}
+	for _, item := range items {
+		ok, err := evalBool(n.args[1], &scope{vars: map[string]any{ident.name: item}, parent: s})
+		if err != nil {
+			return nil, err
+		}
+		if n.name == "exists" && ok {
+			return true, nil
+		}
+		if n.name == "all" && !ok {
+			return false, nil
+		}
+	}
+	return n.name == "all", nil
+}
+
+func evalBool(n node, s *scope) (bool, error) {
+	v, err := n.eval(s)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+// field returns the value of key in the map x.
+func field(x any, key string) (any, bool, error) {
+	m, ok := x.(map[string]any)
+	if !ok {
+		return nil, false, fmt.Errorf("cannot select %s of %s", key, typeName(x))
+	}
+	v, ok := m[key]
+	return normalize(v), ok, nil
+}
+
+// normalize converts the values decoded from YAML into the types of the evaluator: int64, float64,
+// string, bool, nil, []any and map[string]any.
+func normalize(v any) any {
+	switch x := v.(type) {
+	case nil, bool, string, int64, float64:
+		return v
+	case float32:
+		return float64(x)
+	case map[string]any:
+		return x
+	case map[interface{}]interface{}:
+		m := make(map[string]any, len(x))
+		for k, item := range x {
+			m[fmt.Sprint(k)] = item
+		}
+		return m
+	case []any:
+		return x
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[fmt.Sprint(k.Interface())] = rv.MapIndex(k).Interface()
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list
+	}
+	return v
+}
+
+func equal(l, r any) bool {
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		return ok && lf == rf
+	}
+	ll, lok := l.([]any)
+	rl, rok := r.([]any)
+	if lok && rok {
+		if len(ll) != len(rl) {
+			return false
+		}
+		for i := range ll {
+			if !equal(normalize(ll[i]), normalize(rl[i])) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func in(l, r any) (bool, error) {
+	switch x := r.(type) {
+	case []any:
+		for _, item := range x {
+			if equal(l, normalize(item)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]any:
+		key, ok := l.(string)
+		if !ok {
+			return false, nil
+		}
+		_, ok = x[key]
+		return ok, nil
+	}
+	return false, fmt.Errorf("in is undefined on %s", typeName(r))
+}
+
+func compare(l, r any) (int, error) {
+	if lf, ok := toFloat(l); ok {
+		if rf, ok := toFloat(r); ok {
+			switch {
+			case lf < rf:
+				return -1, nil
+			case lf > rf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	ls, lok := l.(string)
+	rs, rok := r.(string)
+	if lok && rok {
+		return strings.Compare(ls, rs), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", typeName(l), typeName(r))
+}
+
+func arithmetic(op string, l, r any) (any, error) {
+	li, lok := l.(int64)
+	ri, rok := r.(int64)
+	if lok && rok {
+		return intArithmetic(op, li, ri)
+	}
+	if op == "+" {
+		if ls, ok := l.(string); ok {
+			if rs, ok := r.(string); ok {
+				return ls + rs, nil
+			}
+		}
+		if ll, ok := l.([]any); ok {
+			if rl, ok := r.([]any); ok {
+				return append(append([]any{}, ll...), rl...), nil
+			}
+		}
+	}
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok || op == "%" {
+		return nil, fmt.Errorf("%s is undefined on %s and %s", op, typeName(l), typeName(r))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	}
+	return lf / rf, nil
+}
+
+var errIntOverflow = errors.New("int overflow")
+
+// intArithmetic applies op to ints, failing on overflow as CEL does.
+func intArithmetic(op string, l, r int64) (any, error) {
+	switch op {
+	case "+":
+		if (r > 0 && l > math.MaxInt64-r) || (r < 0 && l < math.MinInt64-r) {
+			return nil, errIntOverflow
+		}
+		return l + r, nil
+	case "-":
+		if (r < 0 && l > math.MaxInt64+r) || (r > 0 && l < math.MinInt64+r) {
+			return nil, errIntOverflow
+		}
+		return l - r, nil
+	case "*":
+		p := l * r
+		if l != 0 && (p/l != r || (l == -1 && r == math.MinInt64)) {
+			return nil, errIntOverflow
+		}
+		return p, nil
+	}
+	if r == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if l == math.MinInt64 && r == -1 {
+		if op == "%" {
+			return int64(0), nil
+		}
+		return nil, errIntOverflow
+	}
+	if op == "/" {
+		return l / r, nil
+	}
+	return l % r, nil
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// selectPath returns the dot separated path of identifiers and field selections.
+func selectPath(n node) (string, bool) {
+	switch x := n.(type) {
+	case *identNode:
+		return x.name, true
+	case *selectNode:
+		if p, ok := selectPath(x.x); ok {
+			return p + "." + x.field, true
+		}
+	}
+	return "", false
+}
+
+// walkNodes calls fn on n and its children in order until fn returns false.
+func walkNodes(n node, fn func(node) bool) bool {
+	if n == nil || !fn(n) {
+		return false
+	}
+	var children []node
+	switch x := n.(type) {
+	case *selectNode:
+		return true
+	case *indexNode:
+		children = []node{x.x, x.index}
+	case *listNode:
+		children = x.items
+	case *condNode:
+		children = []node{x.cond, x.then, x.els}
+	case *unaryNode:
+		children = []node{x.x}
+	case *binaryNode:
+		children = []node{x.left, x.right}
+	case *callNode:
+		children = append([]node{x.target}, x.args...)
+	}
+	for _, c := range children {
+		if c != nil && !walkNodes(c, fn) {
+			return false
+		}
+	}
+	return true
+}
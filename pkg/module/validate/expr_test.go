@@ -0,0 +1,251 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+var exprConfig = map[string]any{
+	"replicas":    3,
+	"maxReplicas": 5,
+	"ratio":       0.5,
+	"name":        "web-server",
+	"enabled":     true,
+	"nothing":     nil,
+	"ports":       []any{80, 443},
+	"database":    map[string]any{"port": 5432, "engine": "postgres"},
+	"labels":      map[interface{}]interface{}{"team": "infra"},
+	"max":         int64(9223372036854775807),
+	"min":         int64(-9223372036854775808),
+}
+
+func TestExprEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// precedence
+		{"1 + 2 * 3 == 7", true},
+		{"(1 + 2) * 3 == 9", true},
+		{"10 - 4 - 3 == 3", true},
+		{"7 / 2 * 2 == 6", true},
+		{"7 % 4 + 1 == 4", true},
+		{"-2 * 3 == -6", true},
+		{"!false && false", false},
+		{"!(false && false)", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"1 < 2 == true", true},
+		{"replicas + 2 <= maxReplicas", true},
+		{"false ? false : true ? true : false", true},
+		{"replicas > 2 ? name == 'web-server' : false", true},
+		// operators
+		{"ratio * 2 == 1", true},
+		{"7 / 2.0 == 3.5", true},
+		{"'a' < 'b'", true},
+		{"'web' + '-server' == name", true},
+		{"[1] + [2] == [1, 2]", true},
+		{"443 in ports", true},
+		{"8080 in ports", false},
+		{"'port' in database", true},
+		{"'team' in labels", true},
+		{"1 in database", false},
+		{"1 == 1.0", true},
+		{"1 == '1'", false},
+		// selection and indexing
+		{"database.port == 5432", true},
+		{"database['engine'] == 'postgres'", true},
+		{"self.replicas == replicas", true},
+		{"ports[1] == 443", true},
+		{"labels.team == 'infra'", true},
+		// functions, methods and macros
+		{"size(name) == 10 && size(ports) == 2 && size(database) == 2", true},
+		{"size('héllo') == 5", true},
+		{"int('42') == 42 && int(2.9) == 2 && double('1.5') == 1.5 && double(2) == 2.0", true},
+		{"string(replicas) == '3' && string(true) == 'true'", true},
+		{"name.startsWith('web') && name.endsWith('server') && name.contains('-')", true},
+		{"name.matches('^[a-z]+-[a-z]+$')", true},
+		{"ports.all(p, p > 0)", true},
+		{"ports.all(p, p > 100)", false},
+		{"ports.exists(p, p == 80)", true},
+		{"[].exists(p, true)", false},
+		{"database.exists(k, k == 'engine')", true},
+		{`"it's" == 'it\'s'`, true},
+		// null and missing keys
+		{"nothing == null", true},
+		{"has(database.port)", true},
+		{"has(database.host)", false},
+		{"!has(database.host) || database.host == ''", true},
+		{"has(self.replicas)", true},
+		// short-circuiting
+		{"false && missing", false},
+		{"true || missing", true},
+		{"false && 1 / 0 == 0", false},
+		{"true ? true : missing", true},
+		{"ports.exists(p, p == 80 || p.x)", true},
+		// int bounds
+		{"max - 1 + 1 == max", true},
+		{"min % -1 == 0", true},
+		{"-max - 1 == min", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := CompileExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileExpr() error = %v", err)
+			}
+			got, err := p.Eval(exprConfig, nil)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		// missing keys and null
+		{"missing == 1", "no such key: missing"},
+		{"database.host == ''", "no such key: database.host"},
+		{"database['host'] == ''", "no such key: host"},
+		{"nothing.field == 1", "cannot select field of null"},
+		{"has(missing.field)", "no such key: missing"},
+		{"has(replicas)", "has expects a field selection, e.g. has(a.b)"},
+		// operands are evaluated from left to right
+		{"missing && false", "no such key: missing"},
+		{"missing || true", "no such key: missing"},
+		// type errors
+		{"replicas", "expression must be a boolean, got int"},
+		{"replicas && true", "expected a boolean, got int"},
+		{"!name", "expected a boolean, got string"},
+		{"name < 1", "cannot compare string and int"},
+		{"name - 'a' == ''", "- is undefined on string and string"},
+		{"ratio % 2 == 0", "% is undefined on double and int"},
+		{"-name == ''", "cannot negate string"},
+		{"1 in 2", "in is undefined on int"},
+		{"ports['a'] == 1", "list index must be an int, got string"},
+		{"ports[2] == 1", "index 2 out of range of list of size 2"},
+		{"database[1] == 1", "map key must be a string, got int"},
+		{"size(1) == 1", "size of int is undefined"},
+		{"string(ports) == ''", "cannot convert list with string"},
+		{"int('a') == 1", `strconv.ParseInt: parsing "a": invalid syntax`},
+		{"replicas.startsWith('a')", "startsWith is not a method of int"},
+		{"name.startsWith(1)", "startsWith expects a string argument, got int"},
+		{"name.matches('(')", "error parsing regexp: missing closing ): `(`"},
+		{"name.trim('a')", "unknown method trim"},
+		{"len(name) == 1", "unknown function len"},
+		{"replicas.all(x, true)", "all is undefined on int"},
+		{"ports.all(1, true)", "all expects a variable name as the first argument"},
+		{"ports.exists(p, p)", "expected a boolean, got int"},
+		// int overflow and division by zero
+		{"max + 1 > 0", "int overflow"},
+		{"min - 1 < 0", "int overflow"},
+		{"max * 2 > 0", "int overflow"},
+		{"min * -1 > 0", "int overflow"},
+		{"-1 * min > 0", "int overflow"},
+		{"min / -1 > 0", "int overflow"},
+		{"-min > 0", "int overflow"},
+		{"int(9.3e18) > 0", "int overflow"},
+		{"int(double(max)) > 0", "int overflow"},
+		{"replicas / 0 == 0", "division by zero"},
+		{"replicas % 0 == 0", "division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := CompileExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileExpr() error = %v", err)
+			}
+			_, err = p.Eval(exprConfig, nil)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Eval() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"", `unexpected "end of expression" at 0`},
+		{"replicas >", `unexpected "end of expression" at 10`},
+		{"replicas > 1)", `unexpected ")" at 12`},
+		{"(replicas > 1", `expected ")" at 13, got "end of expression"`},
+		{"ports[0 == 80", `expected "]" at 13, got "end of expression"`},
+		{"[1, 2 == [1]", `expected "," at 12, got "end of expression"`},
+		{"true ? 1", `expected ":" at 8, got "end of expression"`},
+		{"database.1 == 1", `expected field name at 9, got "1"`},
+		{"replicas == 'a", "unterminated string at 12"},
+		{`name == "\q"`, "invalid string at 8"},
+		{"replicas == 9223372036854775808", `invalid number "9223372036854775808" at 12`},
+		{"replicas # 1", `unexpected '#' at 9`},
+		{"a = 1", `unexpected '=' at 2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := CompileExpr(tt.expr)
+			want := "invalid expression `" + tt.expr + "`: " + tt.wantErr
+			if err == nil || err.Error() != want {
+				t.Errorf("CompileExpr() error = %v, want %s", err, want)
+			}
+		})
+	}
+}
+
+func TestExprRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want *FieldError
+	}{
+		{"satisfied", Expr("replicas <= maxReplicas", ""), nil},
+		{"violated", Expr("database.port < 1024", ""), &FieldError{Path: "database.port", Message: "violates `database.port < 1024`"}},
+		{"violated with message", Expr("self.replicas > 3", "too few"), &FieldError{Path: "replicas", Message: "too few (violates `self.replicas > 3`)"}},
+		{"evaluation error", Expr("missing > 1", ""), &FieldError{Path: "missing", Message: "evaluate `missing > 1` failed: no such key: missing"}},
+		{"compile error", Expr("replicas >", ""), &FieldError{Path: "self", Message: "invalid expression `replicas >`: unexpected \"end of expression\" at 10"}},
+		{"no path", Expr("1 > 2", ""), &FieldError{Path: "self", Message: "violates `1 > 2`"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule(exprConfig)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("rule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// FuzzParse compiles arbitrary expressions and evaluates the ones that compile, which must fail with an
+// error instead of panicking.
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"replicas <= maxReplicas",
+		"!has(database.host) || database.host == ''",
+		"ports.all(p, p > 0) && ports[0] in [80, 443]",
+		"name.matches('^web') ? size(name) > 3 : int('1') == 1",
+		`"a\n" + 'b\'' == string(1.5e3)`,
+		"max + 1 > min - 1",
+		"labels['team'].startsWith(\"in\")",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		p, err := CompileExpr(expr)
+		if err != nil {
+			if !strings.HasPrefix(err.Error(), "invalid expression") {
+				t.Fatalf("CompileExpr() error = %v, want an invalid expression error", err)
+			}
+			return
+		}
+		_, _ = p.Eval(exprConfig, nil)
+		_ = p.path()
+	})
+}
@@ -0,0 +1,202 @@
+// Package validate provides declarative rules validating module configs, such as
+//
+//	err := validate.PlatformConfig(req,
+//		validate.Required("port"),
+//		validate.OneOf("size", "small", "large"),
+//		validate.Range("replicas", 1, 10),
+//		validate.Expr("replicas <= maxReplicas", "replicas must not exceed maxReplicas"),
+//	)
+//
+// Paths are dot separated keys into nested maps, e.g. "database.port". All rules are evaluated
+// and the violations are aggregated into a single error.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+// Rule validates a config and returns the violation, or nil if the config satisfies the rule.
+type Rule func(config map[string]any) *FieldError
+
+// FieldError is a violation of a rule by the value at Path.
+type FieldError struct {
+	// Path is the dot separated key path of the value
+	Path string
+	// Message describes the violation
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Errors aggregates the violations of all rules.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("%d config error(s):\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
+// Validate evaluates all rules against config and returns Errors if any rule is violated.
+func Validate(config map[string]any, rules ...Rule) error {
+	var errs Errors
+	for _, rule := range rules {
+		if fe := rule(config); fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// PlatformConfig evaluates all rules against the platform module config of req.
+func PlatformConfig(req *module.GeneratorRequest, rules ...Rule) error {
+	if err := Validate(req.PlatformModuleConfig, rules...); err != nil {
+		return fmt.Errorf("invalid platform module config: %w", err)
+	}
+	return nil
+}
+
+// DevConfig evaluates all rules against the dev module config of req.
+func DevConfig(req *module.GeneratorRequest, rules ...Rule) error {
+	if err := Validate(req.DevModuleConfig, rules...); err != nil {
+		return fmt.Errorf("invalid dev module config: %w", err)
+	}
+	return nil
+}
+
+// Required requires the value at path to be set and not empty, where null and zero-length strings,
+// lists and maps are empty. Zero numbers and false are values.
+func Required(path string) Rule {
+	return func(config map[string]any) *FieldError {
+		v, ok := Lookup(config, path)
+		if !ok || isEmpty(v) {
+			return &FieldError{Path: path, Message: "is required"}
+		}
+		return nil
+	}
+}
+
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// OneOf requires the value at path, if set, to be one of values.
+func OneOf(path string, values ...string) Rule {
+	return func(config map[string]any) *FieldError {
+		v, ok := Lookup(config, path)
+		if !ok || v == nil {
+			return nil
+		}
+		s := fmt.Sprint(v)
+		for _, allowed := range values {
+			if s == allowed {
+				return nil
+			}
+		}
+		return &FieldError{Path: path, Message: fmt.Sprintf("must be one of [%s], got %q", strings.Join(values, ", "), s)}
+	}
+}
+
+// Range requires the value at path, if set, to be a number between min and max inclusive.
+func Range(path string, min, max float64) Rule {
+	return func(config map[string]any) *FieldError {
+		v, ok := Lookup(config, path)
+		if !ok || v == nil {
+			return nil
+		}
+		n, ok := toFloat(v)
+		if !ok {
+			return &FieldError{Path: path, Message: fmt.Sprintf("must be a number, got %v", v)}
+		}
+		if n < min || n > max {
+			return &FieldError{Path: path, Message: fmt.Sprintf("must be between %v and %v, got %v", min, max, v)}
+		}
+		return nil
+	}
+}
+
+// Custom requires check to return nil for the value at path, if set.
+func Custom(path string, check func(v any) error) Rule {
+	return func(config map[string]any) *FieldError {
+		v, ok := Lookup(config, path)
+		if !ok {
+			return nil
+		}
+		if err := check(v); err != nil {
+			return &FieldError{Path: path, Message: err.Error()}
+		}
+		return nil
+	}
+}
+
+// Lookup returns the value at the dot separated path of config.
+func Lookup(config map[string]any, path string) (any, bool) {
+	var cur any = config
+	for _, key := range strings.Split(path, ".") {
+		switch m := cur.(type) {
+		case map[string]any:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case map[interface{}]interface{}:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
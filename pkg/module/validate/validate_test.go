@@ -0,0 +1,102 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"kusionstack.io/kusion-module-framework/pkg/module"
+)
+
+var testConfig = map[string]any{
+	"port":     8080,
+	"zero":     0,
+	"disabled": false,
+	"empty":    "",
+	"nothing":  nil,
+	"list":     []any{},
+	"map":      map[string]any{},
+	"size":     "small",
+	"ratio":    "high",
+	"database": map[string]any{"port": 5432, "name": ""},
+	"legacy":   map[interface{}]interface{}{"host": "db"},
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{"required set", Required("port"), ""},
+		{"required zero", Required("zero"), ""},
+		{"required false", Required("disabled"), ""},
+		{"required nested", Required("database.port"), ""},
+		{"required legacy map", Required("legacy.host"), ""},
+		{"required missing", Required("host"), "host: is required"},
+		{"required missing nested", Required("database.host"), "database.host: is required"},
+		{"required below a scalar", Required("port.number"), "port.number: is required"},
+		{"required null", Required("nothing"), "nothing: is required"},
+		{"required empty string", Required("empty"), "empty: is required"},
+		{"required empty nested string", Required("database.name"), "database.name: is required"},
+		{"required empty list", Required("list"), "list: is required"},
+		{"required empty map", Required("map"), "map: is required"},
+
+		{"one of allowed", OneOf("size", "small", "large"), ""},
+		{"one of number", OneOf("port", "80", "8080"), ""},
+		{"one of unset", OneOf("missing", "small"), ""},
+		{"one of null", OneOf("nothing", "small"), ""},
+		{"one of not allowed", OneOf("size", "medium", "large"), `size: must be one of [medium, large], got "small"`},
+
+		{"range within", Range("port", 1, 65535), ""},
+		{"range bounds", Range("port", 8080, 8080), ""},
+		{"range unset", Range("missing", 1, 2), ""},
+		{"range below", Range("zero", 1, 10), "zero: must be between 1 and 10, got 0"},
+		{"range above", Range("database.port", 1, 1024), "database.port: must be between 1 and 1024, got 5432"},
+		{"range not a number", Range("ratio", 0, 1), "ratio: must be a number, got high"},
+
+		{"custom passes", Custom("size", func(v any) error { return nil }), ""},
+		{"custom unset", Custom("missing", func(v any) error { return errors.New("called") }), ""},
+		{"custom fails", Custom("size", func(v any) error { return errors.New("must be large") }), "size: must be large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if fe := tt.rule(testConfig); fe != nil {
+				got = fe.Error()
+			}
+			if got != tt.want {
+				t.Errorf("rule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(testConfig, Required("port"), Range("port", 1, 65535)); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	err := Validate(testConfig, Required("host"), Required("port"), OneOf("size", "large"))
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Validate() error = %v, want the 2 violations", err)
+	}
+	want := "2 config error(s):\n  host: is required\n  size: must be one of [large], got \"small\""
+	if err.Error() != want {
+		t.Errorf("Validate() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRequestConfigs(t *testing.T) {
+	req := &module.GeneratorRequest{
+		PlatformModuleConfig: map[string]any{"port": 80},
+		DevModuleConfig:      map[string]any{"image": ""},
+	}
+	if err := PlatformConfig(req, Required("port")); err != nil {
+		t.Errorf("PlatformConfig() error = %v, want nil", err)
+	}
+	if err := DevConfig(req, Required("image")); err == nil || !strings.HasPrefix(err.Error(), "invalid dev module config: ") {
+		t.Errorf("DevConfig() error = %v, want the invalid dev module config", err)
+	}
+}
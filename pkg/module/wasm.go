@@ -0,0 +1,96 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"kusionstack.io/kusion/pkg/modules/proto"
+)
+
+// wasmRequest is the envelope of the requests of WASM modules. Metadata values are bytes, as the values
+// of binary keys are not always valid UTF-8.
+type wasmRequest struct {
+	Request  *proto.GeneratorRequest `json:"request"`
+	Metadata map[string][][]byte     `json:"metadata,omitempty"`
+}
+
+// wasmResponse is the envelope of the responses of WASM modules.
+type wasmResponse struct {
+	Response *proto.GeneratorResponse `json:"response,omitempty"`
+	Header   map[string][][]byte      `json:"header,omitempty"`
+	Error    *wasmError               `json:"error,omitempty"`
+}
+
+type wasmError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Hint    string    `json:"hint,omitempty"`
+}
+
+// serveWASM serves the request envelope read from in through the wrapper and writes the response
+// envelope to out. Module errors are sent in the envelope, and only I/O errors are returned.
+func serveWASM(ctx context.Context, w *FrameworkModuleWrapper, in io.Reader, out io.Writer) error {
+	envelope := &wasmRequest{}
+	if err := json.NewDecoder(in).Decode(envelope); err != nil {
+		return fmt.Errorf("unmarshal request failed. %w", err)
+	}
+	resp := &wasmResponse{}
+	if envelope.Request == nil {
+		resp.Error = &wasmError{Code: ErrCodeInvalidRequest, Message: "request is missing"}
+	} else {
+		ctx = metadata.NewIncomingContext(ctx, metadataFromWire(envelope.Metadata))
+		stream := &localStream{header: metadata.MD{}}
+		ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+		protoResp, err := w.generate(ctx, envelope.Request)
+		if err != nil {
+			resp.Error = newWASMError(err)
+		} else {
+			resp.Response = protoResp
+			resp.Header = metadataToWire(stream.header)
+		}
+	}
+	if err := json.NewEncoder(out).Encode(resp); err != nil {
+		return fmt.Errorf("write response failed. %w", err)
+	}
+	return nil
+}
+
+func newWASMError(err error) *wasmError {
+	e, ok := ErrorFromStatus(err)
+	if !ok && !errors.As(err, &e) {
+		e = &Error{Code: ErrCodeInternal, Message: err.Error()}
+	}
+	msg := e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return &wasmError{Code: e.Code, Message: msg, Hint: e.Hint}
+}
+
+func metadataToWire(md metadata.MD) map[string][][]byte {
+	if len(md) == 0 {
+		return nil
+	}
+	out := make(map[string][][]byte, len(md))
+	for k, values := range md {
+		for _, v := range values {
+			out[k] = append(out[k], []byte(v))
+		}
+	}
+	return out
+}
+
+func metadataFromWire(wire map[string][][]byte) metadata.MD {
+	md := metadata.MD{}
+	for k, values := range wire {
+		for _, v := range values {
+			md.Append(k, string(v))
+		}
+	}
+	return md
+}
@@ -0,0 +1,121 @@
+//go:build !wasip1
+
+package module
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WASMRuntimeEnv is the environment variable of the command running WASM modules, e.g. "wazero run",
+// read when WASMModule.Command is empty.
+const WASMRuntimeEnv = "KUSION_MODULE_WASM_RUNTIME"
+
+// DefaultWASMRuntime is the command running WASM modules if neither WASMModule.Command nor
+// WASMRuntimeEnv is set.
+var DefaultWASMRuntime = []string{"wasmtime", "run"}
+
+// WASMModule is a FrameworkModule running the WASM module at Path with a WASI runtime, once per Generate
+// call. The WASM target is experimental. A module built with
+//
+//	GOOS=wasip1 GOARCH=wasm go build -o kusion-module-<name>.wasm
+//
+// serves a single request per run instead of the plugin handshake: Serve reads a JSON envelope with the
+// proto request and its gRPC metadata from stdin, and writes the proto response, the response header and
+// the module error to stdout. Unlike plugins, the module has no access to the file system, the network
+// or the environment of the host unless the runtime grants it. The wasip1 build of the package leaves out
+// the plugin host, the WASM host, metrics and tracing, so WithMetricsAddr has no effect
+// and HandshakeConfig, PluginResolver, LaunchPlugin, WASMModule and StartSpan are not available.
+type WASMModule struct {
+	// Path is the path of the .wasm file of the module
+	Path string
+	// Command is the runtime command the module path is appended to, WASMRuntimeEnv or
+	// DefaultWASMRuntime if empty
+	Command []string
+}
+
+// Generate implements FrameworkModule.
+func (m *WASMModule) Generate(ctx context.Context, req *GeneratorRequest) (*GeneratorResponse, error) {
+	protoReq, err := req.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	md, err := requestMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	in, err := json.Marshal(&wasmRequest{Request: protoReq, Metadata: metadataToWire(md)})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request failed. %w", err)
+	}
+
+	command := m.command()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], append(command[1:], m.Path)...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, NewError(ErrCodeCanceled, "run WASM module %s failed: %v", m.Path, ctx.Err())
+		}
+		return nil, fmt.Errorf("run WASM module %s failed. %w: %s", m.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp := &wasmResponse{}
+	if err = json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("unmarshal response of WASM module %s failed. %w", m.Path, err)
+	}
+	if resp.Error != nil {
+		return nil, NewError(resp.Error.Code, "%s", resp.Error.Message).WithHint(resp.Error.Hint)
+	}
+	if resp.Response == nil {
+		return nil, fmt.Errorf("WASM module %s returned no response", m.Path)
+	}
+	return decodeResponse(resp.Response, metadataFromWire(resp.Header))
+}
+
+func (m *WASMModule) command() []string {
+	if len(m.Command) > 0 {
+		return m.Command
+	}
+	if command := strings.Fields(os.Getenv(WASMRuntimeEnv)); len(command) > 0 {
+		return command
+	}
+	return DefaultWASMRuntime
+}
+
+// WASMResolver resolves WASM modules, named kusion-module-<name>.wasm or <name>.wasm in one of the
+// directories.
+type WASMResolver struct {
+	// Dirs is the directories searched for module files in order
+	Dirs []string
+	// Command is the runtime command of the modules, see WASMModule
+	Command []string
+}
+
+// NewWASMResolver returns a WASMResolver searching dirs.
+func NewWASMResolver(dirs ...string) *WASMResolver {
+	return &WASMResolver{Dirs: dirs}
+}
+
+// Resolve implements Resolver.
+func (r *WASMResolver) Resolve(_ context.Context, name string) (FrameworkModule, error) {
+	if !strings.ContainsAny(name, `/\`) {
+		for _, dir := range r.Dirs {
+			for _, file := range []string{"kusion-module-" + name + ".wasm", name + ".wasm"} {
+				path := filepath.Join(dir, file)
+				if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+					return &WASMModule{Path: path, Command: r.Command}, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+}
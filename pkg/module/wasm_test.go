@@ -0,0 +1,41 @@
+package module
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestWASIP1Build(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the module for wasip1")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	env := append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+
+	build := exec.Command(goBin, "build", "./...")
+	build.Dir = "../.."
+	build.Env = env
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("GOOS=wasip1 GOARCH=wasm go build ./... failed: %v\n%s", err, out)
+	}
+
+	list := exec.Command(goBin, "list", "-deps", ".")
+	list.Env = env
+	out, err := list.Output()
+	if err != nil {
+		t.Fatalf("go list -deps failed: %v", err)
+	}
+	forbidden := []string{"github.com/hashicorp/go-plugin", "github.com/prometheus/", "go.opentelemetry.io/", "os/exec"}
+	for _, dep := range strings.Fields(string(out)) {
+		for _, f := range forbidden {
+			if strings.HasPrefix(dep, f) {
+				t.Errorf("the wasip1 build of the package depends on %s", dep)
+			}
+		}
+	}
+}
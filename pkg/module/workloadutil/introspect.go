@@ -0,0 +1,115 @@
+package workloadutil
+
+import (
+	"fmt"
+	"sort"
+
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+)
+
+// EnvVar is an environment variable of a workload container.
+type EnvVar struct {
+	// Container is the name of the container defining the variable
+	Container string
+	// Name is the name of the variable
+	Name string
+	// Value is the value of the variable, which may be a secret reference like secret://name/key
+	Value string
+}
+
+// VolumeKind is the kind of a path mounted into a workload container.
+type VolumeKind string
+
+const (
+	// VolumeFile is a file declared in the files of a container
+	VolumeFile VolumeKind = "file"
+	// VolumeDir is a directory declared in the dirs of a container
+	VolumeDir VolumeKind = "dir"
+)
+
+// Volume is a path mounted into a workload container.
+type Volume struct {
+	// Container is the name of the container mounting the path
+	Container string
+	// Path is the mount path in the container
+	Path string
+	// Kind is whether the path is a file or a directory
+	Kind VolumeKind
+	// Source is the content source of the path, e.g. the secret or config of a directory, or the
+	// contentFrom of a file, which is empty for inline file contents
+	Source string
+}
+
+// ContainerNames returns the sorted names of the containers of w, or nil if w is neither a service nor a job.
+func ContainerNames(w *workload.Workload) []string {
+	base := Base(w)
+	if base == nil {
+		return nil
+	}
+	names := make([]string, 0, len(base.Containers))
+	for name := range base.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ports returns the ports exposed by w. Jobs expose no ports.
+func Ports(w *workload.Workload) []workload.Port {
+	svc, ok := AsService(w)
+	if !ok {
+		return nil
+	}
+	return svc.Ports
+}
+
+// Env returns the environment variables of all containers of w, ordered by container name and then
+// by their order in the container.
+func Env(w *workload.Workload) []EnvVar {
+	base := Base(w)
+	if base == nil {
+		return nil
+	}
+	var envs []EnvVar
+	for _, name := range ContainerNames(w) {
+		for _, item := range base.Containers[name].Env {
+			envs = append(envs, EnvVar{Container: name, Name: fmt.Sprint(item.Key), Value: fmt.Sprint(item.Value)})
+		}
+	}
+	return envs
+}
+
+// LookupEnv returns the value of the environment variable name in the container, or false if it is not defined.
+func LookupEnv(w *workload.Workload, container, name string) (string, bool) {
+	for _, env := range Env(w) {
+		if env.Container == container && env.Name == name {
+			return env.Value, true
+		}
+	}
+	return "", false
+}
+
+// Volumes returns the files and directories mounted into the containers of w, ordered by container
+// name and then by path.
+func Volumes(w *workload.Workload) []Volume {
+	base := Base(w)
+	if base == nil {
+		return nil
+	}
+	var volumes []Volume
+	for _, name := range ContainerNames(w) {
+		c := base.Containers[name]
+		var containerVolumes []Volume
+		for path, file := range c.Files {
+			containerVolumes = append(containerVolumes, Volume{Container: name, Path: path, Kind: VolumeFile, Source: file.ContentFrom})
+		}
+		for path, source := range c.Dirs {
+			containerVolumes = append(containerVolumes, Volume{Container: name, Path: path, Kind: VolumeDir, Source: source})
+		}
+		sort.Slice(containerVolumes, func(i, j int) bool {
+			return containerVolumes[i].Path < containerVolumes[j].Path
+		})
+		volumes = append(volumes, containerVolumes...)
+	}
+	return volumes
+}
@@ -0,0 +1,58 @@
+// Package workloadutil provides typed accessors of Kusion workloads, so that modules handle
+// services and jobs safely instead of checking the workload type against string constants.
+package workloadutil
+
+import (
+	"fmt"
+
+	"kusionstack.io/kusion/pkg/apis/core/v1/workload"
+)
+
+// ServiceFunc handles a service workload.
+type ServiceFunc func(*workload.Service) error
+
+// JobFunc handles a job workload.
+type JobFunc func(*workload.Job) error
+
+// AsService returns the service of w, or false if w is not a service workload.
+func AsService(w *workload.Workload) (*workload.Service, bool) {
+	if w == nil || w.Header.Type != workload.TypeService || w.Service == nil {
+		return nil, false
+	}
+	return w.Service, true
+}
+
+// AsJob returns the job of w, or false if w is not a job workload.
+func AsJob(w *workload.Workload) (*workload.Job, bool) {
+	if w == nil || w.Header.Type != workload.TypeJob || w.Job == nil {
+		return nil, false
+	}
+	return w.Job, true
+}
+
+// Visit calls onService or onJob according to the type of w and returns its error. Both handlers
+// are required so that callers handle every workload type, and an error is returned for nil or
+// unknown workloads.
+func Visit(w *workload.Workload, onService ServiceFunc, onJob JobFunc) error {
+	if w == nil {
+		return fmt.Errorf("workload is nil")
+	}
+	if svc, ok := AsService(w); ok {
+		return onService(svc)
+	}
+	if job, ok := AsJob(w); ok {
+		return onJob(job)
+	}
+	return fmt.Errorf("unsupported workload type %q", w.Header.Type)
+}
+
+// Base returns the fields shared by service and job workloads, or nil if w is neither.
+func Base(w *workload.Workload) *workload.Base {
+	if svc, ok := AsService(w); ok {
+		return &svc.Base
+	}
+	if job, ok := AsJob(w); ok {
+		return &job.Base
+	}
+	return nil
+}
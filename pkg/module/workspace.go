@@ -0,0 +1,51 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+	v1 "kusionstack.io/kusion/pkg/apis/core/v1"
+)
+
+// WorkspaceMetadataKey is the gRPC request header carrying the YAML encoded workspace configuration,
+// sent by the engine to modules declaring RequiresWorkspace in their ModuleInfo.
+const WorkspaceMetadataKey = "kusion-module-workspace-bin"
+
+// WithWorkspaceAccess opts the module in to read the whole workspace configuration with
+// GeneratorRequest.Workspace, such as the platform configs of other modules and the backend settings.
+// It is reported to the engine by the RequiresWorkspace field of ModuleInfo.
+func WithWorkspaceAccess() ServeOption {
+	return func(o *serveOptions) {
+		o.info.RequiresWorkspace = true
+	}
+}
+
+// Workspace returns the workspace configuration the request is made in. Every call decodes a new
+// copy, so modifying it has no effect on the engine or later calls. An error is returned if the module
+// has not opted in with WithWorkspaceAccess or the engine has not sent the workspace.
+func (r *GeneratorRequest) Workspace() (*v1.Workspace, error) {
+	if !r.workspaceAccess {
+		return nil, NewError(ErrCodeInternal, "workspace access is not enabled").
+			WithHint("serve the module with module.WithWorkspaceAccess()")
+	}
+	if len(r.workspace) == 0 {
+		return nil, NewError(ErrCodeInvalidRequest, "workspace is not sent by the engine").
+			WithHint("upgrade Kusion to a version supporting workspace access of modules")
+	}
+	ws := &v1.Workspace{}
+	if err := yaml.Unmarshal(r.workspace, ws); err != nil {
+		return nil, fmt.Errorf("unmarshal workspace failed. %w", err)
+	}
+	return ws, nil
+}
+
+// ContextWithWorkspace returns a copy of ctx sending ws to module plugins, used by hosts of modules.
+func ContextWithWorkspace(ctx context.Context, ws *v1.Workspace) (context.Context, error) {
+	out, err := yaml.Marshal(ws)
+	if err != nil {
+		return nil, fmt.Errorf("marshal workspace failed. %w", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, WorkspaceMetadataKey, string(out)), nil
+}
@@ -0,0 +1,82 @@
+package schemagen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// RenderMarkdown renders the schemas into a markdown reference with a table of the fields of every
+// schema, titled title. Nested schemas are linked from the types of the fields referencing them.
+func RenderMarkdown(title string, schemas []*Schema) []byte {
+	names := make(map[string]bool, len(schemas))
+	for _, s := range schemas {
+		names[s.Name] = true
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# %s\n", title)
+	for _, s := range schemas {
+		fmt.Fprintf(buf, "\n## %s\n\n", s.Name)
+		if s.Doc != "" {
+			fmt.Fprintf(buf, "%s\n\n", strings.TrimSpace(s.Doc))
+		}
+		if len(s.Fields) == 0 {
+			buf.WriteString("No fields.\n")
+			continue
+		}
+		buf.WriteString("| Field | Type | Required | Default | Description |\n")
+		buf.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, f := range s.Fields {
+			required := "yes"
+			if f.Optional {
+				required = "no"
+			}
+			def := ""
+			if f.Default != "" {
+				def = "`" + kclValue(f.Type, f.Default) + "`"
+			}
+			doc := strings.Join(strings.Fields(f.Doc), " ")
+			if f.Example != "" {
+				if doc != "" {
+					doc += " "
+				}
+				doc += "Example: `" + f.Example + "`"
+			}
+			fmt.Fprintf(buf, "| `%s` | %s | %s | %s | %s |\n", f.Name, markdownType(f.Type, names), required, def, escapeCell(doc))
+		}
+	}
+	return buf.Bytes()
+}
+
+// markdownType renders the KCL type, linking the schemas of names to their sections.
+func markdownType(typ string, names map[string]bool) string {
+	var out strings.Builder
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		if names[w] {
+			fmt.Fprintf(&out, "[%s](#%s)", w, strings.ToLower(w))
+		} else {
+			out.WriteString("`" + w + "`")
+		}
+		word.Reset()
+	}
+	for _, r := range typ {
+		switch r {
+		case '[', ']', '{', '}', ':':
+			flush()
+			out.WriteString(escapeCell(string(r)))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,102 @@
+package schemagen
+
+import (
+	"fmt"
+	"reflect"
+
+	"kusionstack.io/kusion-module-framework/pkg/internal/yamlfield"
+)
+
+// FromType builds the KCL schemas of the Go struct v, or a pointer to it. The first schema is
+// the one of v, followed by the schemas of nested structs.
+func FromType(v any) ([]*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schemagen: expected a struct, got %T", v)
+	}
+	g := &reflectGenerator{seen: map[reflect.Type]bool{}}
+	if _, err := g.schema(t); err != nil {
+		return nil, err
+	}
+	return g.schemas, nil
+}
+
+type reflectGenerator struct {
+	seen    map[reflect.Type]bool
+	schemas []*Schema
+}
+
+func (g *reflectGenerator) schema(t reflect.Type) (string, error) {
+	if g.seen[t] {
+		return t.Name(), nil
+	}
+	if t.Name() == "" {
+		return "", fmt.Errorf("schemagen: anonymous struct types are not supported")
+	}
+	g.seen[t] = true
+	s := &Schema{Name: t.Name()}
+	g.schemas = append(g.schemas, s)
+	fields, err := g.fields(t)
+	if err != nil {
+		return "", err
+	}
+	s.Fields = fields
+	return s.Name, nil
+}
+
+func (g *reflectGenerator) fields(t reflect.Type) ([]Field, error) {
+	var fields []Field
+	err := yamlfield.Walk(t, func(sf yamlfield.Field) error {
+		if sf.YAML.Inline {
+			return fmt.Errorf("schemagen: inlined field %s.%s must be a struct", t.Name(), sf.Name)
+		}
+		typ, err := g.kclType(sf.Type)
+		if err != nil {
+			return fmt.Errorf("schemagen: field %s.%s: %w", t.Name(), sf.Name, err)
+		}
+		fields = append(fields, Field{
+			Name:     sf.YAML.Name,
+			Type:     typ,
+			Optional: sf.YAML.OmitEmpty || sf.Type.Kind() == reflect.Pointer,
+			Default:  sf.Tag.Get("default"),
+			Doc:      sf.Tag.Get("description"),
+			Example:  sf.Tag.Get("example"),
+		})
+		return nil
+	})
+	return fields, err
+}
+
+func (g *reflectGenerator) kclType(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.kclType(t.Elem())
+	case reflect.Slice, reflect.Array:
+		elem, err := g.kclType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "[" + elem + "]", nil
+	case reflect.Map:
+		key, err := g.kclType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.kclType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "{" + key + ":" + elem + "}", nil
+	case reflect.Struct:
+		return g.schema(t)
+	case reflect.Interface:
+		return "any", nil
+	}
+	if typ, ok := kclScalar(t.Kind().String()); ok {
+		return typ, nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
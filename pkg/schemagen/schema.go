@@ -0,0 +1,112 @@
+// Package schemagen generates KCL schemas from the Go config structs of modules, so that the
+// KCL schema of a module and the Go struct decoding its config never drift apart.
+//
+// Schemas are built from Go types by reflection (FromType) or from Go source files (FromSource),
+// and rendered by Render. Field names follow the yaml tags, defaults are read from the `default`
+// tag, examples from the `example` tag and docs from the `description` tag or, for source files, the
+// doc comments of the fields. RenderMarkdown renders the schemas as a config reference for registries.
+package schemagen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Schema is a KCL schema.
+type Schema struct {
+	// Name is the schema name
+	Name string
+	// Doc is the schema docstring
+	Doc string
+	// Fields are the schema attributes in declaration order
+	Fields []Field
+}
+
+// Field is an attribute of a KCL schema.
+type Field struct {
+	// Name is the attribute name
+	Name string
+	// Type is the KCL type of the attribute, e.g. str, [int] or {str:str}
+	Type string
+	// Optional reports whether the attribute may be omitted
+	Optional bool
+	// Default is the default value in Go syntax as written in the default tag, empty if none
+	Default string
+	// Doc is the attribute doc
+	Doc string
+	// Example is an example value as written in the example tag, empty if none
+	Example string
+}
+
+// Render renders the schemas into a KCL file.
+func Render(schemas []*Schema) []byte {
+	buf := &bytes.Buffer{}
+	for i, s := range schemas {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		renderSchema(buf, s)
+	}
+	return buf.Bytes()
+}
+
+func renderSchema(buf *bytes.Buffer, s *Schema) {
+	fmt.Fprintf(buf, "schema %s:\n", s.Name)
+	if s.Doc != "" {
+		fmt.Fprintf(buf, "    \"\"\" %s\n    \"\"\"\n", strings.ReplaceAll(strings.TrimSpace(s.Doc), "\n", "\n    "))
+	}
+	if len(s.Fields) == 0 {
+		buf.WriteString("    pass\n")
+		return
+	}
+	for _, f := range s.Fields {
+		if f.Doc != "" {
+			for _, line := range strings.Split(strings.TrimSpace(f.Doc), "\n") {
+				fmt.Fprintf(buf, "    # %s\n", strings.TrimSpace(line))
+			}
+		}
+		name := f.Name
+		if f.Optional {
+			name += "?"
+		}
+		fmt.Fprintf(buf, "    %s: %s", name, f.Type)
+		if f.Default != "" {
+			fmt.Fprintf(buf, " = %s", kclValue(f.Type, f.Default))
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// kclValue converts a default value written in the default tag into a KCL literal of type typ.
+func kclValue(typ, value string) string {
+	switch typ {
+	case "str":
+		return fmt.Sprintf("%q", value)
+	case "bool":
+		switch value {
+		case "true":
+			return "True"
+		case "false":
+			return "False"
+		}
+	}
+	return value
+}
+
+// kclScalar returns the KCL type of a Go scalar type name, or false if name is not a scalar type.
+func kclScalar(name string) (string, bool) {
+	switch name {
+	case "string":
+		return "str", true
+	case "bool":
+		return "bool", true
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "int", true
+	case "float32", "float64":
+		return "float", true
+	case "any", "interface{}":
+		return "any", true
+	}
+	return "", false
+}
@@ -0,0 +1,197 @@
+package schemagen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"kusionstack.io/kusion-module-framework/pkg/internal/yamlfield"
+)
+
+// FromSource builds the KCL schemas of the struct type named typeName declared in the Go files of dir.
+// Docs are read from the doc comments of the types and fields. Types declared in other packages are
+// rendered as any.
+func FromSource(dir, typeName string) ([]*Schema, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("schemagen: parse %s failed: %w", dir, err)
+	}
+	g := &sourceGenerator{types: map[string]*ast.TypeSpec{}, docs: map[string]string{}, seen: map[string]bool{}}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			g.collect(file)
+		}
+	}
+	if _, ok := g.types[typeName]; !ok {
+		return nil, fmt.Errorf("schemagen: type %s not found in %s", typeName, dir)
+	}
+	if _, err = g.schema(typeName); err != nil {
+		return nil, err
+	}
+	return g.schemas, nil
+}
+
+type sourceGenerator struct {
+	types   map[string]*ast.TypeSpec
+	docs    map[string]string
+	seen    map[string]bool
+	schemas []*Schema
+}
+
+func (g *sourceGenerator) collect(file *ast.File) {
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			g.types[ts.Name.Name] = ts
+			doc := ts.Doc.Text()
+			if doc == "" && len(gd.Specs) == 1 {
+				doc = gd.Doc.Text()
+			}
+			g.docs[ts.Name.Name] = doc
+		}
+	}
+}
+
+func (g *sourceGenerator) schema(name string) (string, error) {
+	if g.seen[name] {
+		return name, nil
+	}
+	st, ok := g.types[name].Type.(*ast.StructType)
+	if !ok {
+		return "", fmt.Errorf("schemagen: type %s is not a struct", name)
+	}
+	g.seen[name] = true
+	s := &Schema{Name: name, Doc: g.docs[name]}
+	g.schemas = append(g.schemas, s)
+	fields, err := g.fields(name, st)
+	if err != nil {
+		return "", err
+	}
+	s.Fields = fields
+	return name, nil
+}
+
+func (g *sourceGenerator) fields(typeName string, st *ast.StructType) ([]Field, error) {
+	var fields []Field
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			unquoted, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+		names := f.Names
+		if len(names) == 0 {
+			// embedded field
+			names = []*ast.Ident{ast.NewIdent(embeddedName(f.Type))}
+		}
+		for _, ident := range names {
+			if !ident.IsExported() {
+				continue
+			}
+			yamlTag := yamlfield.Parse(ident.Name, tag.Get("yaml"))
+			if yamlTag.Skip {
+				continue
+			}
+			if yamlTag.Inline {
+				inlined, err := g.inline(typeName, f.Type)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, inlined...)
+				continue
+			}
+			typ, err := g.kclType(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("schemagen: field %s.%s: %w", typeName, ident.Name, err)
+			}
+			doc := tag.Get("description")
+			if doc == "" {
+				doc = f.Doc.Text()
+			}
+			_, isPointer := f.Type.(*ast.StarExpr)
+			fields = append(fields, Field{
+				Name:     yamlTag.Name,
+				Type:     typ,
+				Optional: yamlTag.OmitEmpty || isPointer,
+				Default:  tag.Get("default"),
+				Doc:      strings.TrimSpace(doc),
+				Example:  tag.Get("example"),
+			})
+		}
+	}
+	return fields, nil
+}
+
+func (g *sourceGenerator) inline(typeName string, expr ast.Expr) ([]Field, error) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok || g.types[ident.Name] == nil {
+		return nil, fmt.Errorf("schemagen: inlined field of %s must be a struct declared in the same package", typeName)
+	}
+	st, ok := g.types[ident.Name].Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("schemagen: inlined type %s is not a struct", ident.Name)
+	}
+	return g.fields(ident.Name, st)
+}
+
+func (g *sourceGenerator) kclType(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return g.kclType(t.X)
+	case *ast.ArrayType:
+		elem, err := g.kclType(t.Elt)
+		if err != nil {
+			return "", err
+		}
+		return "[" + elem + "]", nil
+	case *ast.MapType:
+		key, err := g.kclType(t.Key)
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.kclType(t.Value)
+		if err != nil {
+			return "", err
+		}
+		return "{" + key + ":" + elem + "}", nil
+	case *ast.InterfaceType, *ast.SelectorExpr:
+		return "any", nil
+	case *ast.Ident:
+		if typ, ok := kclScalar(t.Name); ok {
+			return typ, nil
+		}
+		if ts, ok := g.types[t.Name]; ok {
+			if _, isStruct := ts.Type.(*ast.StructType); isStruct {
+				return g.schema(t.Name)
+			}
+			return g.kclType(ts.Type)
+		}
+	}
+	return "", fmt.Errorf("unsupported type %T", expr)
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}